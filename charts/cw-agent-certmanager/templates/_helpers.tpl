@@ -178,3 +178,23 @@ Validate that an API key is configured (either local or global)
 {{- fail "API key is required. Set apiKey.value, apiKey.existingSecret.name, or use global.apiKey in umbrella chart" }}
 {{- end }}
 {{- end }}
+
+{{/*
+Namespaced mode: only the listed namespaces are watched, through namespaced Roles.
+Returns "true" or an empty string.
+*/}}
+{{- define "cw-agent-certmanager.namespaced" -}}
+{{- if and (not .Values.agent.watchAllNamespaces) .Values.agent.namespaces -}}
+true
+{{- end -}}
+{{- end -}}
+
+{{/*
+Whether a ClusterRole is needed: always when watching all namespaces, otherwise
+only for ClusterIssuers or a namespace selector. Returns "true" or an empty string.
+*/}}
+{{- define "cw-agent-certmanager.clusterRBAC" -}}
+{{- if or (not (include "cw-agent-certmanager.namespaced" .)) .Values.agent.watchClusterIssuers .Values.agent.namespaceSelector -}}
+true
+{{- end -}}
+{{- end -}}
@@ -127,6 +127,10 @@ func loadConfig() (*config.Config, error) {
 	// Explicitly bind API key from environment variable
 	//nolint:errcheck // BindEnv always succeeds when args are valid
 	v.BindEnv("api.key", "CW_API_KEY")
+	//nolint:errcheck // BindEnv always succeeds when args are valid
+	v.BindEnv("api.key_file", "CW_API_KEY_FILE")
+	//nolint:errcheck // BindEnv always succeeds when args are valid
+	v.BindEnv("notify.email.password", "CW_NOTIFY_EMAIL_PASSWORD")
 
 	// Load config file if provided
 	if cfgFile != "" {
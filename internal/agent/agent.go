@@ -13,7 +13,13 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/discovery"
+	"github.com/certwatch-app/cw-agent/internal/history"
+	"github.com/certwatch-app/cw-agent/internal/leader"
 	"github.com/certwatch-app/cw-agent/internal/metrics"
+	"github.com/certwatch-app/cw-agent/internal/notify"
+	"github.com/certwatch-app/cw-agent/internal/outbox"
+	"github.com/certwatch-app/cw-agent/internal/proxy"
 	"github.com/certwatch-app/cw-agent/internal/scanner"
 	"github.com/certwatch-app/cw-agent/internal/server"
 	"github.com/certwatch-app/cw-agent/internal/state"
@@ -29,22 +35,83 @@ type Agent struct {
 	stateManager *state.Manager
 	logger       *zap.Logger
 	server       *server.Server
+	discoverer   *discovery.Discoverer
+	notifier     *notify.Notifier
+	history      *history.Store  // nil when disabled or unavailable
+	elector      *leader.Elector // nil unless leader election is enabled
+	lastPrune    time.Time
+	loadConfig   Loader
+	reloadCh     chan struct{}
+	configPath   string
+	targets      []config.CertificateConfig // Static certificates plus discovered targets
 	lastScan     []scanner.ScanResult
+	lastCerts    map[string]*scanner.CertificateInfo // Last certificate served by each target, by host:port
+	changes      []sync.CertificateEvent             // Change events not yet synced
+	logLevel     zap.AtomicLevel
 }
 
 // New creates a new Agent with the given configuration and state manager
 func New(cfg *config.Config, stateManager *state.Manager) (*Agent, error) {
-	// Setup logger
-	logger, err := setupLogger(cfg.Agent.LogLevel)
+	// Setup logger; the level can be changed on reload
+	logLevel := zap.NewAtomicLevelAt(parseLogLevel(cfg.Agent.LogLevel))
+	logger, err := setupLogger(logLevel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup logger: %w", err)
 	}
 
 	// Create scanner
-	s := scanner.New(cfg.API.Timeout, cfg.Agent.Concurrency, logger)
+	s := newScanner(cfg, logger)
 
 	// Create sync client with state manager
-	client := sync.New(cfg, logger, stateManager)
+	client, err := sync.New(cfg, logger, stateManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sync client: %w", err)
+	}
+	client.SetRetryObserver(metrics.RecordSyncRetry)
+	client.SetPayloadObserver(metrics.RecordSyncPayload)
+
+	// Queue undeliverable sync payloads next to the state file
+	if cfg.Agent.Outbox.Enabled {
+		q := outbox.New(stateManager.Dir(), int64(cfg.Agent.Outbox.MaxSizeMB)<<20, cfg.Agent.Outbox.MaxAge)
+		if err := q.Load(); err != nil {
+			logger.Warn("failed to load outbox", zap.Error(err))
+		}
+		client.SetOutbox(q)
+	}
+
+	// Create discovery sources if configured
+	var discoverer *discovery.Discoverer
+	if cfg.Discovery.Enabled() {
+		discoverer, err = discovery.FromConfig(&cfg.Discovery, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup discovery: %w", err)
+		}
+	}
+
+	// Send alerts locally if any sink is configured
+	notifier := newNotifier(cfg, stateManager, logger)
+
+	// Record scan results locally for the history command
+	var store *history.Store
+	if cfg.Agent.History.Enabled {
+		path := history.Path(cfg.Agent.History.Path, stateManager.Dir())
+		if store, err = history.Open(path, false); err != nil {
+			logger.Warn("scan history disabled", zap.Error(err))
+			store = nil
+		}
+	}
+
+	// Only the replica holding the Lease scans and syncs
+	var elector *leader.Elector
+	if cfg.Agent.LeaderElection.Enabled {
+		if elector, err = leader.New(&cfg.Agent.LeaderElection, logger); err != nil {
+			return nil, fmt.Errorf("failed to setup leader election: %w", err)
+		}
+		elector.SetObserver(func(leading bool) {
+			metrics.SetLeader(leading)
+			server.SetStandby(!leading)
+		})
+	}
 
 	// Create metrics/health server if enabled
 	var srv *server.Server
@@ -59,20 +126,52 @@ func New(cfg *config.Config, stateManager *state.Manager) (*Agent, error) {
 		stateManager: stateManager,
 		logger:       logger,
 		server:       srv,
+		discoverer:   discoverer,
+		notifier:     notifier,
+		history:      store,
+		elector:      elector,
+		reloadCh:     make(chan struct{}, 1),
+		targets:      cfg.Certificates,
+		logLevel:     logLevel,
 	}, nil
 }
 
-// Run starts the agent main loop
-func (a *Agent) Run(ctx context.Context) error {
-	a.logger.Info("agent starting",
-		zap.String("name", a.config.Agent.Name),
-		zap.Int("certificates", len(a.config.Certificates)),
-		zap.Duration("sync_interval", a.config.Agent.SyncInterval),
-		zap.Duration("scan_interval", a.config.Agent.ScanInterval),
-	)
+// newScanner creates a scanner for the given configuration
+func newScanner(cfg *config.Config, logger *zap.Logger) *scanner.Scanner {
+	s := scanner.New(cfg.API.Timeout, cfg.Agent.Concurrency, logger)
+	if cfg.Agent.Revocation.Enabled {
+		s.SetRevocationChecker(scanner.NewRevocationChecker(cfg.Agent.Revocation, cfg.API.Timeout, logger))
+	}
+	if cfg.Agent.TLSAudit.Enabled {
+		s.SetTLSAuditor(scanner.NewTLSAuditor(cfg.Agent.TLSAudit))
+	}
+	if cfg.Agent.ScanProxy.URL != "" {
+		d, err := proxy.New(cfg.Agent.ScanProxy.URL, cfg.Agent.ScanProxy.NoProxy, cfg.API.Timeout)
+		if err != nil {
+			logger.Warn("scanning without proxy", zap.Error(err))
+		} else {
+			s.SetDialer(d)
+		}
+	}
+	return s
+}
 
-	// Set initial metrics
-	metrics.SetCertificatesConfigured(len(a.config.Certificates))
+// newNotifier creates a notifier if any sink is configured
+func newNotifier(cfg *config.Config, stateManager *state.Manager, logger *zap.Logger) *notify.Notifier {
+	if !cfg.Notify.Enabled() {
+		return nil
+	}
+	n := notify.New(&cfg.Notify, cfg.Agent.Name, stateManager, logger)
+	n.SetObserver(metrics.RecordNotification)
+	return n
+}
+
+// Run starts the agent main loop. With leader election, the loop only runs
+// while this replica holds the Lease.
+func (a *Agent) Run(ctx context.Context) error {
+	if a.history != nil {
+		defer a.history.Close()
+	}
 
 	// Start metrics/health server if enabled
 	if a.server != nil {
@@ -86,6 +185,31 @@ func (a *Agent) Run(ctx context.Context) error {
 		}()
 	}
 
+	if a.elector != nil {
+		server.SetStandby(true)
+		return a.elector.Run(ctx, a.run)
+	}
+	metrics.SetLeader(true)
+	return a.run(ctx)
+}
+
+// run scans and syncs until ctx is done
+func (a *Agent) run(ctx context.Context) error {
+	// Resolve discovered targets before the first scan
+	if a.discoverer != nil {
+		a.discover(ctx)
+	}
+
+	a.logger.Info("agent starting",
+		zap.String("name", a.config.Agent.Name),
+		zap.Int("certificates", len(a.targets)),
+		zap.Duration("sync_interval", a.config.Agent.SyncInterval),
+		zap.Duration("scan_interval", a.config.Agent.ScanInterval),
+	)
+
+	// Set initial metrics
+	metrics.SetCertificatesConfigured(len(a.targets))
+
 	// Perform initial scan and sync
 	if err := a.scanAndSync(ctx); err != nil {
 		a.logger.Error("initial sync failed", zap.Error(err))
@@ -109,15 +233,33 @@ func (a *Agent) Run(ctx context.Context) error {
 	defer scanTicker.Stop()
 
 	// Setup heartbeat ticker if enabled
-	var heartbeatTicker *time.Ticker
-	var heartbeatChan <-chan time.Time
-	if a.config.Agent.HeartbeatInterval > 0 {
-		heartbeatTicker = time.NewTicker(a.config.Agent.HeartbeatInterval)
-		heartbeatChan = heartbeatTicker.C
-		defer heartbeatTicker.Stop()
+	heartbeatTicker, heartbeatChan := resetTicker(nil, a.config.Agent.HeartbeatInterval)
+	if heartbeatTicker != nil {
 		a.logger.Info("heartbeat enabled", zap.Duration("interval", a.config.Agent.HeartbeatInterval))
 	}
 
+	// Setup discovery ticker if enabled
+	var discoveryTicker *time.Ticker
+	var discoveryChan <-chan time.Time
+	if a.discoverer != nil {
+		discoveryTicker, discoveryChan = resetTicker(nil, a.config.Discovery.Interval)
+	}
+
+	// The optional tickers may be replaced on reload
+	defer func() {
+		if heartbeatTicker != nil {
+			heartbeatTicker.Stop()
+		}
+		if discoveryTicker != nil {
+			discoveryTicker.Stop()
+		}
+	}()
+
+	// Watch the config file for changes
+	if a.loadConfig != nil && a.configPath != "" {
+		go a.watchConfig(ctx)
+	}
+
 	// Start uptime counter
 	go a.trackUptime(ctx)
 
@@ -140,15 +282,66 @@ func (a *Agent) Run(ctx context.Context) error {
 				a.logger.Error("sync failed", zap.Error(err))
 			}
 
+		case <-discoveryChan:
+			a.logger.Debug("discovery interval triggered")
+			a.discover(ctx)
+
 		case <-heartbeatChan:
 			a.logger.Debug("heartbeat interval triggered")
 			if err := a.sendHeartbeat(ctx); err != nil {
 				a.logger.Error("heartbeat failed", zap.Error(err))
 			}
+
+		case <-a.reloadCh:
+			previous := a.config.Agent
+			previousDiscovery := a.config.Discovery.Interval
+			if err := a.reloadConfig(ctx); err != nil {
+				metrics.RecordConfigReload(false)
+				a.logger.Error("configuration reload rejected, keeping current configuration", zap.Error(err))
+				continue
+			}
+			metrics.RecordConfigReload(true)
+
+			if a.config.Agent.SyncInterval != previous.SyncInterval {
+				syncTicker.Reset(a.config.Agent.SyncInterval)
+			}
+			if a.config.Agent.ScanInterval != previous.ScanInterval {
+				scanTicker.Reset(a.config.Agent.ScanInterval)
+			}
+			if a.config.Agent.HeartbeatInterval != previous.HeartbeatInterval {
+				heartbeatTicker, heartbeatChan = resetTicker(heartbeatTicker, a.config.Agent.HeartbeatInterval)
+			}
+			if a.discoverer == nil {
+				discoveryTicker, discoveryChan = resetTicker(discoveryTicker, 0)
+			} else if discoveryTicker == nil || a.config.Discovery.Interval != previousDiscovery {
+				discoveryTicker, discoveryChan = resetTicker(discoveryTicker, a.config.Discovery.Interval)
+			}
+
+			// Scan right away so results match the new certificate list
+			if err := a.scan(ctx); err != nil {
+				a.logger.Error("scan failed", zap.Error(err))
+			}
 		}
 	}
 }
 
+// resetTicker changes the interval of t, creating it if needed. A non-positive
+// interval stops t and returns a nil channel, which never fires in a select.
+func resetTicker(t *time.Ticker, d time.Duration) (*time.Ticker, <-chan time.Time) {
+	if d <= 0 {
+		if t != nil {
+			t.Stop()
+		}
+		return nil, nil
+	}
+	if t == nil {
+		t = time.NewTicker(d)
+	} else {
+		t.Reset(d)
+	}
+	return t, t.C
+}
+
 // scanAndSync performs a scan and immediately syncs results
 func (a *Agent) scanAndSync(ctx context.Context) error {
 	if err := a.scan(ctx); err != nil {
@@ -165,24 +358,30 @@ func (a *Agent) scanAndSync(ctx context.Context) error {
 // scan performs certificate scanning
 func (a *Agent) scan(ctx context.Context) error {
 	start := time.Now()
+	if len(a.targets) == 0 {
+		a.logger.Warn("no certificates to scan")
+		return nil
+	}
+
 	a.logger.Info("starting certificate scan",
-		zap.Int("certificates", len(a.config.Certificates)),
+		zap.Int("certificates", len(a.targets)),
 	)
 
-	results := a.scanner.ScanAll(ctx, a.config.Certificates)
+	results := a.scanner.ScanAll(ctx, a.targets)
 	a.lastScan = results
+	a.trackChanges(results)
 
 	// Count successes and failures, update metrics
 	successCount := 0
 	failCount := 0
-	scanDuration := time.Since(start).Seconds() / float64(len(a.config.Certificates))
+	scanDuration := time.Since(start).Seconds() / float64(len(a.targets))
 
 	for _, r := range results {
 		portStr := strconv.Itoa(r.Port)
 
 		if r.Success {
 			successCount++
-			metrics.RecordScanSuccess(r.Hostname, scanDuration)
+			metrics.RecordScanSuccess(r.Name(), scanDuration)
 
 			// Update certificate metrics
 			if r.Certificate != nil {
@@ -196,7 +395,7 @@ func (a *Agent) scan(ctx context.Context) error {
 				chainValid := r.Chain != nil && r.Chain.Valid
 
 				metrics.RecordCertificateMetrics(
-					r.Hostname,
+					r.Name(),
 					portStr,
 					daysUntilExpiry,
 					expiryTimestamp,
@@ -204,9 +403,33 @@ func (a *Agent) scan(ctx context.Context) error {
 					chainValid,
 				)
 			}
+
+			if len(r.Addresses) > 0 {
+				days := make(map[string]float64, len(r.Addresses))
+				up := make(map[string]bool, len(r.Addresses))
+				for _, addr := range r.Addresses {
+					up[addr.Address] = addr.Success
+					if addr.Success {
+						days[addr.Address] = float64(int(time.Until(addr.NotAfter).Hours() / 24))
+					}
+				}
+				metrics.RecordAddressExpiry(r.Name(), portStr, days)
+				metrics.RecordAddressUp(r.Name(), portStr, up)
+			}
+
+			if r.TLS != nil && r.TLS.Audited {
+				issueTypes := make([]string, 0, len(r.TLS.Issues))
+				for _, issue := range r.TLS.Issues {
+					issueTypes = append(issueTypes, issue.Type)
+				}
+				metrics.RecordTLSAudit(r.Name(), portStr, scanner.AuditedVersions(), r.TLS.SupportedVersions, issueTypes)
+			} else {
+				metrics.ClearTLSAudit(r.Name(), portStr)
+			}
 		} else {
 			failCount++
-			metrics.RecordScanFailure(r.Hostname, scanDuration)
+			metrics.RecordScanFailure(r.Name(), scanDuration)
+			metrics.ClearTLSAudit(r.Name(), portStr)
 		}
 	}
 
@@ -219,9 +442,71 @@ func (a *Agent) scan(ctx context.Context) error {
 		zap.Int("failed", failCount),
 	)
 
+	// Alert locally so expiry is reported even when the API is unreachable
+	if a.notifier != nil {
+		a.notifier.EvaluateScan(ctx, results)
+	}
+
+	a.recordHistory(ctx, start, results)
+
 	return nil
 }
 
+// recordHistory adds scan results to the local history and prunes it hourly
+func (a *Agent) recordHistory(ctx context.Context, at time.Time, results []scanner.ScanResult) {
+	if a.history == nil {
+		return
+	}
+	if err := a.history.Record(ctx, at, results); err != nil {
+		a.logger.Warn("failed to record scan history", zap.Error(err))
+		return
+	}
+
+	if time.Since(a.lastPrune) < time.Hour {
+		return
+	}
+	a.lastPrune = time.Now()
+	pruned, err := a.history.Prune(ctx, time.Now().Add(-a.config.Agent.History.Retention))
+	if err != nil {
+		a.logger.Warn("failed to prune scan history", zap.Error(err))
+	} else if pruned > 0 {
+		a.logger.Debug("pruned scan history", zap.Int64("observations", pruned))
+	}
+}
+
+// discover refreshes the scan targets from the discovery sources and drops the
+// metrics of targets that are no longer present
+func (a *Agent) discover(ctx context.Context) {
+	targets, results := a.discoverer.Discover(ctx, a.config.Certificates)
+	for _, r := range results {
+		metrics.RecordDiscovery(r.Name, r.Targets, r.Err != nil)
+	}
+
+	a.setTargets(targets)
+
+	a.logger.Info("discovery complete",
+		zap.Int("static", len(a.config.Certificates)),
+		zap.Int("discovered", len(targets)-len(a.config.Certificates)),
+	)
+}
+
+// setTargets replaces the scan targets and drops the metrics of targets that are
+// no longer present
+func (a *Agent) setTargets(targets []config.CertificateConfig) {
+	current := make(map[string]bool, len(targets))
+	for i := range targets {
+		current[targets[i].GetHostPort()] = true
+	}
+	for i := range a.targets {
+		if !current[a.targets[i].GetHostPort()] {
+			metrics.DeleteCertificateMetrics(a.targets[i].Name(), strconv.Itoa(a.targets[i].Port))
+		}
+	}
+
+	a.targets = targets
+	metrics.SetCertificatesConfigured(len(targets))
+}
+
 // syncWithCloud sends scan results to the CertWatch API
 func (a *Agent) syncWithCloud(ctx context.Context) error {
 	if a.lastScan == nil {
@@ -234,8 +519,9 @@ func (a *Agent) syncWithCloud(ctx context.Context) error {
 	start := time.Now()
 	a.logger.Info("syncing with cloud")
 
-	resp, err := a.client.Sync(ctx, a.config.Certificates, a.lastScan)
+	resp, err := a.client.Sync(ctx, a.targets, a.lastScan)
 	duration := time.Since(start).Seconds()
+	metrics.SetOutboxPending(a.client.OutboxLen())
 
 	if err != nil {
 		metrics.RecordSyncFailure(duration)
@@ -266,9 +552,35 @@ func (a *Agent) syncWithCloud(ctx context.Context) error {
 		)
 	}
 
+	a.syncChanges(ctx)
+
 	return nil
 }
 
+// syncChanges sends the queued change events. Events that can't be delivered
+// are kept in the outbox when it is enabled, and pending until the next sync
+// otherwise.
+func (a *Agent) syncChanges(ctx context.Context) {
+	if len(a.changes) == 0 {
+		return
+	}
+
+	// Keep the events until they are sent or queued in the outbox, so a failed
+	// send is retried with the next sync
+	events := a.changes
+	err := a.client.SyncCertificateEvents(ctx, events)
+	if err != nil && !errors.Is(err, sync.ErrQueued) {
+		a.logger.Error("change event sync failed", zap.Error(err), zap.Int("events", len(events)))
+		return
+	}
+	a.changes = nil
+	if err != nil {
+		a.logger.Warn("change event sync queued", zap.Error(err), zap.Int("events", len(events)))
+		return
+	}
+	a.logger.Info("change event sync completed", zap.Int("events", len(events)))
+}
+
 // sendHeartbeat sends a heartbeat to the CertWatch API
 func (a *Agent) sendHeartbeat(ctx context.Context) error {
 	start := time.Now()
@@ -277,7 +589,7 @@ func (a *Agent) sendHeartbeat(ctx context.Context) error {
 	lastScan, _ := server.GetLastScan()
 	lastSync, _ := server.GetLastSync()
 
-	err := a.client.Heartbeat(ctx, len(a.config.Certificates), lastScan, lastSync)
+	err := a.client.Heartbeat(ctx, len(a.targets), lastScan, lastSync)
 	duration := time.Since(start).Seconds()
 
 	if err != nil {
@@ -332,22 +644,22 @@ func (a *Agent) trackUptime(ctx context.Context) {
 	}
 }
 
-// setupLogger creates a configured zap logger
-func setupLogger(level string) (*zap.Logger, error) {
-	// Parse log level
-	var zapLevel zapcore.Level
+// parseLogLevel maps a config log level to a zap level, defaulting to info
+func parseLogLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
+		return zapcore.DebugLevel
 	case "warn":
-		zapLevel = zapcore.WarnLevel
+		return zapcore.WarnLevel
 	case "error":
-		zapLevel = zapcore.ErrorLevel
+		return zapcore.ErrorLevel
 	default:
-		zapLevel = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	}
+}
+
+// setupLogger creates a configured zap logger
+func setupLogger(level zap.AtomicLevel) (*zap.Logger, error) {
 
 	// Create encoder config
 	encoderConfig := zapcore.EncoderConfig{
@@ -368,7 +680,7 @@ func setupLogger(level string) (*zap.Logger, error) {
 	core := zapcore.NewCore(
 		zapcore.NewConsoleEncoder(encoderConfig),
 		zapcore.AddSync(os.Stdout),
-		zapLevel,
+		level,
 	)
 
 	return zap.New(core), nil
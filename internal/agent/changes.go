@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/history"
+	"github.com/certwatch-app/cw-agent/internal/metrics"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/sync"
+)
+
+// maxPendingChanges bounds the change events kept while the API is unreachable
+const maxPendingChanges = 1000
+
+// trackChanges compares the certificates in results with those of the previous
+// scan, records the changes and queues them for the next sync. A failed scan
+// keeps the previous certificate, so a renewal during an outage is still found.
+func (a *Agent) trackChanges(results []scanner.ScanResult) {
+	seen := make(map[string]*scanner.CertificateInfo, len(results))
+	for i := range results {
+		r := &results[i]
+		key := r.GetHostPort()
+		prev := a.lastCerts[key]
+		if !r.Success || r.Certificate == nil {
+			if prev != nil {
+				seen[key] = prev
+			}
+			continue
+		}
+		seen[key] = r.Certificate
+		if prev == nil {
+			continue
+		}
+
+		for _, e := range certificateChanges(prev, r.Certificate) {
+			e.Timestamp = r.ScannedAt
+			if e.Timestamp.IsZero() {
+				e.Timestamp = time.Now()
+			}
+			e.Hostname, e.ServerName, e.Port = r.Hostname, r.ServerName, r.Port
+			a.recordChange(r.Name(), e)
+		}
+	}
+	a.lastCerts = seen
+}
+
+// recordChange logs and counts a change event and queues it for sync
+func (a *Agent) recordChange(name string, e sync.CertificateEvent) {
+	metrics.RecordCertificateChange(name, strconv.Itoa(e.Port), e.Type)
+
+	log := a.logger.Info
+	if e.Type == history.ChangeValidityDowngraded {
+		log = a.logger.Warn
+	}
+	log("certificate changed",
+		zap.String("hostname", name),
+		zap.Int("port", e.Port),
+		zap.String("type", e.Type),
+		zap.String("previous", e.Previous),
+		zap.String("current", e.Current),
+	)
+
+	if len(a.changes) >= maxPendingChanges {
+		a.changes = a.changes[1:]
+	}
+	a.changes = append(a.changes, e)
+}
+
+// certificateChanges describes how cur differs from prev. Only Type, Message,
+// Previous and Current are set.
+func certificateChanges(prev, cur *scanner.CertificateInfo) []sync.CertificateEvent {
+	changes := history.CompareCertificates(history.CertificateOf(prev), history.CertificateOf(cur))
+	events := make([]sync.CertificateEvent, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, sync.CertificateEvent{
+			Type: c.Kind, Message: c.Message, Previous: c.Previous, Current: c.Current,
+		})
+	}
+	return events
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/certwatch-app/cw-agent/internal/history"
+	"github.com/certwatch-app/cw-agent/internal/metrics"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/state"
+	"github.com/certwatch-app/cw-agent/internal/sync"
+)
+
+func certificate(fingerprint, issuer string, notAfter time.Time, sans ...string) *scanner.CertificateInfo {
+	return &scanner.CertificateInfo{
+		FingerprintSHA256: fingerprint,
+		Issuer:            issuer,
+		KeyAlgorithm:      "RSA",
+		KeySize:           2048,
+		SANList:           sans,
+		NotAfter:          notAfter,
+	}
+}
+
+func TestCertificateChanges(t *testing.T) {
+	expiry := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	prev := certificate("aa", "CN=R3", expiry, "example.com", "www.example.com")
+
+	ecdsa := certificate("bb", "CN=E5", expiry.Add(-24*time.Hour), "example.com", "api.example.com")
+	ecdsa.KeyAlgorithm, ecdsa.KeySize = "ECDSA", 256
+
+	tests := []struct {
+		name string
+		cur  *scanner.CertificateInfo
+		want []string
+	}{
+		{"unchanged", certificate("aa", "CN=R3", expiry, "example.com", "www.example.com"), nil},
+		{"renewed", certificate("bb", "CN=R3", expiry.AddDate(0, 3, 0), "example.com", "www.example.com"),
+			[]string{history.ChangeRenewed}},
+		{"replaced", ecdsa, []string{
+			history.ChangeValidityDowngraded, history.ChangeIssuerChanged, history.ChangeKeyAlgorithmChanged,
+			history.ChangeSANsAdded, history.ChangeSANsRemoved,
+		}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range certificateChanges(prev, tt.cur) {
+			got = append(got, e.Type)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: changes = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: changes = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+
+	changes := certificateChanges(prev, ecdsa)
+	if changes[2].Previous != "RSA 2048" || changes[2].Current != "ECDSA 256" {
+		t.Errorf("key change = %s -> %s, want RSA 2048 -> ECDSA 256", changes[2].Previous, changes[2].Current)
+	}
+	if changes[3].Current != "api.example.com" || changes[4].Previous != "www.example.com" {
+		t.Errorf("SAN changes = %+v, %+v", changes[3], changes[4])
+	}
+}
+
+func TestTrackChangesAndSync(t *testing.T) {
+	var received []sync.CertificateEvent
+	reject := true
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agent/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if reject {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req sync.CertificateEventSyncRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		received = append(received, req.Events...)
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer api.Close()
+
+	path := filepath.Join(t.TempDir(), "certwatch.yaml")
+	writeConfig(t, path, api.URL, "", "certificates:\n  - hostname: rotating.changes.test")
+	cfg, err := fileLoader(path)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(cfg, state.NewManagerWithStateDir(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiry := time.Now().Add(30 * 24 * time.Hour)
+	scan := func(c *scanner.CertificateInfo) []scanner.ScanResult {
+		r := scanner.ScanResult{Hostname: "rotating.changes.test", Port: 443, Success: c != nil, Certificate: c}
+		if c == nil {
+			r.Error = "connection refused"
+		}
+		return []scanner.ScanResult{r}
+	}
+
+	a.trackChanges(scan(certificate("aa", "CN=R3", expiry)))
+	a.trackChanges(scan(nil))
+	a.trackChanges(scan(certificate("bb", "CN=R3", expiry.AddDate(0, 2, 0))))
+
+	// The renewal during the outage is found against the certificate seen before it
+	if len(a.changes) != 1 || a.changes[0].Type != history.ChangeRenewed || a.changes[0].Previous != "aa" {
+		t.Fatalf("changes = %+v, want one renewal from aa", a.changes)
+	}
+	if got := testutil.ToFloat64(metrics.CertChangesTotal.WithLabelValues("rotating.changes.test", "443", history.ChangeRenewed)); got != 1 {
+		t.Errorf("changes_total = %v, want 1", got)
+	}
+
+	// A rejected send keeps the events for the next sync
+	a.syncChanges(context.Background())
+	if len(a.changes) != 1 {
+		t.Fatalf("%d changes pending after a failed sync, want 1", len(a.changes))
+	}
+
+	reject = false
+	a.syncChanges(context.Background())
+	if len(received) != 1 || received[0].Hostname != "rotating.changes.test" || received[0].Type != history.ChangeRenewed {
+		t.Errorf("API received %+v, want the renewal", received)
+	}
+	if len(a.changes) != 0 {
+		t.Errorf("%d changes still pending after sync", len(a.changes))
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/discovery"
+)
+
+// reloadDebounce coalesces the burst of events an editor or a ConfigMap update
+// produces into a single reload
+const reloadDebounce = 500 * time.Millisecond
+
+// Loader reads the configuration again, e.g. from the config file
+type Loader func() (*config.Config, error)
+
+// SetConfigWatcher enables hot reload: the file at path is watched for changes
+// and load is called to read the new configuration. Reload can be triggered
+// explicitly, e.g. on SIGHUP, even if path is empty.
+func (a *Agent) SetConfigWatcher(path string, load Loader) {
+	if path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	a.configPath = path
+	a.loadConfig = load
+}
+
+// Reload requests a configuration reload. It doesn't block; the reload runs in
+// the agent loop and requests made while one is pending are merged.
+func (a *Agent) Reload() {
+	select {
+	case a.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// reloadConfig loads and validates the new configuration and swaps it in. On
+// error the current configuration is left untouched.
+func (a *Agent) reloadConfig(ctx context.Context) error {
+	if a.loadConfig == nil {
+		return fmt.Errorf("reload is not enabled")
+	}
+
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Settings bound to the API client, the agent identity or open ports need a restart
+	var ignored []string
+	if !reflect.DeepEqual(cfg.API, a.config.API) {
+		ignored = append(ignored, "api")
+		cfg.API = a.config.API
+	}
+	if cfg.Agent.Name != a.config.Agent.Name {
+		ignored = append(ignored, "agent.name")
+		cfg.Agent.Name = a.config.Agent.Name
+	}
+	if cfg.Agent.MetricsPort != a.config.Agent.MetricsPort {
+		ignored = append(ignored, "agent.metrics_port")
+		cfg.Agent.MetricsPort = a.config.Agent.MetricsPort
+	}
+	if cfg.Agent.Outbox != a.config.Agent.Outbox {
+		ignored = append(ignored, "agent.outbox")
+		cfg.Agent.Outbox = a.config.Agent.Outbox
+	}
+	if cfg.Agent.History != a.config.Agent.History {
+		ignored = append(ignored, "agent.history")
+		cfg.Agent.History = a.config.Agent.History
+	}
+	if cfg.Agent.LeaderElection != a.config.Agent.LeaderElection {
+		ignored = append(ignored, "agent.leader_election")
+		cfg.Agent.LeaderElection = a.config.Agent.LeaderElection
+	}
+
+	// Keep the discoverer, and with it the last results of each source, unless its
+	// sources changed
+	discoverer := a.discoverer
+	if !reflect.DeepEqual(cfg.Discovery, a.config.Discovery) {
+		discoverer = nil
+		if cfg.Discovery.Enabled() {
+			if discoverer, err = discovery.FromConfig(&cfg.Discovery, a.logger); err != nil {
+				return fmt.Errorf("failed to setup discovery: %w", err)
+			}
+		}
+	}
+
+	// Everything below is applied together; nothing can fail from here on
+	a.config = cfg
+	a.logLevel.SetLevel(parseLogLevel(cfg.Agent.LogLevel))
+	a.scanner = newScanner(cfg, a.logger)
+	a.notifier = newNotifier(cfg, a.stateManager, a.logger)
+	a.client.SetHeartbeatInterval(cfg.Agent.HeartbeatInterval)
+	a.discoverer = discoverer
+	if discoverer != nil {
+		a.discover(ctx)
+	} else {
+		a.setTargets(cfg.Certificates)
+	}
+
+	if len(ignored) > 0 {
+		a.logger.Warn("configuration changes require a restart to take effect",
+			zap.String("settings", strings.Join(ignored, ", ")),
+		)
+	}
+	a.logger.Info("configuration reloaded",
+		zap.Int("certificates", len(a.targets)),
+		zap.Duration("sync_interval", cfg.Agent.SyncInterval),
+		zap.Duration("scan_interval", cfg.Agent.ScanInterval),
+		zap.String("log_level", cfg.Agent.LogLevel),
+	)
+	return nil
+}
+
+// watchConfig requests a reload whenever the config file changes
+func (a *Agent) watchConfig(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		a.logger.Warn("failed to watch config file, reload with SIGHUP instead", zap.Error(err))
+		return
+	}
+	defer watcher.Close()
+
+	// Watch the directory rather than the file: editors and Kubernetes ConfigMap
+	// updates replace the file (or the symlink pointing to it) instead of writing to it
+	if err := watcher.Add(filepath.Dir(a.configPath)); err != nil {
+		a.logger.Warn("failed to watch config file, reload with SIGHUP instead",
+			zap.String("path", a.configPath),
+			zap.Error(err),
+		)
+		return
+	}
+	a.logger.Info("watching config file for changes", zap.String("path", a.configPath))
+
+	realPath, _ := filepath.EvalSymlinks(a.configPath)
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			current, _ := filepath.EvalSymlinks(a.configPath)
+			written := filepath.Clean(event.Name) == a.configPath && event.Has(fsnotify.Write|fsnotify.Create)
+			if written || (current != "" && current != realPath) {
+				realPath = current
+				debounce.Reset(reloadDebounce)
+			}
+
+		case <-debounce.C:
+			a.logger.Info("config file changed, reloading", zap.String("path", a.configPath))
+			a.Reload()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			a.logger.Warn("config watcher error", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/metrics"
+	"github.com/certwatch-app/cw-agent/internal/state"
+)
+
+// writeConfig writes a config with the given extra agent settings and certificates section
+func writeConfig(t *testing.T, path, endpoint, agentExtra, certificates string) {
+	t.Helper()
+	data := fmt.Sprintf(`api:
+  endpoint: %s
+  key: cw_test_key
+agent:
+  name: test-agent
+  metrics_port: 0
+  revocation:
+    enabled: false
+%s
+%s
+`, endpoint, agentExtra, certificates)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+// fileLoader reads the config at path with a fresh viper instance
+func fileLoader(path string) Loader {
+	return func() (*config.Config, error) {
+		v := viper.New()
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+		return config.Load(v)
+	}
+}
+
+func newTestAgent(t *testing.T, certificates string) (*Agent, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "certwatch.yaml")
+	writeConfig(t, path, "https://api.certwatch.app", "", certificates)
+
+	cfg, err := fileLoader(path)()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+
+	a, err := New(cfg, state.NewManagerWithStateDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	a.SetConfigWatcher(path, fileLoader(path))
+	return a, path
+}
+
+func TestReloadConfig(t *testing.T) {
+	a, path := newTestAgent(t, `certificates:
+  - hostname: kept.reload.test
+  - hostname: removed.reload.test`)
+	metrics.RecordCertificateMetrics("removed.reload.test", "443", 30, 0, true, true)
+
+	writeConfig(t, path, "https://other.certwatch.app", `  log_level: debug
+  scan_interval: 2m`, `certificates:
+  - hostname: kept.reload.test
+  - hostname: added.reload.test`)
+
+	if err := a.reloadConfig(context.Background()); err != nil {
+		t.Fatalf("reloadConfig() error = %v", err)
+	}
+
+	if len(a.targets) != 2 || a.targets[1].Hostname != "added.reload.test" {
+		t.Errorf("targets = %+v, want kept and added", a.targets)
+	}
+	if a.config.Agent.ScanInterval != 2*time.Minute {
+		t.Errorf("ScanInterval = %v, want 2m", a.config.Agent.ScanInterval)
+	}
+	if a.logLevel.Level() != zapcore.DebugLevel {
+		t.Errorf("log level = %v, want debug", a.logLevel.Level())
+	}
+	if a.config.API.Endpoint != "https://api.certwatch.app" {
+		t.Errorf("Endpoint = %v, want the original endpoint until restart", a.config.API.Endpoint)
+	}
+	if n := testutil.CollectAndCount(metrics.CertDaysUntilExpiry); n != 0 {
+		t.Errorf("days_until_expiry series = %d, want 0 after removing the host", n)
+	}
+}
+
+func TestReloadConfig_RejectsInvalid(t *testing.T) {
+	a, path := newTestAgent(t, `certificates:
+  - hostname: example.reload.test`)
+	before := a.config
+
+	writeConfig(t, path, "https://api.certwatch.app", "  scan_interval: 1s", `certificates:
+  - hostname: other.reload.test`)
+	if err := a.reloadConfig(context.Background()); err == nil {
+		t.Fatal("reloadConfig() error = nil, want validation error")
+	}
+
+	if err := os.WriteFile(path, []byte("agent: [not yaml"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := a.reloadConfig(context.Background()); err == nil {
+		t.Fatal("reloadConfig() error = nil, want parse error")
+	}
+
+	if a.config != before || len(a.targets) != 1 || a.targets[0].Hostname != "example.reload.test" {
+		t.Errorf("configuration changed after rejected reloads: targets = %+v", a.targets)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	a, path := newTestAgent(t, `certificates:
+  - hostname: example.reload.test`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.watchConfig(ctx)
+
+	// Editors and ConfigMap updates replace the file. Repeat the change in case the
+	// watcher wasn't running yet, spaced out so the debounce can fire.
+	deadline := time.After(10 * time.Second)
+	tick := time.NewTicker(2 * reloadDebounce)
+	defer tick.Stop()
+	for {
+		select {
+		case <-a.reloadCh:
+			return
+		case <-tick.C:
+			tmp := path + ".tmp"
+			writeConfig(t, tmp, "https://api.certwatch.app", "", `certificates:
+  - hostname: new.reload.test`)
+			if err := os.Rename(tmp, path); err != nil {
+				t.Fatalf("failed to replace config: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("no reload requested after the config file changed")
+		}
+	}
+}
@@ -2,10 +2,12 @@ package certmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	gosync "sync"
 	"time"
 
+	acmeapi "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
@@ -13,15 +15,20 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/certwatch-app/cw-agent/internal/certmanager/config"
 	"github.com/certwatch-app/cw-agent/internal/certmanager/controller"
 	"github.com/certwatch-app/cw-agent/internal/certmanager/metrics"
 	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+	"github.com/certwatch-app/cw-agent/internal/notify"
+	"github.com/certwatch-app/cw-agent/internal/outbox"
 	"github.com/certwatch-app/cw-agent/internal/state"
 	"github.com/certwatch-app/cw-agent/internal/sync"
 	"github.com/certwatch-app/cw-agent/internal/version"
@@ -32,6 +39,7 @@ var scheme = runtime.NewScheme()
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(cmapi.AddToScheme(scheme))
+	utilruntime.Must(acmeapi.AddToScheme(scheme))
 }
 
 // Agent is the main cert-manager controller agent
@@ -40,15 +48,30 @@ type Agent struct {
 	logger       *zap.Logger
 	syncClient   *sync.Client
 	stateManager *state.Manager
+	notifier     *notify.Notifier // nil unless notify sinks are configured
+
+	// Undelivered events and CertificateRequest states are saved here; empty when disabled
+	checkpointPath string
+	checkpointMu   gosync.Mutex
+	eventSyncMu    gosync.Mutex // One event sync at a time so events aren't sent twice
+	acmeSyncMu     gosync.Mutex // One ACME sync at a time
+
+	// Certificates are synced in full at startup and every full_sync_interval,
+	// and as changes in between; only the sync loop touches this
+	lastFullSync time.Time
 
 	// Reconcilers
 	reconciler        *controller.CertificateReconciler
 	requestReconciler *controller.CertificateRequestReconciler
+	issuerReconciler  *controller.IssuerReconciler
+	acmeReconciler    *controller.ACMEReconciler // nil unless watch_acme is enabled
 	eventWatcher      *controller.EventWatcher
 
-	// Debounce immediate event sync to prevent rapid-fire API calls
+	// Debounce immediate event, issuer and ACME syncs to prevent rapid-fire API calls
 	immediateSyncMu       gosync.Mutex
 	immediateSyncPending  bool
+	issuerSyncPending     bool
+	acmeSyncPending       bool
 	immediateSyncDebounce time.Duration
 }
 
@@ -62,17 +85,64 @@ func New(cfg *config.Config, stateManager *state.Manager) (*Agent, error) {
 
 	// Create sync client using the config adapter
 	syncCfg := &sync.ClientConfig{
-		Endpoint: cfg.API.Endpoint,
-		APIKey:   cfg.API.Key,
-		Timeout:  cfg.API.Timeout,
+		Endpoint:  cfg.API.Endpoint,
+		KeySource: cfg.API.APIKey(),
+		Timeout:   cfg.API.Timeout,
+		Retry: sync.RetryPolicy{
+			MaxAttempts:    cfg.API.Retry.MaxAttempts,
+			InitialBackoff: cfg.API.Retry.InitialBackoff,
+			MaxBackoff:     cfg.API.Retry.MaxBackoff,
+		},
+		Proxy:       cfg.API.Proxy,
+		TLS:         cfg.API.TLS,
+		Compression: cfg.API.Compression,
+	}
+	syncClient, err := sync.NewWithConfig(syncCfg, cfg.Agent.Name, logger, stateManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sync client: %w", err)
+	}
+	syncClient.SetRetryObserver(func(operation, reason string) {
+		metrics.SyncRetriesTotal.WithLabelValues(operation, reason).Inc()
+	})
+	syncClient.SetPayloadObserver(func(kind string, size, sent int) {
+		metrics.SyncPayloadBytes.WithLabelValues(kind).Observe(float64(size))
+		metrics.SyncSentBytesTotal.WithLabelValues(kind).Add(float64(sent))
+	})
+
+	// Queue undeliverable sync payloads next to the state file
+	if cfg.Agent.Outbox.Enabled {
+		q := outbox.New(stateManager.Dir(), int64(cfg.Agent.Outbox.MaxSizeMB)<<20, cfg.Agent.Outbox.MaxAge)
+		if err := q.Load(); err != nil {
+			logger.Warn("failed to load outbox", zap.Error(err))
+		}
+		syncClient.SetOutbox(q)
+	}
+
+	// Send alerts locally if any sink is configured
+	var notifier *notify.Notifier
+	if cfg.Notify.Enabled() {
+		notifier = notify.New(&cfg.Notify, cfg.Agent.Name, stateManager, logger)
+		notifier.SetObserver(func(sink string, success bool) {
+			status := "success"
+			if !success {
+				status = "failure"
+			}
+			metrics.NotificationsTotal.WithLabelValues(sink, status).Inc()
+		})
+	}
+
+	var checkpointPath string
+	if cfg.Agent.Checkpoint.Enabled {
+		checkpointPath = controller.CheckpointPath(cfg.Agent.Checkpoint.Path, stateManager.Dir())
 	}
-	syncClient := sync.NewWithConfig(syncCfg, cfg.Agent.Name, logger, stateManager)
 
 	return &Agent{
 		config:                cfg,
 		logger:                logger,
 		syncClient:            syncClient,
 		stateManager:          stateManager,
+		notifier:              notifier,
+		checkpointPath:        checkpointPath,
 		immediateSyncDebounce: 2 * time.Second, // Wait 2s for events to batch up
 	}, nil
 }
@@ -97,6 +167,30 @@ func (a *Agent) Run(ctx context.Context) error {
 		HealthProbeBindAddress: fmt.Sprintf(":%d", a.config.Agent.MetricsPort+1), // Use next port for health
 	}
 
+	// Namespaced resources are only listed and watched in the configured
+	// namespaces, so the agent works with namespaced Roles
+	if namespaces := a.config.Agent.WatchedNamespaces(); len(namespaces) > 0 {
+		mgrOpts.Cache.DefaultNamespaces = make(map[string]cache.Config, len(namespaces))
+		for _, ns := range namespaces {
+			mgrOpts.Cache.DefaultNamespaces[ns] = cache.Config{}
+		}
+		a.logger.Info("watching namespaces", zap.Strings("namespaces", namespaces))
+	} else if !a.config.Agent.WatchAllNS {
+		a.logger.Warn("watch_all_namespaces is false but no namespaces are set, watching all namespaces")
+	}
+
+	// Reconcilers and the sync loops only run on the replica holding the Lease
+	if le := a.config.Agent.LeaderElection; le.Enabled {
+		mgrOpts.LeaderElection = true
+		mgrOpts.LeaderElectionResourceLock = resourcelock.LeasesResourceLock
+		mgrOpts.LeaderElectionID = le.LeaseName
+		mgrOpts.LeaderElectionNamespace = le.Namespace()
+		mgrOpts.LeaseDuration = &le.LeaseDuration
+		mgrOpts.RenewDeadline = &le.RenewDeadline
+		mgrOpts.RetryPeriod = &le.RetryPeriod
+		mgrOpts.LeaderElectionReleaseOnCancel = true
+	}
+
 	// Create manager
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), mgrOpts)
 	if err != nil {
@@ -117,6 +211,17 @@ func (a *Agent) Run(ctx context.Context) error {
 		mgr.GetScheme(),
 		a.logger,
 	)
+	filter, err := controller.NewCertificateFilter(a.config.Agent.LabelSelector, a.config.Agent.NamespaceSelector,
+		a.config.Agent.TagLabels, a.config.Agent.TagAnnotations)
+	if err != nil {
+		return fmt.Errorf("failed to setup certificate filter: %w", err)
+	}
+	a.reconciler.Filter = filter
+	if a.config.Agent.ScanSecrets {
+		// Read Secrets directly rather than through the cache so the agent doesn't
+		// hold every Secret in the cluster in memory
+		a.reconciler.SecretReader = mgr.GetAPIReader()
+	}
 	if err := a.reconciler.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("failed to setup certificate reconciler: %w", err)
 	}
@@ -135,11 +240,68 @@ func (a *Agent) Run(ctx context.Context) error {
 			zap.String("category", req.FailureCategory),
 		)
 		go a.doEventSync(ctx)
+		if a.notifier != nil {
+			go a.notifier.CertificateRequestFailed(ctx, req)
+		}
 	}
 	if err := a.requestReconciler.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("failed to setup certificaterequest reconciler: %w", err)
 	}
 
+	// Create and register Issuer/ClusterIssuer reconciler
+	a.issuerReconciler = controller.NewIssuerReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		a.logger,
+	)
+	a.issuerReconciler.SkipClusterIssuers = !a.config.Agent.WatchClusterIssuers
+	a.issuerReconciler.OnNotReady = func(issuer types.IssuerStatus) {
+		a.logger.Info("issuer not ready, triggering immediate issuer sync",
+			zap.String("kind", issuer.Kind),
+			zap.String("namespace", issuer.Namespace),
+			zap.String("name", issuer.Name),
+			zap.String("reason", issuer.ReadyReason),
+		)
+		go a.scheduleImmediateIssuerSync(ctx)
+		if a.notifier != nil {
+			go a.notifier.IssuerNotReady(ctx, issuer)
+		}
+	}
+	if err := a.issuerReconciler.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("failed to setup issuer reconciler: %w", err)
+	}
+
+	// Create and register ACME Order/Challenge reconciler
+	if a.config.Agent.WatchACME {
+		a.acmeReconciler = controller.NewACMEReconciler(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			a.logger,
+		)
+		a.acmeReconciler.OnOrderFailure = func(order types.ACMEOrderStatus) {
+			a.logger.Info("acme order failed, triggering immediate acme sync",
+				zap.String("namespace", order.Namespace),
+				zap.String("name", order.Name),
+				zap.String("certificate", order.CertificateName),
+				zap.String("problem", order.Problem),
+			)
+			go a.scheduleImmediateACMESync(ctx)
+		}
+		a.acmeReconciler.OnChallengeFailure = func(challenge types.ACMEChallengeStatus) {
+			a.logger.Info("acme challenge failed, triggering immediate acme sync",
+				zap.String("namespace", challenge.Namespace),
+				zap.String("name", challenge.Name),
+				zap.String("certificate", challenge.CertificateName),
+				zap.String("dns_name", challenge.DNSName),
+				zap.String("problem", challenge.Problem),
+			)
+			go a.scheduleImmediateACMESync(ctx)
+		}
+		if err := a.acmeReconciler.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("failed to setup acme reconciler: %w", err)
+		}
+	}
+
 	// Create and register Event watcher (Phase 2)
 	a.eventWatcher = controller.NewEventWatcher(
 		mgr.GetClient(),
@@ -159,12 +321,26 @@ func (a *Agent) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to setup event watcher: %w", err)
 	}
 
-	// Start sync loop in background
-	go a.syncLoop(ctx)
+	// Pick up events and request states that weren't delivered before a restart
+	a.restoreCheckpoint()
 
-	// Start heartbeat loop in background if enabled
-	if a.config.Agent.HeartbeatInterval > 0 {
-		go a.heartbeatLoop(ctx)
+	// Start the sync and heartbeat loops once elected (right away without
+	// leader election)
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		metrics.Leader.Set(1)
+		defer metrics.Leader.Set(0)
+		if a.config.Agent.HeartbeatInterval > 0 {
+			go a.heartbeatLoop(ctx)
+		}
+		// A full sync reports an empty cluster as such, so never send one
+		// from a cache that hasn't been listed yet
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return nil
+		}
+		a.syncLoop(ctx)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("failed to add sync loop: %w", err)
 	}
 
 	// Start manager (blocking)
@@ -186,21 +362,32 @@ func (a *Agent) syncLoop(ctx context.Context) {
 
 	// Initial sync after short delay for controller to populate
 	time.Sleep(10 * time.Second)
-	a.doSync(ctx)
-	a.doRequestSync(ctx) // Also sync CertificateRequests
+	a.syncAll(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
+			a.saveCheckpoint()
 			a.logger.Info("sync loop stopped")
 			return
 		case <-ticker.C:
-			a.doSync(ctx)
-			a.doRequestSync(ctx) // Also sync CertificateRequests
+			a.syncAll(ctx)
 		}
 	}
 }
 
+// syncAll syncs certificates, CertificateRequests, issuers, ACME Orders and
+// Challenges and any events not
+// delivered yet, then checkpoints what is still pending
+func (a *Agent) syncAll(ctx context.Context) {
+	a.doSync(ctx)
+	a.doRequestSync(ctx)
+	a.doIssuerSync(ctx)
+	a.doACMESync(ctx)
+	a.doEventSync(ctx)
+	a.saveCheckpoint()
+}
+
 func (a *Agent) heartbeatLoop(ctx context.Context) {
 	ticker := time.NewTicker(a.config.Agent.HeartbeatInterval)
 	defer ticker.Stop()
@@ -216,13 +403,28 @@ func (a *Agent) heartbeatLoop(ctx context.Context) {
 	}
 }
 
+// doSync sends the certificates that changed or were deleted since the last
+// sync, and every certificate on the first sync and each full_sync_interval
 func (a *Agent) doSync(ctx context.Context) {
 	start := time.Now()
-	certs := a.reconciler.GetCertificates()
-
-	if len(certs) == 0 {
-		a.logger.Debug("no certificates to sync")
-		return
+	full := a.lastFullSync.IsZero() || a.config.Agent.FullSyncInterval == 0 ||
+		start.Sub(a.lastFullSync) >= a.config.Agent.FullSyncInterval
+	mode := sync.SyncModeDelta
+	if full {
+		mode = sync.SyncModeFull
+	}
+
+	var certs []types.CertificateStatus
+	var deleted []types.CertificateRef
+	if full {
+		// Sent even when empty so the API learns the last one was deleted
+		certs = a.reconciler.GetCertificates()
+	} else {
+		certs, deleted = a.reconciler.Changes()
+		if len(certs) == 0 && len(deleted) == 0 {
+			a.logger.Debug("no certificate changes to sync")
+			return
+		}
 	}
 
 	// Convert to sync format
@@ -231,15 +433,38 @@ func (a *Agent) doSync(ctx context.Context) {
 		syncCerts = append(syncCerts, convertToSyncCert(certs[i]))
 	}
 
-	resp, err := a.syncClient.SyncCertManagerCertificates(ctx, a.config.Agent.ClusterName, syncCerts)
+	var resp *sync.CertManagerSyncResponse
+	var err error
+	if full {
+		resp, err = a.syncClient.SyncCertManagerCertificates(ctx, a.config.Agent.ClusterName, syncCerts)
+	} else {
+		refs := make([]sync.CertManagerCertificateRef, 0, len(deleted))
+		for _, ref := range deleted {
+			refs = append(refs, sync.CertManagerCertificateRef{Namespace: ref.Namespace, Name: ref.Name})
+		}
+		resp, err = a.syncClient.SyncCertManagerChanges(ctx, a.config.Agent.ClusterName, syncCerts, refs)
+	}
+	metrics.OutboxPending.Set(float64(a.syncClient.OutboxLen()))
+
+	// Queued payloads are delivered by the outbox, so they count as synced
+	if err == nil || errors.Is(err, sync.ErrQueued) {
+		if full {
+			a.reconciler.MarkAllSynced(certs)
+			a.lastFullSync = start
+		} else {
+			a.reconciler.MarkSynced(certs, deleted)
+		}
+	}
 	if err != nil {
-		a.logger.Error("sync failed", zap.Error(err))
+		a.logger.Error("sync failed", zap.String("mode", mode), zap.Error(err))
 		metrics.SyncTotal.WithLabelValues("error").Inc()
 		return
 	}
 
 	a.logger.Info("sync completed",
+		zap.String("mode", mode),
 		zap.Int("certificates", len(certs)),
+		zap.Int("deleted", len(deleted)),
 		zap.Int("created", resp.Data.Created),
 		zap.Int("updated", resp.Data.Updated),
 		zap.Int("unchanged", resp.Data.Unchanged),
@@ -267,65 +492,61 @@ func (a *Agent) doHeartbeat(ctx context.Context) {
 // scheduleImmediateEventSync schedules an event sync with debouncing.
 // Multiple rapid failure events will consolidate into a single sync after the debounce period.
 func (a *Agent) scheduleImmediateEventSync(ctx context.Context) {
+	a.debounce(&a.immediateSyncPending, "event", func() {
+		a.doEventSync(ctx)
+		a.saveCheckpoint()
+	})
+}
+
+// scheduleImmediateIssuerSync schedules an issuer sync with debouncing, so
+// many issuers going not ready at once cause a single sync
+func (a *Agent) scheduleImmediateIssuerSync(ctx context.Context) {
+	a.debounce(&a.issuerSyncPending, "issuer", func() {
+		a.doIssuerSync(ctx)
+	})
+}
+
+// scheduleImmediateACMESync schedules an ACME sync with debouncing, so a batch
+// of failed Challenges causes a single sync
+func (a *Agent) scheduleImmediateACMESync(ctx context.Context) {
+	a.debounce(&a.acmeSyncPending, "acme", func() {
+		a.doACMESync(ctx)
+	})
+}
+
+// debounce runs run after the debounce period unless one is already
+// scheduled. pending is guarded by immediateSyncMu.
+func (a *Agent) debounce(pending *bool, kind string, run func()) {
 	a.immediateSyncMu.Lock()
-	if a.immediateSyncPending {
-		// Already have a pending sync scheduled, this event will be included
+	if *pending {
+		// Already have a pending sync scheduled, this change will be included
 		a.immediateSyncMu.Unlock()
-		a.logger.Debug("immediate event sync already scheduled, skipping duplicate")
+		a.logger.Debug("immediate sync already scheduled, skipping duplicate", zap.String("kind", kind))
 		return
 	}
-	a.immediateSyncPending = true
+	*pending = true
 	a.immediateSyncMu.Unlock()
 
-	// Wait for debounce period to allow events to batch up
+	// Wait for debounce period to allow changes to batch up
 	time.Sleep(a.immediateSyncDebounce)
 
 	a.immediateSyncMu.Lock()
-	a.immediateSyncPending = false
+	*pending = false
 	a.immediateSyncMu.Unlock()
 
-	// Now sync all recent events (uses GetRecentEvents which doesn't clear buffer)
-	a.doImmediateEventSync(ctx)
-}
-
-// doImmediateEventSync syncs recent events without clearing the buffer.
-// This is used for immediate sync on failure events.
-func (a *Agent) doImmediateEventSync(ctx context.Context) {
-	start := time.Now()
-
-	// Get recent events (last 5 minutes) without clearing the buffer
-	// The periodic sync will clear them later
-	events := a.eventWatcher.GetRecentEvents(5 * time.Minute)
-	if len(events) == 0 {
-		a.logger.Debug("no recent events to sync")
-		return
-	}
-
-	// Convert to sync format
-	syncEvents := make([]sync.CertManagerEvent, 0, len(events))
-	for i := range events {
-		syncEvents = append(syncEvents, convertToSyncEvent(&events[i]))
-	}
-
-	// Sync events to API (database deduplication handles duplicates)
-	err := a.syncClient.SyncCertManagerEvents(ctx, a.config.Agent.ClusterName, syncEvents)
-	if err != nil {
-		a.logger.Error("immediate event sync failed", zap.Error(err))
-		metrics.EventSyncTotal.WithLabelValues("error").Inc()
-		return
-	}
-
-	a.logger.Info("immediate event sync completed",
-		zap.Int("events", len(events)),
-		zap.Duration("duration", time.Since(start)),
-	)
-	metrics.EventSyncTotal.WithLabelValues("success").Inc()
+	run()
 }
 
+// doEventSync delivers buffered events. They stay buffered until the API (or
+// the outbox) has accepted them, so a failed sync is retried on the next one.
 func (a *Agent) doEventSync(ctx context.Context) {
-	start := time.Now()
+	a.eventSyncMu.Lock()
+	defer a.eventSyncMu.Unlock()
+	defer func() {
+		metrics.BufferPending.WithLabelValues("events").Set(float64(a.eventWatcher.PendingCount()))
+	}()
 
-	// Get buffered events from the event watcher (clears the buffer)
+	start := time.Now()
 	events := a.eventWatcher.GetEvents()
 	if len(events) == 0 {
 		a.logger.Debug("no events to sync")
@@ -338,13 +559,17 @@ func (a *Agent) doEventSync(ctx context.Context) {
 		syncEvents = append(syncEvents, convertToSyncEvent(&events[i]))
 	}
 
-	// Sync events to API
+	// Sync events to API; queued events are delivered by the outbox
 	err := a.syncClient.SyncCertManagerEvents(ctx, a.config.Agent.ClusterName, syncEvents)
 	if err != nil {
-		a.logger.Error("event sync failed", zap.Error(err))
+		a.logger.Error("event sync failed", zap.Int("events", len(events)), zap.Error(err))
 		metrics.EventSyncTotal.WithLabelValues("error").Inc()
+		if errors.Is(err, sync.ErrQueued) {
+			a.eventWatcher.MarkDelivered(events)
+		}
 		return
 	}
+	a.eventWatcher.MarkDelivered(events)
 
 	a.logger.Info("event sync completed",
 		zap.Int("events", len(events)),
@@ -354,6 +579,10 @@ func (a *Agent) doEventSync(ctx context.Context) {
 }
 
 func (a *Agent) doRequestSync(ctx context.Context) {
+	defer func() {
+		metrics.BufferPending.WithLabelValues("requests").Set(float64(a.requestReconciler.PendingCount()))
+	}()
+
 	start := time.Now()
 
 	// Get all tracked certificate requests
@@ -369,13 +598,17 @@ func (a *Agent) doRequestSync(ctx context.Context) {
 		syncRequests = append(syncRequests, convertToSyncRequest(&requests[i]))
 	}
 
-	// Sync requests to API
+	// Sync requests to API; queued requests are delivered by the outbox
 	err := a.syncClient.SyncCertManagerRequests(ctx, a.config.Agent.ClusterName, syncRequests)
 	if err != nil {
 		a.logger.Error("request sync failed", zap.Error(err))
 		metrics.RequestSyncTotal.WithLabelValues("error").Inc()
+		if errors.Is(err, sync.ErrQueued) {
+			a.requestReconciler.MarkSynced(requests)
+		}
 		return
 	}
+	a.requestReconciler.MarkSynced(requests)
 
 	a.logger.Info("request sync completed",
 		zap.Int("requests", len(requests)),
@@ -384,10 +617,191 @@ func (a *Agent) doRequestSync(ctx context.Context) {
 	metrics.RequestSyncTotal.WithLabelValues("success").Inc()
 }
 
+func (a *Agent) doIssuerSync(ctx context.Context) {
+	start := time.Now()
+
+	issuers := a.issuerReconciler.GetIssuers()
+	if len(issuers) == 0 {
+		a.logger.Debug("no issuers to sync")
+		return
+	}
+
+	// Convert to sync format
+	syncIssuers := make([]sync.CertManagerIssuer, 0, len(issuers))
+	for i := range issuers {
+		syncIssuers = append(syncIssuers, convertToSyncIssuer(&issuers[i]))
+	}
+
+	err := a.syncClient.SyncCertManagerIssuers(ctx, a.config.Agent.ClusterName, syncIssuers)
+	if err != nil {
+		a.logger.Error("issuer sync failed", zap.Error(err))
+		metrics.IssuerSyncTotal.WithLabelValues("error").Inc()
+		return
+	}
+
+	a.logger.Info("issuer sync completed",
+		zap.Int("issuers", len(issuers)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	metrics.IssuerSyncTotal.WithLabelValues("success").Inc()
+}
+
+// doACMESync delivers the Order and Challenge states that changed since the
+// last accepted sync
+func (a *Agent) doACMESync(ctx context.Context) {
+	if a.acmeReconciler == nil {
+		return
+	}
+	a.acmeSyncMu.Lock()
+	defer a.acmeSyncMu.Unlock()
+	defer func() {
+		metrics.BufferPending.WithLabelValues("acme").Set(float64(a.acmeReconciler.PendingCount()))
+	}()
+
+	start := time.Now()
+	orders, challenges := a.acmeReconciler.GetPending()
+	if len(orders) == 0 && len(challenges) == 0 {
+		a.logger.Debug("no acme changes to sync")
+		return
+	}
+
+	// Convert to sync format
+	syncOrders := make([]sync.CertManagerACMEOrder, 0, len(orders))
+	for i := range orders {
+		syncOrders = append(syncOrders, convertToSyncOrder(&orders[i]))
+	}
+	syncChallenges := make([]sync.CertManagerACMEChallenge, 0, len(challenges))
+	for i := range challenges {
+		syncChallenges = append(syncChallenges, convertToSyncChallenge(&challenges[i]))
+	}
+
+	// Queued states are delivered by the outbox
+	err := a.syncClient.SyncCertManagerACME(ctx, a.config.Agent.ClusterName, syncOrders, syncChallenges)
+	if err != nil {
+		a.logger.Error("acme sync failed", zap.Error(err))
+		metrics.ACMESyncTotal.WithLabelValues("error").Inc()
+		if errors.Is(err, sync.ErrQueued) {
+			a.acmeReconciler.MarkSynced(orders, challenges)
+		}
+		return
+	}
+	a.acmeReconciler.MarkSynced(orders, challenges)
+
+	a.logger.Info("acme sync completed",
+		zap.Int("orders", len(orders)),
+		zap.Int("challenges", len(challenges)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	metrics.ACMESyncTotal.WithLabelValues("success").Inc()
+}
+
+// restoreCheckpoint loads the buffers saved by saveCheckpoint
+func (a *Agent) restoreCheckpoint() {
+	if a.checkpointPath == "" {
+		return
+	}
+	cp, err := controller.LoadCheckpoint(a.checkpointPath)
+	if err != nil {
+		a.logger.Warn("failed to load checkpoint, starting with empty buffers", zap.Error(err))
+		return
+	}
+	a.eventWatcher.Restore(cp.Events, cp.Delivered)
+	a.requestReconciler.Restore(cp.Requests)
+	if a.acmeReconciler != nil {
+		a.acmeReconciler.Restore(cp.ACMEOrders, cp.ACMEChallenges)
+	}
+	if len(cp.Events) > 0 || len(cp.Requests) > 0 || len(cp.ACMEOrders) > 0 || len(cp.ACMEChallenges) > 0 {
+		a.logger.Info("restored undelivered events, certificate requests and acme states",
+			zap.Int("events", len(cp.Events)),
+			zap.Int("requests", len(cp.Requests)),
+			zap.Int("acme_orders", len(cp.ACMEOrders)),
+			zap.Int("acme_challenges", len(cp.ACMEChallenges)),
+		)
+	}
+}
+
+// saveCheckpoint writes the undelivered events, CertificateRequest and ACME states
+func (a *Agent) saveCheckpoint() {
+	if a.checkpointPath == "" {
+		return
+	}
+	a.checkpointMu.Lock()
+	defer a.checkpointMu.Unlock()
+
+	cp := &controller.Checkpoint{Requests: a.requestReconciler.Checkpoint()}
+	cp.Events, cp.Delivered = a.eventWatcher.Checkpoint()
+	if a.acmeReconciler != nil {
+		cp.ACMEOrders, cp.ACMEChallenges = a.acmeReconciler.GetPending()
+	}
+	if err := cp.Save(a.checkpointPath); err != nil {
+		a.logger.Warn("failed to save checkpoint", zap.Error(err))
+	}
+}
+
+func convertToSyncIssuer(i *types.IssuerStatus) sync.CertManagerIssuer {
+	return sync.CertManagerIssuer{
+		Namespace:      i.Namespace,
+		Name:           i.Name,
+		Kind:           i.Kind,
+		Type:           i.Type,
+		Ready:          i.Ready,
+		ReadyReason:    i.ReadyReason,
+		ReadyMessage:   i.ReadyMessage,
+		LastTransition: i.LastTransition,
+		ACMEServer:     i.ACMEServer,
+		ACMEEmail:      i.ACMEEmail,
+		ACMEAccountURI: i.ACMEAccountURI,
+		ACMERegistered: i.ACMERegistered,
+	}
+}
+
+func convertToSyncOrder(o *types.ACMEOrderStatus) sync.CertManagerACMEOrder {
+	return sync.CertManagerACMEOrder{
+		Namespace:          o.Namespace,
+		Name:               o.Name,
+		CertificateRequest: o.CertificateRequest,
+		CertificateName:    o.CertificateName,
+		IssuerName:         o.IssuerName,
+		IssuerKind:         o.IssuerKind,
+		DNSNames:           o.DNSNames,
+		URL:                o.URL,
+		State:              o.State,
+		Reason:             o.Reason,
+		Problem:            o.Problem,
+		Failed:             o.Failed,
+		Deleted:            o.Deleted,
+		CreatedAt:          o.CreatedAt,
+		FailureTime:        o.FailureTime,
+	}
+}
+
+func convertToSyncChallenge(c *types.ACMEChallengeStatus) sync.CertManagerACMEChallenge {
+	return sync.CertManagerACMEChallenge{
+		Namespace:          c.Namespace,
+		Name:               c.Name,
+		OrderName:          c.OrderName,
+		CertificateRequest: c.CertificateRequest,
+		CertificateName:    c.CertificateName,
+		DNSName:            c.DNSName,
+		Wildcard:           c.Wildcard,
+		Type:               c.Type,
+		Solver:             c.Solver,
+		State:              c.State,
+		Reason:             c.Reason,
+		Problem:            c.Problem,
+		Processing:         c.Processing,
+		Presented:          c.Presented,
+		Failed:             c.Failed,
+		Deleted:            c.Deleted,
+		CreatedAt:          c.CreatedAt,
+	}
+}
+
 func convertToSyncEvent(e *types.CertManagerEvent) sync.CertManagerEvent {
 	return sync.CertManagerEvent{
 		CertificateNamespace: e.CertificateNamespace,
 		CertificateName:      e.CertificateName,
+		Key:                  e.Key,
 		Reason:               e.Reason,
 		Message:              e.Message,
 		Type:                 e.Type,
@@ -429,6 +843,7 @@ func convertToSyncCert(c types.CertificateStatus) sync.CertManagerCertificate {
 		IssuerName:     c.IssuerName,
 		IssuerKind:     c.IssuerKind,
 		IssuerGroup:    c.IssuerGroup,
+		Tags:           c.Tags,
 		Ready:          c.Ready,
 		ReadyReason:    c.ReadyReason,
 		Issuing:        c.Issuing,
@@ -437,9 +852,37 @@ func convertToSyncCert(c types.CertificateStatus) sync.CertManagerCertificate {
 		RenewalTime:    c.RenewalTime,
 		Revision:       c.Revision,
 		FailedAttempts: c.FailedAttempts,
+		Secret:         convertToSyncSecret(c.Secret),
 	}
 }
 
+func convertToSyncSecret(s *types.SecretStatus) *sync.CertManagerSecret {
+	if s == nil {
+		return nil
+	}
+	secret := &sync.CertManagerSecret{
+		NotBefore:         s.NotBefore,
+		NotAfter:          s.NotAfter,
+		Subject:           s.Subject,
+		Issuer:            s.Issuer,
+		SerialNumber:      s.SerialNumber,
+		FingerprintSHA256: s.FingerprintSHA256,
+		KeyAlgorithm:      s.KeyAlgorithm,
+		Error:             s.Error,
+		SANList:           s.SANList,
+		KeySize:           s.KeySize,
+		ChainLength:       s.ChainLength,
+		ChainValid:        s.ChainValid,
+	}
+	for _, issue := range s.ChainIssues {
+		secret.ChainIssues = append(secret.ChainIssues, sync.ChainIssueData(issue))
+	}
+	for _, issue := range s.Drift {
+		secret.Drift = append(secret.Drift, sync.ChainIssueData(issue))
+	}
+	return secret
+}
+
 func setupLogger(level string) *zap.Logger {
 	var zapLevel zapcore.Level
 	switch level {
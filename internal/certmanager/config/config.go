@@ -5,31 +5,88 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/labels"
+
+	agentconfig "github.com/certwatch-app/cw-agent/internal/config"
 )
 
 // Config holds all configuration for the cert-manager agent
 type Config struct {
-	API   APIConfig   `mapstructure:"api"`
-	Agent AgentConfig `mapstructure:"agent"`
+	API    APIConfig                `mapstructure:"api"`
+	Agent  AgentConfig              `mapstructure:"agent"`
+	Notify agentconfig.NotifyConfig `mapstructure:"notify"`
 }
 
 // APIConfig holds API connection settings
 type APIConfig struct {
-	Endpoint string        `mapstructure:"endpoint"`
-	Key      string        `mapstructure:"key"`
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Endpoint    string                   `mapstructure:"endpoint"`
+	Key         string                   `mapstructure:"key"`
+	KeyFile     string                   `mapstructure:"key_file"`
+	KeyCommand  []string                 `mapstructure:"key_command"`
+	KeyVault    agentconfig.VaultConfig  `mapstructure:"key_vault"`
+	KeyRefresh  time.Duration            `mapstructure:"key_refresh"`
+	Timeout     time.Duration            `mapstructure:"timeout"`
+	Retry       RetryConfig              `mapstructure:"retry"`
+	Proxy       agentconfig.ProxyConfig  `mapstructure:"proxy"`
+	TLS         agentconfig.APITLSConfig `mapstructure:"tls"`
+	Compression string                   `mapstructure:"compression"` // Request body compression: gzip or none
+}
+
+// APIKey returns the API key settings
+func (a *APIConfig) APIKey() agentconfig.APIKeyConfig {
+	return agentconfig.APIKeyConfig{Key: a.Key, File: a.KeyFile, Command: a.KeyCommand, Vault: a.KeyVault, Refresh: a.KeyRefresh}
+}
+
+// RetryConfig controls retries of failed API calls
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
 }
 
 // AgentConfig holds agent-specific settings
 type AgentConfig struct {
-	Name              string        `mapstructure:"name"`
-	ClusterName       string        `mapstructure:"cluster_name"` // Optional, defaults to agent.name
-	LogLevel          string        `mapstructure:"log_level"`
-	MetricsPort       int           `mapstructure:"metrics_port"`
-	SyncInterval      time.Duration `mapstructure:"sync_interval"`
-	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
-	WatchAllNS        bool          `mapstructure:"watch_all_namespaces"`
-	Namespaces        []string      `mapstructure:"namespaces"` // If not watching all
+	Name                string                           `mapstructure:"name"`
+	ClusterName         string                           `mapstructure:"cluster_name"` // Optional, defaults to agent.name
+	LogLevel            string                           `mapstructure:"log_level"`
+	MetricsPort         int                              `mapstructure:"metrics_port"`
+	SyncInterval        time.Duration                    `mapstructure:"sync_interval"`
+	HeartbeatInterval   time.Duration                    `mapstructure:"heartbeat_interval"`
+	FullSyncInterval    time.Duration                    `mapstructure:"full_sync_interval"` // 0 sends every certificate on every sync
+	WatchAllNS          bool                             `mapstructure:"watch_all_namespaces"`
+	Namespaces          []string                         `mapstructure:"namespaces"`            // If not watching all
+	WatchClusterIssuers bool                             `mapstructure:"watch_cluster_issuers"` // Needs cluster-wide read access to ClusterIssuers
+	LabelSelector       string                           `mapstructure:"label_selector"`        // Certificates to watch by label
+	NamespaceSelector   string                           `mapstructure:"namespace_selector"`    // Namespaces to watch by label
+	TagLabels           []string                         `mapstructure:"tag_labels"`            // Certificate labels forwarded as tags
+	TagAnnotations      []string                         `mapstructure:"tag_annotations"`       // Certificate annotations forwarded as tags
+	ScanSecrets         bool                             `mapstructure:"scan_secrets"`
+	WatchACME           bool                             `mapstructure:"watch_acme"` // ACME Orders and Challenges
+	Outbox              OutboxConfig                     `mapstructure:"outbox"`
+	Checkpoint          CheckpointConfig                 `mapstructure:"checkpoint"`
+	LeaderElection      agentconfig.LeaderElectionConfig `mapstructure:"leader_election"`
+}
+
+// WatchedNamespaces returns the namespaces to watch, or nil for all namespaces
+func (c *AgentConfig) WatchedNamespaces() []string {
+	if c.WatchAllNS {
+		return nil
+	}
+	return c.Namespaces
+}
+
+// OutboxConfig controls the disk-backed queue of sync payloads that failed to reach the API
+type OutboxConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	MaxSizeMB int           `mapstructure:"max_size_mb"`
+	MaxAge    time.Duration `mapstructure:"max_age"`
+}
+
+// CheckpointConfig controls the file that keeps undelivered events and
+// CertificateRequest states across restarts
+type CheckpointConfig struct {
+	Path    string `mapstructure:"path"` // Default: in the state directory
+	Enabled bool   `mapstructure:"enabled"`
 }
 
 // Load loads configuration from viper
@@ -52,17 +109,57 @@ func Load(v *viper.Viper) (*Config, error) {
 func setDefaults(v *viper.Viper) {
 	v.SetDefault("api.endpoint", "https://api.certwatch.app")
 	v.SetDefault("api.timeout", "30s")
+	v.SetDefault("api.key_refresh", "5m")
+	v.SetDefault("api.retry.max_attempts", 3)
+	v.SetDefault("api.retry.initial_backoff", "1s")
+	v.SetDefault("api.retry.max_backoff", "30s")
+	v.SetDefault("api.compression", agentconfig.CompressionNone)
 	v.SetDefault("agent.log_level", "info")
 	v.SetDefault("agent.metrics_port", 9402)
 	v.SetDefault("agent.sync_interval", "30s")
 	v.SetDefault("agent.heartbeat_interval", "30s")
+	v.SetDefault("agent.full_sync_interval", "0s") // Delta sync is opt-in
 	v.SetDefault("agent.watch_all_namespaces", true)
+	v.SetDefault("agent.watch_cluster_issuers", true)
+	v.SetDefault("agent.scan_secrets", true)
+	v.SetDefault("agent.watch_acme", true)
+	v.SetDefault("agent.outbox.enabled", true)
+	v.SetDefault("agent.outbox.max_size_mb", 10)
+	v.SetDefault("agent.outbox.max_age", "24h")
+	v.SetDefault("agent.checkpoint.enabled", true)
+	agentconfig.SetLeaderElectionDefaults(v, "cw-agent-certmanager")
+	agentconfig.SetNotifyDefaults(v)
 }
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
-	if c.API.Key == "" {
-		return fmt.Errorf("api.key is required")
+	apiKey := c.API.APIKey()
+	if err := apiKey.Validate(); err != nil {
+		return fmt.Errorf("api.%w", err)
+	}
+	// Retry settings are optional when constructing Config directly; a
+	// max_attempts of 0 uses the client default
+	if c.API.Retry.MaxAttempts != 0 {
+		if c.API.Retry.MaxAttempts < 1 || c.API.Retry.MaxAttempts > 10 {
+			return fmt.Errorf("api.retry.max_attempts must be between 1 and 10")
+		}
+		if c.API.Retry.InitialBackoff < 100*time.Millisecond {
+			return fmt.Errorf("api.retry.initial_backoff must be at least 100ms")
+		}
+		if c.API.Retry.MaxBackoff < c.API.Retry.InitialBackoff {
+			return fmt.Errorf("api.retry.max_backoff must be at least api.retry.initial_backoff")
+		}
+	}
+	if err := c.API.Proxy.Validate(); err != nil {
+		return fmt.Errorf("api.proxy: %w", err)
+	}
+	if err := c.API.TLS.Validate(); err != nil {
+		return fmt.Errorf("api.tls: %w", err)
+	}
+	if c.API.Compression != "" {
+		if err := agentconfig.ValidateCompression(c.API.Compression); err != nil {
+			return fmt.Errorf("api.%w", err)
+		}
 	}
 	if c.Agent.Name == "" {
 		return fmt.Errorf("agent.name is required")
@@ -73,5 +170,28 @@ func (c *Config) Validate() error {
 	if c.Agent.SyncInterval < 10*time.Second {
 		return fmt.Errorf("agent.sync_interval must be at least 10s")
 	}
+	if c.Agent.FullSyncInterval < 0 || (c.Agent.FullSyncInterval > 0 && c.Agent.FullSyncInterval < c.Agent.SyncInterval) {
+		return fmt.Errorf("agent.full_sync_interval must be 0 or at least agent.sync_interval")
+	}
+	if _, err := labels.Parse(c.Agent.LabelSelector); err != nil {
+		return fmt.Errorf("agent.label_selector: %w", err)
+	}
+	if _, err := labels.Parse(c.Agent.NamespaceSelector); err != nil {
+		return fmt.Errorf("agent.namespace_selector: %w", err)
+	}
+	if c.Agent.Outbox.Enabled {
+		if c.Agent.Outbox.MaxSizeMB < 1 || c.Agent.Outbox.MaxSizeMB > 1024 {
+			return fmt.Errorf("agent.outbox.max_size_mb must be between 1 and 1024")
+		}
+		if c.Agent.Outbox.MaxAge < time.Minute {
+			return fmt.Errorf("agent.outbox.max_age must be at least 1m")
+		}
+	}
+	if err := c.Agent.LeaderElection.Validate(); err != nil {
+		return fmt.Errorf("agent.leader_election: %w", err)
+	}
+	if err := c.Notify.Validate(); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
 	return nil
 }
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/viper"
+
+	agentconfig "github.com/certwatch-app/cw-agent/internal/config"
 )
 
 func TestLoad_Defaults(t *testing.T) {
@@ -36,9 +39,36 @@ func TestLoad_Defaults(t *testing.T) {
 	if !cfg.Agent.WatchAllNS {
 		t.Error("Agent.WatchAllNS = false, want true")
 	}
+	if !cfg.Agent.WatchClusterIssuers {
+		t.Error("Agent.WatchClusterIssuers = false, want true")
+	}
 	if cfg.Agent.HeartbeatInterval != 30*time.Second {
 		t.Errorf("Agent.HeartbeatInterval = %v, want 30s", cfg.Agent.HeartbeatInterval)
 	}
+	if cfg.Agent.FullSyncInterval != 0 {
+		t.Errorf("Agent.FullSyncInterval = %v, want 0", cfg.Agent.FullSyncInterval)
+	}
+	if cfg.API.Compression != "none" {
+		t.Errorf("API.Compression = %v, want none", cfg.API.Compression)
+	}
+	if !cfg.Agent.ScanSecrets {
+		t.Error("Agent.ScanSecrets = false, want true")
+	}
+	if !cfg.Agent.WatchACME {
+		t.Error("Agent.WatchACME = false, want true")
+	}
+	if !cfg.Agent.Outbox.Enabled {
+		t.Error("Agent.Outbox.Enabled = false, want true")
+	}
+	if cfg.Agent.Outbox.MaxSizeMB != 10 {
+		t.Errorf("Agent.Outbox.MaxSizeMB = %v, want 10", cfg.Agent.Outbox.MaxSizeMB)
+	}
+	if cfg.Agent.Outbox.MaxAge != 24*time.Hour {
+		t.Errorf("Agent.Outbox.MaxAge = %v, want 24h", cfg.Agent.Outbox.MaxAge)
+	}
+	if !cfg.Agent.Checkpoint.Enabled {
+		t.Error("Agent.Checkpoint.Enabled = false, want true")
+	}
 }
 
 func TestLoad_ClusterNameDefaultsToAgentName(t *testing.T) {
@@ -160,6 +190,43 @@ func TestValidate_Valid(t *testing.T) {
 	}
 }
 
+func TestValidate_APIKeySources(t *testing.T) {
+	agent := AgentConfig{Name: "test", SyncInterval: 30 * time.Second}
+	tests := []struct {
+		name    string
+		api     APIConfig
+		wantErr string
+	}{
+		{name: "key file", api: APIConfig{KeyFile: "/var/run/secrets/certwatch/api-key"}},
+		{name: "command", api: APIConfig{KeyCommand: []string{"op", "read", "op://infra/certwatch/key"}}},
+		{name: "vault", api: APIConfig{KeyVault: agentconfig.VaultConfig{Path: "certwatch/prod"}}},
+		{name: "env reference", api: APIConfig{Key: "${CW_TEST_UNSET_KEY}"}, wantErr: "api.key is required"},
+		{
+			name:    "two sources",
+			api:     APIConfig{KeyFile: "/key", KeyVault: agentconfig.VaultConfig{Path: "certwatch/prod"}},
+			wantErr: "mutually exclusive",
+		},
+		{name: "vault without path", api: APIConfig{KeyVault: agentconfig.VaultConfig{Address: "https://vault:8200"}}, wantErr: "api.key_vault: path is required"},
+		{name: "vault kv version", api: APIConfig{KeyVault: agentconfig.VaultConfig{Path: "p", KVVersion: 3}}, wantErr: "kv_version"},
+		{name: "refresh too short", api: APIConfig{KeyFile: "/key", KeyRefresh: time.Second}, wantErr: "api.key_refresh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{API: tt.api, Agent: agent}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidate_SyncIntervalTooShort(t *testing.T) {
 	cfg := &Config{
 		API: APIConfig{Key: "test-key"},
@@ -175,6 +242,72 @@ func TestValidate_SyncIntervalTooShort(t *testing.T) {
 	}
 }
 
+func TestValidate_FullSyncIntervalShorterThanSyncInterval(t *testing.T) {
+	cfg := &Config{
+		API: APIConfig{Key: "test-key"},
+		Agent: AgentConfig{
+			Name:             "test",
+			SyncInterval:     time.Minute,
+			FullSyncInterval: 30 * time.Second, // Shorter than sync_interval
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Error("Validate() error = nil, want error for full_sync_interval < sync_interval")
+	}
+}
+
+func TestValidate_InvalidRetry(t *testing.T) {
+	tests := []struct {
+		name  string
+		retry RetryConfig
+	}{
+		{"negative attempts", RetryConfig{MaxAttempts: -1}},
+		{"too many attempts", RetryConfig{MaxAttempts: 11, InitialBackoff: time.Second, MaxBackoff: time.Second}},
+		{"initial backoff too short", RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Second}},
+		{"max below initial backoff", RetryConfig{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Millisecond}},
+	}
+	for _, tt := range tests {
+		cfg := &Config{
+			API:   APIConfig{Key: "test-key", Retry: tt.retry},
+			Agent: AgentConfig{Name: "test", SyncInterval: time.Minute},
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate() error = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestValidate_InvalidSelector(t *testing.T) {
+	cfg := &Config{
+		API: APIConfig{Key: "test-key"},
+		Agent: AgentConfig{
+			Name:              "test",
+			SyncInterval:      30 * time.Second,
+			LabelSelector:     "team=payments",
+			NamespaceSelector: "env in (prod", // Unterminated set
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agent.namespace_selector") {
+		t.Errorf("Validate() error = %v, want agent.namespace_selector error", err)
+	}
+}
+
+func TestWatchedNamespaces(t *testing.T) {
+	agent := AgentConfig{WatchAllNS: true, Namespaces: []string{"production"}}
+	if got := agent.WatchedNamespaces(); got != nil {
+		t.Errorf("WatchedNamespaces() = %v, want nil when watching all namespaces", got)
+	}
+
+	agent.WatchAllNS = false
+	if got := agent.WatchedNamespaces(); len(got) != 1 || got[0] != "production" {
+		t.Errorf("WatchedNamespaces() = %v, want [production]", got)
+	}
+}
+
 func TestValidate_InvalidMetricsPort(t *testing.T) {
 	cfg := &Config{
 		API: APIConfig{Key: "test-key"},
@@ -190,3 +323,57 @@ func TestValidate_InvalidMetricsPort(t *testing.T) {
 		t.Error("Validate() error = nil, want error for invalid metrics_port")
 	}
 }
+
+func TestValidate_InvalidOutbox(t *testing.T) {
+	cfg := &Config{
+		API: APIConfig{Key: "test-key"},
+		Agent: AgentConfig{
+			Name:         "test",
+			SyncInterval: 30 * time.Second,
+			Outbox: OutboxConfig{
+				Enabled:   true,
+				MaxSizeMB: 0, // Invalid
+				MaxAge:    time.Hour,
+			},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Error("Validate() error = nil, want error for outbox.max_size_mb < 1")
+	}
+}
+
+func TestLoad_Notify(t *testing.T) {
+	v := viper.New()
+	v.Set("api.key", "test-key")
+	v.Set("agent.name", "test-agent")
+	v.Set("notify.slack", []map[string]any{{"webhook_url": "https://hooks.slack.com/services/T/B/X"}})
+
+	cfg, err := Load(v)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !cfg.Notify.Enabled() {
+		t.Error("Notify.Enabled() = false, want true")
+	}
+	if len(cfg.Notify.Thresholds) != 4 || cfg.Notify.Thresholds[0] != 30 {
+		t.Errorf("Notify.Thresholds = %v, want [30 14 7 1]", cfg.Notify.Thresholds)
+	}
+	if cfg.Notify.Timeout != 10*time.Second {
+		t.Errorf("Notify.Timeout = %v, want 10s", cfg.Notify.Timeout)
+	}
+}
+
+func TestValidate_InvalidNotify(t *testing.T) {
+	v := viper.New()
+	v.Set("api.key", "test-key")
+	v.Set("agent.name", "test-agent")
+	v.Set("notify.email.host", "smtp.example.com")
+	v.Set("notify.email.from", "certwatch@example.com")
+
+	if _, err := Load(v); err == nil {
+		t.Error("Load() error = nil, want error for email without recipients")
+	}
+}
@@ -0,0 +1,411 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	acmeapi "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/metrics"
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+// ACMEReconciler watches acme.cert-manager.io Order and Challenge resources and
+// links them to the Certificate they were created for
+type ACMEReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+
+	// Callbacks when an Order or Challenge fails
+	OnOrderFailure     func(order types.ACMEOrderStatus)
+	OnChallengeFailure func(challenge types.ACMEChallengeStatus)
+
+	// Orders and Challenges by namespace/name. States the API hasn't accepted
+	// are kept in the unsynced maps. A removed resource is reported once more
+	// as its last state marked Deleted, so Challenges removed as soon as their
+	// Order completes are still delivered.
+	mu                 sync.RWMutex
+	orders             map[string]types.ACMEOrderStatus
+	challenges         map[string]types.ACMEChallengeStatus
+	unsyncedOrders     map[string]types.ACMEOrderStatus
+	unsyncedChallenges map[string]types.ACMEChallengeStatus
+}
+
+// NewACMEReconciler creates a new reconciler
+func NewACMEReconciler(c client.Client, scheme *runtime.Scheme, logger *zap.Logger) *ACMEReconciler {
+	return &ACMEReconciler{
+		Client:             c,
+		Scheme:             scheme,
+		Logger:             logger,
+		orders:             make(map[string]types.ACMEOrderStatus),
+		challenges:         make(map[string]types.ACMEChallengeStatus),
+		unsyncedOrders:     make(map[string]types.ACMEOrderStatus),
+		unsyncedChallenges: make(map[string]types.ACMEChallengeStatus),
+	}
+}
+
+// ReconcileOrder handles Order changes
+func (r *ACMEReconciler) ReconcileOrder(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	start := time.Now()
+	log := r.Logger.With(
+		zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name),
+	)
+
+	var order acmeapi.Order
+	if err := r.Get(ctx, req.NamespacedName, &order); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Debug("order deleted")
+			r.removeOrder(req.Namespace, req.Name)
+			metrics.ReconcileTotal.WithLabelValues("order", "deleted").Inc()
+			return ctrl.Result{}, nil
+		}
+		log.Error("failed to get order", zap.Error(err))
+		metrics.ReconcileTotal.WithLabelValues("order", "error").Inc()
+		return ctrl.Result{}, err
+	}
+
+	status := r.extractOrderStatus(ctx, &order)
+	prev, seen := r.storeOrder(status)
+
+	if status.Failed && (!seen || !prev.Failed) {
+		log.Info("acme order failed",
+			zap.String("certificate", status.CertificateName),
+			zap.String("state", status.State),
+			zap.String("problem", status.Problem),
+			zap.String("reason", status.Reason),
+		)
+		metrics.ACMEFailuresTotal.WithLabelValues("order", status.Problem).Inc()
+		if r.OnOrderFailure != nil {
+			r.OnOrderFailure(status)
+		}
+	}
+
+	log.Debug("order reconciled", zap.String("state", status.State))
+
+	metrics.ReconcileTotal.WithLabelValues("order", "success").Inc()
+	metrics.ReconcileDuration.WithLabelValues("order").Observe(time.Since(start).Seconds())
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileChallenge handles Challenge changes
+func (r *ACMEReconciler) ReconcileChallenge(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	start := time.Now()
+	log := r.Logger.With(
+		zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name),
+	)
+
+	var ch acmeapi.Challenge
+	if err := r.Get(ctx, req.NamespacedName, &ch); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Debug("challenge deleted")
+			r.removeChallenge(req.Namespace, req.Name)
+			metrics.ReconcileTotal.WithLabelValues("challenge", "deleted").Inc()
+			return ctrl.Result{}, nil
+		}
+		log.Error("failed to get challenge", zap.Error(err))
+		metrics.ReconcileTotal.WithLabelValues("challenge", "error").Inc()
+		return ctrl.Result{}, err
+	}
+
+	status := r.extractChallengeStatus(ctx, &ch)
+	prev, seen := r.storeChallenge(status)
+
+	if status.Failed && (!seen || !prev.Failed) {
+		log.Info("acme challenge failed",
+			zap.String("certificate", status.CertificateName),
+			zap.String("type", status.Type),
+			zap.String("solver", status.Solver),
+			zap.String("problem", status.Problem),
+			zap.String("reason", status.Reason),
+		)
+		metrics.ACMEFailuresTotal.WithLabelValues("challenge", status.Problem).Inc()
+		if r.OnChallengeFailure != nil {
+			r.OnChallengeFailure(status)
+		}
+	}
+
+	log.Debug("challenge reconciled",
+		zap.String("state", status.State),
+		zap.Bool("presented", status.Presented),
+	)
+
+	metrics.ReconcileTotal.WithLabelValues("challenge", "success").Inc()
+	metrics.ReconcileDuration.WithLabelValues("challenge").Observe(time.Since(start).Seconds())
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ACMEReconciler) extractOrderStatus(ctx context.Context, order *acmeapi.Order) types.ACMEOrderStatus {
+	status := types.ACMEOrderStatus{
+		Namespace:          order.Namespace,
+		Name:               order.Name,
+		ResourceVersion:    order.ResourceVersion,
+		CertificateRequest: ownerName(order.OwnerReferences, "CertificateRequest"),
+		IssuerName:         order.Spec.IssuerRef.Name,
+		IssuerKind:         order.Spec.IssuerRef.Kind,
+		DNSNames:           order.Spec.DNSNames,
+		URL:                order.Status.URL,
+		State:              string(order.Status.State),
+		Reason:             order.Status.Reason,
+		Problem:            types.CategorizeACMEProblem(order.Status.Reason),
+		Failed:             acmeFailed(order.Status.State),
+		CreatedAt:          order.CreationTimestamp.Time,
+	}
+	if status.IssuerKind == "" {
+		status.IssuerKind = KindIssuer
+	}
+	if order.Status.FailureTime != nil {
+		t := order.Status.FailureTime.Time
+		status.FailureTime = &t
+	}
+	if status.CertificateRequest != "" {
+		status.CertificateName = r.requestCertificate(ctx, order.Namespace, status.CertificateRequest)
+	}
+	return status
+}
+
+func (r *ACMEReconciler) extractChallengeStatus(ctx context.Context, ch *acmeapi.Challenge) types.ACMEChallengeStatus {
+	status := types.ACMEChallengeStatus{
+		Namespace:       ch.Namespace,
+		Name:            ch.Name,
+		ResourceVersion: ch.ResourceVersion,
+		OrderName:       ownerName(ch.OwnerReferences, "Order"),
+		DNSName:         ch.Spec.DNSName,
+		Wildcard:        ch.Spec.Wildcard,
+		Type:            string(ch.Spec.Type),
+		Solver:          solverName(&ch.Spec.Solver),
+		State:           string(ch.Status.State),
+		Reason:          ch.Status.Reason,
+		Problem:         types.CategorizeACMEProblem(ch.Status.Reason),
+		Processing:      ch.Status.Processing,
+		Presented:       ch.Status.Presented,
+		Failed:          acmeFailed(ch.Status.State),
+		CreatedAt:       ch.CreationTimestamp.Time,
+	}
+
+	// Challenge -> Order -> CertificateRequest -> Certificate
+	if status.OrderName != "" {
+		var order acmeapi.Order
+		if err := r.Get(ctx, client.ObjectKey{Namespace: ch.Namespace, Name: status.OrderName}, &order); err == nil {
+			status.CertificateRequest = ownerName(order.OwnerReferences, "CertificateRequest")
+		}
+	}
+	if status.CertificateRequest != "" {
+		status.CertificateName = r.requestCertificate(ctx, ch.Namespace, status.CertificateRequest)
+	}
+	return status
+}
+
+// requestCertificate returns the name of the Certificate that owns a
+// CertificateRequest, or "" if it can't be determined
+func (r *ACMEReconciler) requestCertificate(ctx context.Context, namespace, name string) string {
+	var cr cmapi.CertificateRequest
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &cr); err != nil {
+		return ""
+	}
+	if owner := ownerName(cr.OwnerReferences, "Certificate"); owner != "" {
+		return owner
+	}
+	return cr.Annotations[cmapi.CertificateNameKey]
+}
+
+// ownerName returns the name of the first owner of the given kind
+func ownerName(refs []metav1.OwnerReference, kind string) string {
+	for _, ref := range refs {
+		if ref.Kind == kind {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// acmeFailed reports whether an Order or Challenge state is a final failure
+func acmeFailed(state acmeapi.State) bool {
+	return state == acmeapi.Invalid || state == acmeapi.Errored || state == acmeapi.Expired
+}
+
+// solverName describes the solver that handles a Challenge, e.g. dns01-route53
+func solverName(s *acmeapi.ACMEChallengeSolver) string {
+	switch {
+	case s.HTTP01 != nil:
+		switch {
+		case s.HTTP01.Ingress != nil:
+			return "http01-ingress"
+		case s.HTTP01.GatewayHTTPRoute != nil:
+			return "http01-gateway"
+		}
+		return "http01"
+	case s.DNS01 != nil:
+		d := s.DNS01
+		switch {
+		case d.Route53 != nil:
+			return "dns01-route53"
+		case d.Cloudflare != nil:
+			return "dns01-cloudflare"
+		case d.CloudDNS != nil:
+			return "dns01-clouddns"
+		case d.AzureDNS != nil:
+			return "dns01-azuredns"
+		case d.DigitalOcean != nil:
+			return "dns01-digitalocean"
+		case d.Akamai != nil:
+			return "dns01-akamai"
+		case d.AcmeDNS != nil:
+			return "dns01-acmedns"
+		case d.RFC2136 != nil:
+			return "dns01-rfc2136"
+		case d.Webhook != nil:
+			return "dns01-webhook"
+		}
+		return "dns01"
+	}
+	return ""
+}
+
+// storeOrder records status and returns the previously stored status, if any
+func (r *ACMEReconciler) storeOrder(status types.ACMEOrderStatus) (types.ACMEOrderStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := status.Namespace + "/" + status.Name
+	prev, seen := r.orders[key]
+	if !seen || prev.ResourceVersion != status.ResourceVersion {
+		r.unsyncedOrders[key] = status
+	}
+	r.orders[key] = status
+	r.updateGauges()
+	return prev, seen
+}
+
+// storeChallenge records status and returns the previously stored status, if any
+func (r *ACMEReconciler) storeChallenge(status types.ACMEChallengeStatus) (types.ACMEChallengeStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := status.Namespace + "/" + status.Name
+	prev, seen := r.challenges[key]
+	if !seen || prev.ResourceVersion != status.ResourceVersion {
+		r.unsyncedChallenges[key] = status
+	}
+	r.challenges[key] = status
+	r.updateGauges()
+	return prev, seen
+}
+
+// removeOrder forgets an Order and queues its deletion marker
+func (r *ACMEReconciler) removeOrder(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := namespace + "/" + name
+	if last, ok := r.orders[key]; ok {
+		last.Deleted = true
+		r.unsyncedOrders[key] = last
+	}
+	delete(r.orders, key)
+	r.updateGauges()
+}
+
+// removeChallenge forgets a Challenge and queues its deletion marker
+func (r *ACMEReconciler) removeChallenge(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := namespace + "/" + name
+	if last, ok := r.challenges[key]; ok {
+		last.Deleted = true
+		r.unsyncedChallenges[key] = last
+	}
+	delete(r.challenges, key)
+	r.updateGauges()
+}
+
+// updateGauges recounts Orders and Challenges by state. Callers hold r.mu.
+func (r *ACMEReconciler) updateGauges() {
+	metrics.ACMEOrders.Reset()
+	for k := range r.orders {
+		metrics.ACMEOrders.WithLabelValues(r.orders[k].State).Inc()
+	}
+	metrics.ACMEChallenges.Reset()
+	for k := range r.challenges {
+		metrics.ACMEChallenges.WithLabelValues(r.challenges[k].Type, r.challenges[k].State).Inc()
+	}
+}
+
+// GetPending returns the Order and Challenge states not synced yet, including
+// deletion markers
+func (r *ACMEReconciler) GetPending() ([]types.ACMEOrderStatus, []types.ACMEChallengeStatus) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orders := make([]types.ACMEOrderStatus, 0, len(r.unsyncedOrders))
+	for k := range r.unsyncedOrders {
+		orders = append(orders, r.unsyncedOrders[k])
+	}
+	challenges := make([]types.ACMEChallengeStatus, 0, len(r.unsyncedChallenges))
+	for k := range r.unsyncedChallenges {
+		challenges = append(challenges, r.unsyncedChallenges[k])
+	}
+	return orders, challenges
+}
+
+// MarkSynced records that the API accepted the given states. Resources changed
+// or deleted since they were read stay pending.
+func (r *ACMEReconciler) MarkSynced(orders []types.ACMEOrderStatus, challenges []types.ACMEChallengeStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range orders {
+		key := orders[i].Namespace + "/" + orders[i].Name
+		if u, ok := r.unsyncedOrders[key]; ok && u.ResourceVersion == orders[i].ResourceVersion && u.Deleted == orders[i].Deleted {
+			delete(r.unsyncedOrders, key)
+		}
+	}
+	for i := range challenges {
+		key := challenges[i].Namespace + "/" + challenges[i].Name
+		if u, ok := r.unsyncedChallenges[key]; ok && u.ResourceVersion == challenges[i].ResourceVersion && u.Deleted == challenges[i].Deleted {
+			delete(r.unsyncedChallenges, key)
+		}
+	}
+}
+
+// PendingCount returns the number of Order and Challenge states not yet synced
+func (r *ACMEReconciler) PendingCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.unsyncedOrders) + len(r.unsyncedChallenges)
+}
+
+// Restore loads states saved from GetPending by the agent checkpoint. It is
+// called before the reconciler starts; resources that still exist are
+// replaced when reconciled.
+func (r *ACMEReconciler) Restore(orders []types.ACMEOrderStatus, challenges []types.ACMEChallengeStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range orders {
+		r.unsyncedOrders[orders[i].Namespace+"/"+orders[i].Name] = orders[i]
+	}
+	for i := range challenges {
+		r.unsyncedChallenges[challenges[i].Namespace+"/"+challenges[i].Name] = challenges[i]
+	}
+}
+
+// SetupWithManager sets up the Order and Challenge controllers with the Manager
+func (r *ACMEReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&acmeapi.Order{}).
+		Named("order").
+		Complete(reconcile.Func(r.ReconcileOrder)); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&acmeapi.Challenge{}).
+		Named("challenge").
+		Complete(reconcile.Func(r.ReconcileChallenge))
+}
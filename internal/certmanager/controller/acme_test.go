@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	acmeapi "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+func owner(kind, name string) []metav1.OwnerReference {
+	return []metav1.OwnerReference{{Kind: kind, Name: name}}
+}
+
+func TestACMEReconciler_LinksAndReportsProblems(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := cmapi.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	if err := acmeapi.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+
+	meta := func(name string, owners []metav1.OwnerReference) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: "default", Name: name, OwnerReferences: owners, ResourceVersion: "1"}
+	}
+	order := &acmeapi.Order{
+		ObjectMeta: meta("web-1-123", owner("CertificateRequest", "web-1")),
+		Spec: acmeapi.OrderSpec{
+			IssuerRef: cmmeta.ObjectReference{Name: "letsencrypt", Kind: "ClusterIssuer"},
+			DNSNames:  []string{"example.com"},
+		},
+		Status: acmeapi.OrderStatus{
+			State:  acmeapi.Errored,
+			Reason: "429 urn:ietf:params:acme:error:rateLimited: too many certificates already issued",
+		},
+	}
+	challenge := &acmeapi.Challenge{
+		ObjectMeta: meta("web-1-123-456", owner("Order", "web-1-123")),
+		Spec: acmeapi.ChallengeSpec{
+			DNSName: "example.com",
+			Type:    acmeapi.ACMEChallengeTypeDNS01,
+			Solver:  acmeapi.ACMEChallengeSolver{DNS01: &acmeapi.ACMEChallengeSolverDNS01{Route53: &acmeapi.ACMEIssuerDNS01ProviderRoute53{}}},
+		},
+		Status: acmeapi.ChallengeStatus{
+			State:      acmeapi.Pending,
+			Processing: true,
+			Presented:  true,
+			Reason:     `Waiting for DNS-01 challenge propagation: DNS record for "example.com" not yet propagated`,
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&cmapi.CertificateRequest{ObjectMeta: meta("web-1", owner("Certificate", "web"))},
+		order,
+		challenge,
+	).Build()
+
+	r := NewACMEReconciler(c, s, zap.NewNop())
+	var failedOrders int
+	r.OnOrderFailure = func(_ types.ACMEOrderStatus) { failedOrders++ }
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := r.ReconcileOrder(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(order)}); err != nil {
+			t.Fatalf("ReconcileOrder() error = %v", err)
+		}
+	}
+	if _, err := r.ReconcileChallenge(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(challenge)}); err != nil {
+		t.Fatalf("ReconcileChallenge() error = %v", err)
+	}
+	if failedOrders != 1 {
+		t.Errorf("OnOrderFailure called %d times, want 1", failedOrders)
+	}
+
+	orders, challenges := r.GetPending()
+	if len(orders) != 1 || len(challenges) != 1 {
+		t.Fatalf("GetPending() = %d orders, %d challenges, want 1 and 1", len(orders), len(challenges))
+	}
+	o := orders[0]
+	if o.CertificateRequest != "web-1" || o.CertificateName != "web" || !o.Failed || o.Problem != types.ACMEProblemRateLimited {
+		t.Errorf("order = %+v, want failed rate_limited order of web via web-1", o)
+	}
+	ch := challenges[0]
+	if ch.OrderName != "web-1-123" || ch.CertificateName != "web" || ch.Type != "DNS-01" || ch.Solver != "dns01-route53" {
+		t.Errorf("challenge = %+v, want DNS-01 route53 challenge of web", ch)
+	}
+	if ch.Failed || !ch.Presented || ch.Problem != types.ACMEProblemDNSPropagation {
+		t.Errorf("challenge = %+v, want pending presented challenge waiting for propagation", ch)
+	}
+
+	// A deleted challenge is reported with its last state marked deleted, even
+	// when the state read before the deletion is synced afterwards
+	if err := c.Delete(ctx, challenge); err != nil {
+		t.Fatalf("failed to delete challenge: %v", err)
+	}
+	if _, err := r.ReconcileChallenge(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(challenge)}); err != nil {
+		t.Fatalf("ReconcileChallenge() error = %v", err)
+	}
+	r.MarkSynced(orders, challenges)
+	_, pending := r.GetPending()
+	if len(pending) != 1 || !pending[0].Deleted || pending[0].Problem != types.ACMEProblemDNSPropagation {
+		t.Fatalf("GetPending() challenges = %+v after delete, want the last state marked deleted", pending)
+	}
+
+	// Pending states survive a restart through the checkpoint
+	path := filepath.Join(t.TempDir(), CheckpointFileName)
+	cp := &Checkpoint{}
+	cp.ACMEOrders, cp.ACMEChallenges = r.GetPending()
+	if err := cp.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	restored := NewACMEReconciler(nil, s, zap.NewNop())
+	restored.Restore(loaded.ACMEOrders, loaded.ACMEChallenges)
+	if _, got := restored.GetPending(); len(got) != 1 || !got[0].Deleted {
+		t.Errorf("restored challenges = %+v, want the deletion marker", got)
+	}
+
+	r.MarkSynced(nil, pending)
+	if r.PendingCount() != 0 {
+		t.Errorf("PendingCount() = %d after MarkSynced, want 0", r.PendingCount())
+	}
+}
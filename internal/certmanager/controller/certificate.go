@@ -2,15 +2,23 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/certwatch-app/cw-agent/internal/certmanager/metrics"
 	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
@@ -22,9 +30,18 @@ type CertificateReconciler struct {
 	Scheme *runtime.Scheme
 	Logger *zap.Logger
 
+	// SecretReader reads the Secret referenced by each Certificate to inspect the
+	// stored certificate. Nil disables secret scanning.
+	SecretReader client.Reader
+
+	// Filter selects the Certificates to watch and their tags. Nil watches every
+	// Certificate that isn't ignored.
+	Filter *CertificateFilter
+
 	// Sync state
 	mu           sync.RWMutex
 	certificates map[string]types.CertificateStatus // key: namespace/name
+	synced       map[string]string                  // key: namespace/name, value: hash of the last synced status
 }
 
 // NewCertificateReconciler creates a new reconciler
@@ -34,6 +51,7 @@ func NewCertificateReconciler(c client.Client, scheme *runtime.Scheme, logger *z
 		Scheme:       scheme,
 		Logger:       logger,
 		certificates: make(map[string]types.CertificateStatus),
+		synced:       make(map[string]string),
 	}
 }
 
@@ -59,8 +77,33 @@ func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	selected, err := r.selected(ctx, &cert)
+	if err != nil {
+		log.Error("failed to get certificate namespace", zap.Error(err))
+		metrics.ReconcileTotal.WithLabelValues("certificate", "error").Inc()
+		return ctrl.Result{}, err
+	}
+	if !selected {
+		// Reported as deleted if it was watched before
+		log.Debug("certificate ignored or not selected")
+		r.removeCertificate(req.Namespace, req.Name)
+		metrics.ReconcileTotal.WithLabelValues("certificate", "skipped").Inc()
+		return ctrl.Result{}, nil
+	}
+
 	// Extract status
 	status := r.extractStatus(&cert)
+	if r.SecretReader != nil && cert.Spec.SecretName != "" {
+		status.Secret = r.inspectSecret(ctx, &cert)
+		if status.Secret.Error != "" {
+			log.Debug("secret inspection failed", zap.String("error", status.Secret.Error))
+		} else if len(status.Secret.Drift) > 0 {
+			log.Warn("certificate secret drift detected",
+				zap.String("secret", cert.Spec.SecretName),
+				zap.Int("issues", len(status.Secret.Drift)),
+			)
+		}
+	}
 	r.storeCertificate(status)
 
 	// Update metrics
@@ -78,6 +121,27 @@ func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
+// selected reports whether cert is watched: not ignored and matching the filter
+func (r *CertificateReconciler) selected(ctx context.Context, cert *cmapi.Certificate) (bool, error) {
+	if Ignored(cert) {
+		return false, nil
+	}
+	if r.Filter == nil {
+		return true, nil
+	}
+	if r.Filter.Selector != nil && !r.Filter.Selector.Matches(labels.Set(cert.Labels)) {
+		return false, nil
+	}
+	if r.Filter.NamespaceSelector != nil {
+		var ns corev1.Namespace
+		if err := r.Get(ctx, client.ObjectKey{Name: cert.Namespace}, &ns); err != nil {
+			return false, err
+		}
+		return r.Filter.NamespaceSelector.Matches(labels.Set(ns.Labels)), nil
+	}
+	return true, nil
+}
+
 func (r *CertificateReconciler) extractStatus(cert *cmapi.Certificate) types.CertificateStatus {
 	status := types.CertificateStatus{
 		Namespace:  cert.Namespace,
@@ -89,6 +153,10 @@ func (r *CertificateReconciler) extractStatus(cert *cmapi.Certificate) types.Cer
 		IssuerKind: cert.Spec.IssuerRef.Kind,
 	}
 
+	if r.Filter != nil {
+		status.Tags = r.Filter.Tags(cert)
+	}
+
 	// Default issuer kind if not set
 	if status.IssuerKind == "" {
 		status.IssuerKind = "Issuer"
@@ -171,6 +239,7 @@ func (r *CertificateReconciler) removeCertificate(namespace, name string) {
 	metrics.CertificateExpirySeconds.DeleteLabelValues(namespace, name)
 	metrics.CertificateDaysUntilExpiry.DeleteLabelValues(namespace, name)
 	metrics.CertificateFailedAttempts.DeleteLabelValues(namespace, name)
+	metrics.CertificateSecretDrift.DeleteLabelValues(namespace, name)
 }
 
 // GetCertificates returns all watched certificates for syncing
@@ -184,6 +253,70 @@ func (r *CertificateReconciler) GetCertificates() []types.CertificateStatus {
 	return certs
 }
 
+// Changes returns the certificates that changed since they were last synced and
+// the certificates deleted since then
+func (r *CertificateReconciler) Changes() ([]types.CertificateStatus, []types.CertificateRef) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changed []types.CertificateStatus
+	for key := range r.certificates {
+		if r.synced[key] != hashStatus(r.certificates[key]) {
+			changed = append(changed, r.certificates[key])
+		}
+	}
+
+	var deleted []types.CertificateRef
+	for key := range r.synced {
+		if _, ok := r.certificates[key]; !ok {
+			namespace, name, _ := strings.Cut(key, "/")
+			deleted = append(deleted, types.CertificateRef{Namespace: namespace, Name: name})
+		}
+	}
+	return changed, deleted
+}
+
+// MarkSynced records the changes returned by Changes as accepted by the API
+func (r *CertificateReconciler) MarkSynced(changed []types.CertificateStatus, deleted []types.CertificateRef) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.synced == nil {
+		r.synced = make(map[string]string)
+	}
+	for i := range changed {
+		r.synced[changed[i].Namespace+"/"+changed[i].Name] = hashStatus(changed[i])
+	}
+	for _, ref := range deleted {
+		key := ref.Namespace + "/" + ref.Name
+		// A certificate re-created since Changes is reported as changed next time
+		if _, ok := r.certificates[key]; !ok {
+			delete(r.synced, key)
+		}
+	}
+}
+
+// MarkAllSynced records a full sync of certs, replacing the previous sync state
+func (r *CertificateReconciler) MarkAllSynced(certs []types.CertificateStatus) {
+	synced := make(map[string]string, len(certs))
+	for i := range certs {
+		synced[certs[i].Namespace+"/"+certs[i].Name] = hashStatus(certs[i])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.synced = synced
+}
+
+// hashStatus returns a hash of the status as sent to the API
+func hashStatus(status types.CertificateStatus) string {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // CertificateCount returns the number of watched certificates
 func (r *CertificateReconciler) CertificateCount() int {
 	r.mu.RLock()
@@ -214,12 +347,34 @@ func (r *CertificateReconciler) updateMetrics(status types.CertificateStatus) {
 	}
 
 	metrics.CertificateFailedAttempts.WithLabelValues(labels...).Set(float64(status.FailedAttempts))
+
+	if status.Secret != nil {
+		metrics.CertificateSecretDrift.WithLabelValues(labels...).Set(float64(len(status.Secret.Drift)))
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager
 func (r *CertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&cmapi.Certificate{}).
-		Named("certificate").
-		Complete(r)
+		Named("certificate")
+	if r.Filter != nil && r.Filter.NamespaceSelector != nil {
+		// Re-evaluate a namespace's Certificates when its labels change
+		b = b.Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceCertificates))
+	}
+	return b.Complete(r)
+}
+
+// namespaceCertificates maps a Namespace to reconcile requests for its Certificates
+func (r *CertificateReconciler) namespaceCertificates(ctx context.Context, obj client.Object) []reconcile.Request {
+	var certs cmapi.CertificateList
+	if err := r.List(ctx, &certs, client.InNamespace(obj.GetName())); err != nil {
+		r.Logger.Warn("failed to list certificates", zap.String("namespace", obj.GetName()), zap.Error(err))
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(certs.Items))
+	for i := range certs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&certs.Items[i])})
+	}
+	return requests
 }
@@ -373,6 +373,40 @@ func TestRemoveCertificate(t *testing.T) {
 	}
 }
 
+func TestChanges(t *testing.T) {
+	r := NewCertificateReconciler(nil, nil, zap.NewNop())
+	r.storeCertificate(types.CertificateStatus{Namespace: "default", Name: "web", Ready: true})
+	r.storeCertificate(types.CertificateStatus{Namespace: "default", Name: "api", Ready: true})
+
+	r.MarkAllSynced(r.GetCertificates())
+	if changed, deleted := r.Changes(); len(changed) != 0 || len(deleted) != 0 {
+		t.Fatalf("Changes() after full sync = %v, %v, want none", changed, deleted)
+	}
+
+	r.storeCertificate(types.CertificateStatus{Namespace: "default", Name: "web", Ready: false})
+	r.removeCertificate("default", "api")
+	changed, deleted := r.Changes()
+	if len(changed) != 1 || changed[0].Name != "web" {
+		t.Errorf("changed = %v, want web", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != (types.CertificateRef{Namespace: "default", Name: "api"}) {
+		t.Errorf("deleted = %v, want default/api", deleted)
+	}
+
+	// A change after Changes is still reported once the earlier one is synced
+	r.storeCertificate(types.CertificateStatus{Namespace: "default", Name: "web", Ready: true})
+	r.MarkSynced(changed, deleted)
+	changed, deleted = r.Changes()
+	if len(changed) != 1 || !changed[0].Ready || len(deleted) != 0 {
+		t.Errorf("Changes() after MarkSynced = %v, %v, want the newer web status", changed, deleted)
+	}
+
+	r.MarkSynced(changed, deleted)
+	if changed, deleted := r.Changes(); len(changed) != 0 || len(deleted) != 0 {
+		t.Errorf("Changes() = %v, %v, want none", changed, deleted)
+	}
+}
+
 func TestGetCertificates(t *testing.T) {
 	r := NewCertificateReconciler(nil, nil, zap.NewNop())
 
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+// CheckpointFileName is the checkpoint file stored alongside the state file
+const CheckpointFileName = ".certwatch-certmanager-buffer.json"
+
+// Checkpoint is the on-disk copy of the event, CertificateRequest and ACME
+// buffers, so undelivered failure history survives a restart
+type Checkpoint struct {
+	Delivered      map[string]time.Time             `json:"delivered"` // Event key -> event timestamp
+	Events         []types.CertManagerEvent         `json:"events"`
+	Requests       []types.CertificateRequestStatus `json:"requests"`
+	ACMEOrders     []types.ACMEOrderStatus          `json:"acme_orders,omitempty"`
+	ACMEChallenges []types.ACMEChallengeStatus      `json:"acme_challenges,omitempty"`
+}
+
+// CheckpointPath returns the configured checkpoint path, or CheckpointFileName in stateDir
+func CheckpointPath(configured, stateDir string) string {
+	if configured != "" {
+		return configured
+	}
+	return filepath.Join(stateDir, CheckpointFileName)
+}
+
+// LoadCheckpoint reads a checkpoint. A missing file yields an empty checkpoint.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// Save writes the checkpoint atomically with secure permissions (0600)
+func (c *Checkpoint) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
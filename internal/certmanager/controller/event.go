@@ -2,6 +2,9 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -26,20 +29,29 @@ type EventWatcher struct {
 	// Callback for immediate sync on failure event
 	OnFailureEvent func(event types.CertManagerEvent)
 
-	// Buffer recent events for batch sync
-	mu     sync.RWMutex
-	events []types.CertManagerEvent
-	maxAge time.Duration // How long to keep events in buffer
+	// Buffer undelivered events for batch sync. Keys of delivered events are
+	// kept so the same occurrence isn't sent again when it is reconciled twice.
+	mu        sync.RWMutex
+	events    []types.CertManagerEvent
+	delivered map[string]time.Time // Event key -> event timestamp
+	maxAge    time.Duration        // How long to remember delivered keys
+	maxEvents int                  // Undelivered events kept before the oldest are dropped
 }
 
+// DefaultMaxBufferedEvents bounds the undelivered event buffer during long
+// API outages
+const DefaultMaxBufferedEvents = 5000
+
 // NewEventWatcher creates a new event watcher
 func NewEventWatcher(c client.Client, scheme *runtime.Scheme, logger *zap.Logger) *EventWatcher {
 	return &EventWatcher{
-		Client: c,
-		Scheme: scheme,
-		Logger: logger,
-		events: make([]types.CertManagerEvent, 0),
-		maxAge: 30 * time.Minute, // Keep events for 30 minutes
+		Client:    c,
+		Scheme:    scheme,
+		Logger:    logger,
+		events:    make([]types.CertManagerEvent, 0),
+		delivered: make(map[string]time.Time),
+		maxAge:    30 * time.Minute, // Remember delivered keys for 30 minutes
+		maxEvents: DefaultMaxBufferedEvents,
 	}
 }
 
@@ -69,8 +81,11 @@ func (w *EventWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Re
 	// Extract event data
 	cmEvent := w.extractEvent(&event)
 
-	// Store event in buffer
-	w.storeEvent(cmEvent)
+	// Store event in buffer, once per occurrence
+	if !w.storeEvent(cmEvent) {
+		log.Debug("cert-manager event already buffered or delivered", zap.String("key", cmEvent.Key))
+		return ctrl.Result{}, nil
+	}
 
 	// Update metrics
 	w.updateMetrics(cmEvent)
@@ -123,6 +138,7 @@ func (w *EventWatcher) extractEvent(event *corev1.Event) types.CertManagerEvent
 	cmEvent := types.CertManagerEvent{
 		CertificateNamespace: event.InvolvedObject.Namespace,
 		CertificateName:      event.InvolvedObject.Name,
+		Key:                  eventKey(event),
 		Reason:               event.Reason,
 		Message:              event.Message,
 		Type:                 event.Type,
@@ -149,32 +165,114 @@ func (w *EventWatcher) extractEvent(event *corev1.Event) types.CertManagerEvent
 	return cmEvent
 }
 
-func (w *EventWatcher) storeEvent(event types.CertManagerEvent) {
+// eventKey identifies one occurrence of an event. The API server updates the
+// count of a repeated event instead of creating a new one.
+func eventKey(event *corev1.Event) string {
+	count := event.Count
+	if event.Series != nil {
+		count = event.Series.Count
+	}
+	return fmt.Sprintf("%s/%d", event.UID, count)
+}
+
+// storeEvent buffers an event and reports whether it is new
+func (w *EventWatcher) storeEvent(event types.CertManagerEvent) bool {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if _, ok := w.delivered[event.Key]; ok {
+		return false
+	}
+	for i := range w.events {
+		if w.events[i].Key == event.Key {
+			return false
+		}
+	}
+
 	// Add event to buffer
 	w.events = append(w.events, event)
+	w.trim()
+	return true
+}
 
-	// Clean up old events
+// trim drops the oldest undelivered events once the buffer exceeds maxEvents.
+// Undelivered events are never dropped by age. Caller must hold w.mu.
+func (w *EventWatcher) trim() {
+	overflow := len(w.events) - w.maxEvents
+	if w.maxEvents <= 0 || overflow <= 0 {
+		return
+	}
+	w.Logger.Warn("event buffer full, dropping oldest undelivered events",
+		zap.Int("dropped", overflow),
+		zap.Int("max_events", w.maxEvents),
+	)
+	metrics.BufferDroppedTotal.WithLabelValues("events").Add(float64(overflow))
+	w.events = slices.Delete(w.events, 0, overflow)
+}
+
+// pruneDelivered forgets delivered keys older than maxAge. Caller must hold w.mu.
+func (w *EventWatcher) pruneDelivered() {
 	cutoff := time.Now().Add(-w.maxAge)
-	filtered := make([]types.CertManagerEvent, 0, len(w.events))
-	for i := range w.events {
-		if w.events[i].Timestamp.After(cutoff) {
-			filtered = append(filtered, w.events[i])
+	for key, ts := range w.delivered {
+		if !ts.After(cutoff) {
+			delete(w.delivered, key)
 		}
 	}
-	w.events = filtered
 }
 
-// GetEvents returns all buffered events and clears the buffer
+// GetEvents returns the buffered events without clearing the buffer. Pass
+// them to MarkDelivered once the API has accepted them.
 func (w *EventWatcher) GetEvents() []types.CertManagerEvent {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return slices.Clone(w.events)
+}
+
+// MarkDelivered removes delivered events from the buffer and remembers their
+// keys so they aren't buffered again
+func (w *EventWatcher) MarkDelivered(events []types.CertManagerEvent) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	events := w.events
-	w.events = make([]types.CertManagerEvent, 0)
-	return events
+	for i := range events {
+		w.delivered[events[i].Key] = events[i].Timestamp
+	}
+	w.events = slices.DeleteFunc(w.events, func(e types.CertManagerEvent) bool {
+		_, ok := w.delivered[e.Key]
+		return ok
+	})
+	w.pruneDelivered()
+}
+
+// PendingCount returns the number of undelivered events
+func (w *EventWatcher) PendingCount() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.events)
+}
+
+// Checkpoint returns the undelivered events and the keys of delivered ones
+func (w *EventWatcher) Checkpoint() ([]types.CertManagerEvent, map[string]time.Time) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return slices.Clone(w.events), maps.Clone(w.delivered)
+}
+
+// Restore loads events and delivered keys saved by Checkpoint. It is called
+// before the watcher starts.
+func (w *EventWatcher) Restore(events []types.CertManagerEvent, delivered map[string]time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	maps.Copy(w.delivered, delivered)
+	for i := range events {
+		if _, ok := w.delivered[events[i].Key]; !ok {
+			w.events = append(w.events, events[i])
+		}
+	}
+	w.pruneDelivered()
+	w.trim()
 }
 
 // GetRecentEvents returns events from the buffer without clearing
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cmtypes "github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+func newFailedEvent(count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web.17a", UID: "uid-1"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Certificate",
+			APIVersion: "cert-manager.io/v1",
+			Namespace:  "default",
+			Name:       "web",
+		},
+		Reason:        "Failed",
+		Message:       "The certificate request has failed to complete",
+		Type:          corev1.EventTypeWarning,
+		Count:         count,
+		LastTimestamp: metav1.NewTime(time.Now()),
+	}
+}
+
+func TestEventWatcher_DeliversEachOccurrenceOnce(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := corev1.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	event := newFailedEvent(1)
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(event).Build()
+
+	w := NewEventWatcher(c, s, zap.NewNop())
+	var failures int
+	w.OnFailureEvent = func(_ cmtypes.CertManagerEvent) { failures++ }
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web.17a"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := w.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	reconcile()
+	reconcile() // Resync of the same occurrence
+	events := w.GetEvents()
+	if len(events) != 1 || events[0].Key != "uid-1/1" {
+		t.Fatalf("GetEvents() = %+v, want one event with key uid-1/1", events)
+	}
+	if failures != 1 {
+		t.Errorf("OnFailureEvent called %d times, want 1", failures)
+	}
+
+	// Reading the buffer doesn't clear it, so a failed sync is retried
+	if got := w.GetEvents(); len(got) != 1 {
+		t.Fatalf("GetEvents() again = %d events, want 1", len(got))
+	}
+
+	w.MarkDelivered(events)
+	if w.PendingCount() != 0 {
+		t.Errorf("PendingCount() = %d, want 0", w.PendingCount())
+	}
+	reconcile()
+	if w.PendingCount() != 0 {
+		t.Error("delivered event was buffered again")
+	}
+
+	// The event repeating is a new occurrence
+	event.Count = 2
+	if err := c.Update(ctx, event); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	reconcile()
+	if events := w.GetEvents(); len(events) != 1 || events[0].Key != "uid-1/2" {
+		t.Errorf("GetEvents() = %+v, want the repeated event", events)
+	}
+}
+
+func TestEventWatcher_CheckpointRestore(t *testing.T) {
+	now := time.Now()
+	w := NewEventWatcher(nil, nil, zap.NewNop())
+	w.storeEvent(cmtypes.CertManagerEvent{Key: "a/1", Reason: "Failed", Timestamp: now})
+	w.storeEvent(cmtypes.CertManagerEvent{Key: "b/1", Reason: "Issuing", Timestamp: now})
+	w.MarkDelivered([]cmtypes.CertManagerEvent{{Key: "b/1", Timestamp: now}})
+
+	r := NewCertificateRequestReconciler(nil, nil, zap.NewNop())
+	r.storeRequest(cmtypes.CertificateRequestStatus{Namespace: "default", Name: "web-1", ResourceVersion: "7", Failed: true})
+
+	path := filepath.Join(t.TempDir(), "state", CheckpointFileName)
+	cp := &Checkpoint{Requests: r.Checkpoint()}
+	cp.Events, cp.Delivered = w.Checkpoint()
+	if err := cp.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error = %v", err)
+	}
+	restored := NewEventWatcher(nil, nil, zap.NewNop())
+	restored.Restore(loaded.Events, loaded.Delivered)
+	if events := restored.GetEvents(); len(events) != 1 || events[0].Key != "a/1" {
+		t.Errorf("restored events = %+v, want a/1", events)
+	}
+	if restored.storeEvent(cmtypes.CertManagerEvent{Key: "b/1", Timestamp: now}) {
+		t.Error("event delivered before the restart was buffered again")
+	}
+
+	restoredRequests := NewCertificateRequestReconciler(nil, nil, zap.NewNop())
+	restoredRequests.Restore(loaded.Requests)
+	if got := restoredRequests.GetRequests(); len(got) != 1 || !got[0].Failed {
+		t.Errorf("restored requests = %+v, want the failed request", got)
+	}
+
+	// A missing file is an empty checkpoint
+	empty, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(empty.Events) != 0 {
+		t.Errorf("LoadCheckpoint(missing) = %+v, %v, want empty", empty, err)
+	}
+}
+
+func TestEventWatcher_KeepsOldUndeliveredEvents(t *testing.T) {
+	old := time.Now().Add(-24 * time.Hour)
+	w := NewEventWatcher(nil, nil, zap.NewNop())
+	w.Restore([]cmtypes.CertManagerEvent{{Key: "a/1", Timestamp: old}}, map[string]time.Time{"b/1": old})
+	if events := w.GetEvents(); len(events) != 1 || events[0].Key != "a/1" {
+		t.Fatalf("GetEvents() = %+v, want the day-old undelivered event", events)
+	}
+	if _, delivered := w.Checkpoint(); len(delivered) != 0 {
+		t.Errorf("delivered keys = %v, want old keys aged out", delivered)
+	}
+
+	// Only the count bounds the buffer, dropping the oldest first
+	w.maxEvents = 2
+	w.storeEvent(cmtypes.CertManagerEvent{Key: "c/1", Timestamp: old})
+	w.storeEvent(cmtypes.CertManagerEvent{Key: "d/1", Timestamp: old})
+	events := w.GetEvents()
+	if len(events) != 2 || events[0].Key != "c/1" || events[1].Key != "d/1" {
+		t.Errorf("GetEvents() = %+v, want c/1 and d/1", events)
+	}
+}
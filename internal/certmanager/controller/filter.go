@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// IgnoreAnnotation opts a Certificate out of monitoring when set to "true"
+const IgnoreAnnotation = "certwatch.app/ignore"
+
+// CertificateFilter selects the Certificates to watch and the metadata
+// forwarded with them as tags
+type CertificateFilter struct {
+	Selector          labels.Selector // Certificate labels; nil matches every Certificate
+	NamespaceSelector labels.Selector // Namespace labels; nil matches every namespace
+	TagLabels         []string
+	TagAnnotations    []string
+}
+
+// NewCertificateFilter parses the label and namespace selectors. Empty
+// selectors match everything.
+func NewCertificateFilter(selector, namespaceSelector string, tagLabels, tagAnnotations []string) (*CertificateFilter, error) {
+	f := &CertificateFilter{TagLabels: tagLabels, TagAnnotations: tagAnnotations}
+	if selector != "" {
+		s, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector: %w", err)
+		}
+		f.Selector = s
+	}
+	if namespaceSelector != "" {
+		s, err := labels.Parse(namespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+		f.NamespaceSelector = s
+	}
+	return f, nil
+}
+
+// Ignored reports whether obj opted out with the IgnoreAnnotation
+func Ignored(obj metav1.Object) bool {
+	ignored, _ := strconv.ParseBool(obj.GetAnnotations()[IgnoreAnnotation])
+	return ignored
+}
+
+// Tags returns the configured labels and annotations present on obj. An
+// annotation takes precedence over a label with the same key.
+func (f *CertificateFilter) Tags(obj metav1.Object) map[string]string {
+	var tags map[string]string
+	add := func(source map[string]string, keys []string) {
+		for _, key := range keys {
+			if v, ok := source[key]; ok {
+				if tags == nil {
+					tags = make(map[string]string)
+				}
+				tags[key] = v
+			}
+		}
+	}
+	add(obj.GetLabels(), f.TagLabels)
+	add(obj.GetAnnotations(), f.TagAnnotations)
+	return tags
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCertificateReconciler_Filter(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := corev1.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	if err := cmapi.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+
+	newCert := func(namespace, name string, labels, annotations map[string]string) *cmapi.Certificate {
+		return &cmapi.Certificate{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace, Name: name, Labels: labels, Annotations: annotations,
+		}}
+	}
+	web := newCert("prod", "web", map[string]string{"team": "payments", "app": "web"}, map[string]string{"owner": "alice"})
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}}},
+		web,
+		newCert("prod", "legacy", nil, map[string]string{IgnoreAnnotation: "true"}),
+		newCert("dev", "api", map[string]string{"team": "payments"}, nil),
+	).Build()
+
+	filter, err := NewCertificateFilter("team", "env=prod", []string{"team", "env"}, []string{"owner"})
+	if err != nil {
+		t.Fatalf("NewCertificateFilter() error = %v", err)
+	}
+	r := NewCertificateReconciler(c, s, zap.NewNop())
+	r.Filter = filter
+
+	ctx := context.Background()
+	reconcile := func(namespace, name string) {
+		t.Helper()
+		req := ctrl.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: name}}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile(%s/%s) error = %v", namespace, name, err)
+		}
+	}
+	reconcile("prod", "web")
+	reconcile("prod", "legacy")
+	reconcile("dev", "api")
+
+	certs := r.GetCertificates()
+	if len(certs) != 1 || certs[0].Name != "web" {
+		t.Fatalf("GetCertificates() = %+v, want only prod/web", certs)
+	}
+	tags := certs[0].Tags
+	if len(tags) != 2 || tags["team"] != "payments" || tags["owner"] != "alice" {
+		t.Errorf("Tags = %v, want team=payments and owner=alice", tags)
+	}
+
+	// Opting out later reports the certificate as deleted
+	r.MarkAllSynced(certs)
+	web.Annotations[IgnoreAnnotation] = "true"
+	if err := c.Update(ctx, web); err != nil {
+		t.Fatalf("failed to update certificate: %v", err)
+	}
+	reconcile("prod", "web")
+	if _, deleted := r.Changes(); len(deleted) != 1 || deleted[0].Name != "web" {
+		t.Errorf("deleted = %v, want prod/web", deleted)
+	}
+}
+
+func TestNewCertificateFilter_InvalidSelector(t *testing.T) {
+	if _, err := NewCertificateFilter("team in (a", "", nil, nil); err == nil {
+		t.Error("NewCertificateFilter() error = nil, want error for invalid label selector")
+	}
+	if _, err := NewCertificateFilter("", "env in prod", nil, nil); err == nil {
+		t.Error("NewCertificateFilter() error = nil, want error for invalid namespace selector")
+	}
+}
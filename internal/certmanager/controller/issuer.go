@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/metrics"
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+// Issuer kinds
+const (
+	KindIssuer        = "Issuer"
+	KindClusterIssuer = "ClusterIssuer"
+)
+
+// IssuerReconciler watches cert-manager Issuer and ClusterIssuer resources
+type IssuerReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger *zap.Logger
+
+	// Callback when an issuer becomes not ready
+	OnNotReady func(issuer types.IssuerStatus)
+
+	// SkipClusterIssuers only watches namespaced Issuers, for agents without
+	// cluster-wide read access
+	SkipClusterIssuers bool
+
+	// Sync state
+	mu      sync.RWMutex
+	issuers map[string]types.IssuerStatus // key: kind/namespace/name
+}
+
+// NewIssuerReconciler creates a new reconciler
+func NewIssuerReconciler(c client.Client, scheme *runtime.Scheme, logger *zap.Logger) *IssuerReconciler {
+	return &IssuerReconciler{
+		Client:  c,
+		Scheme:  scheme,
+		Logger:  logger,
+		issuers: make(map[string]types.IssuerStatus),
+	}
+}
+
+// ReconcileIssuer handles namespaced Issuer changes
+func (r *IssuerReconciler) ReconcileIssuer(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var issuer cmapi.Issuer
+	return r.reconcile(ctx, req, KindIssuer, &issuer, func() types.IssuerStatus {
+		return extractIssuerStatus(KindIssuer, issuer.Namespace, issuer.Name, &issuer.Spec, &issuer.Status)
+	})
+}
+
+// ReconcileClusterIssuer handles ClusterIssuer changes
+func (r *IssuerReconciler) ReconcileClusterIssuer(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var issuer cmapi.ClusterIssuer
+	return r.reconcile(ctx, req, KindClusterIssuer, &issuer, func() types.IssuerStatus {
+		return extractIssuerStatus(KindClusterIssuer, "", issuer.Name, &issuer.Spec, &issuer.Status)
+	})
+}
+
+// reconcile fetches obj and stores its extracted status; Issuer and ClusterIssuer share this flow
+func (r *IssuerReconciler) reconcile(ctx context.Context, req ctrl.Request, kind string, obj client.Object, extract func() types.IssuerStatus) (ctrl.Result, error) {
+	start := time.Now()
+	controllerName := lowerKind(kind)
+	log := r.Logger.With(
+		zap.String("kind", kind),
+		zap.String("namespace", req.Namespace),
+		zap.String("name", req.Name),
+	)
+
+	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Debug("issuer deleted")
+			r.removeIssuer(kind, req.Namespace, req.Name)
+			metrics.ReconcileTotal.WithLabelValues(controllerName, "deleted").Inc()
+			return ctrl.Result{}, nil
+		}
+		log.Error("failed to get issuer", zap.Error(err))
+		metrics.ReconcileTotal.WithLabelValues(controllerName, "error").Inc()
+		return ctrl.Result{}, err
+	}
+
+	status := extract()
+	prev, seen := r.storeIssuer(status)
+	if seen && prev.Type != status.Type {
+		metrics.IssuerReady.DeleteLabelValues(prev.Namespace, prev.Name, prev.Kind, prev.Type)
+	}
+	r.updateMetrics(status)
+
+	// Notify when an issuer transitions to not ready (or is first seen not ready)
+	if !status.Ready && (!seen || prev.Ready) && r.OnNotReady != nil {
+		log.Info("issuer not ready",
+			zap.String("reason", status.ReadyReason),
+			zap.String("message", status.ReadyMessage),
+		)
+		r.OnNotReady(status)
+	}
+
+	log.Debug("issuer reconciled",
+		zap.String("type", status.Type),
+		zap.Bool("ready", status.Ready),
+	)
+
+	metrics.ReconcileTotal.WithLabelValues(controllerName, "success").Inc()
+	metrics.ReconcileDuration.WithLabelValues(controllerName).Observe(time.Since(start).Seconds())
+
+	return ctrl.Result{}, nil
+}
+
+func extractIssuerStatus(kind, namespace, name string, spec *cmapi.IssuerSpec, st *cmapi.IssuerStatus) types.IssuerStatus {
+	status := types.IssuerStatus{
+		Namespace: namespace,
+		Name:      name,
+		Kind:      kind,
+		Type:      issuerType(&spec.IssuerConfig),
+	}
+
+	for _, cond := range st.Conditions {
+		if cond.Type != cmapi.IssuerConditionReady {
+			continue
+		}
+		status.Ready = cond.Status == cmmeta.ConditionTrue
+		status.ReadyReason = cond.Reason
+		status.ReadyMessage = cond.Message
+		if cond.LastTransitionTime != nil && !cond.LastTransitionTime.IsZero() {
+			t := cond.LastTransitionTime.Time
+			status.LastTransition = &t
+		}
+	}
+
+	if spec.ACME != nil {
+		status.ACMEServer = spec.ACME.Server
+		status.ACMEEmail = spec.ACME.Email
+		if st.ACME != nil {
+			status.ACMEAccountURI = st.ACME.URI
+			status.ACMERegistered = st.ACME.URI != ""
+		}
+	}
+
+	return status
+}
+
+// issuerType returns which issuer backend is configured
+func issuerType(cfg *cmapi.IssuerConfig) string {
+	switch {
+	case cfg.ACME != nil:
+		return types.IssuerTypeACME
+	case cfg.CA != nil:
+		return types.IssuerTypeCA
+	case cfg.Vault != nil:
+		return types.IssuerTypeVault
+	case cfg.Venafi != nil:
+		return types.IssuerTypeVenafi
+	case cfg.SelfSigned != nil:
+		return types.IssuerTypeSelfSigned
+	}
+	return types.IssuerTypeUnknown
+}
+
+// storeIssuer records status and returns the previously stored status, if any
+func (r *IssuerReconciler) storeIssuer(status types.IssuerStatus) (types.IssuerStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := issuerKey(status.Kind, status.Namespace, status.Name)
+	prev, seen := r.issuers[key]
+	r.issuers[key] = status
+	metrics.IssuersWatched.Set(float64(len(r.issuers)))
+	return prev, seen
+}
+
+func (r *IssuerReconciler) removeIssuer(kind, namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := issuerKey(kind, namespace, name)
+	prev, ok := r.issuers[key]
+	delete(r.issuers, key)
+	metrics.IssuersWatched.Set(float64(len(r.issuers)))
+
+	// Clean up metrics for deleted issuer
+	if ok {
+		metrics.IssuerReady.DeleteLabelValues(namespace, name, kind, prev.Type)
+		metrics.IssuerACMERegistered.DeleteLabelValues(namespace, name, kind)
+	}
+}
+
+// GetIssuers returns all watched issuers for syncing
+func (r *IssuerReconciler) GetIssuers() []types.IssuerStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	issuers := make([]types.IssuerStatus, 0, len(r.issuers))
+	for k := range r.issuers {
+		issuers = append(issuers, r.issuers[k])
+	}
+	return issuers
+}
+
+func (r *IssuerReconciler) updateMetrics(status types.IssuerStatus) {
+	labels := []string{status.Namespace, status.Name, status.Kind, status.Type}
+
+	if status.Ready {
+		metrics.IssuerReady.WithLabelValues(labels...).Set(1)
+	} else {
+		metrics.IssuerReady.WithLabelValues(labels...).Set(0)
+	}
+
+	if status.Type == types.IssuerTypeACME {
+		registered := 0.0
+		if status.ACMERegistered {
+			registered = 1
+		}
+		metrics.IssuerACMERegistered.WithLabelValues(status.Namespace, status.Name, status.Kind).Set(registered)
+	}
+}
+
+// SetupWithManager sets up the Issuer and, unless skipped, ClusterIssuer controllers with the Manager
+func (r *IssuerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&cmapi.Issuer{}).
+		Named("issuer").
+		Complete(reconcile.Func(r.ReconcileIssuer)); err != nil {
+		return err
+	}
+	if r.SkipClusterIssuers {
+		return nil
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&cmapi.ClusterIssuer{}).
+		Named("clusterissuer").
+		Complete(reconcile.Func(r.ReconcileClusterIssuer))
+}
+
+func issuerKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func lowerKind(kind string) string {
+	if kind == KindClusterIssuer {
+		return "clusterissuer"
+	}
+	return "issuer"
+}
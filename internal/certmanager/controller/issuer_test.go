@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cmtypes "github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+func TestIssuerType(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  cmapi.IssuerConfig
+		want string
+	}{
+		{"acme", cmapi.IssuerConfig{ACME: &cmacme.ACMEIssuer{}}, cmtypes.IssuerTypeACME},
+		{"ca", cmapi.IssuerConfig{CA: &cmapi.CAIssuer{}}, cmtypes.IssuerTypeCA},
+		{"vault", cmapi.IssuerConfig{Vault: &cmapi.VaultIssuer{}}, cmtypes.IssuerTypeVault},
+		{"venafi", cmapi.IssuerConfig{Venafi: &cmapi.VenafiIssuer{}}, cmtypes.IssuerTypeVenafi},
+		{"selfsigned", cmapi.IssuerConfig{SelfSigned: &cmapi.SelfSignedIssuer{}}, cmtypes.IssuerTypeSelfSigned},
+		{"empty", cmapi.IssuerConfig{}, cmtypes.IssuerTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issuerType(&tt.cfg); got != tt.want {
+				t.Errorf("issuerType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractIssuerStatus_ACME(t *testing.T) {
+	spec := &cmapi.IssuerSpec{
+		IssuerConfig: cmapi.IssuerConfig{
+			ACME: &cmacme.ACMEIssuer{
+				Server: "https://acme-v02.api.letsencrypt.org/directory",
+				Email:  "ops@example.com",
+			},
+		},
+	}
+	st := &cmapi.IssuerStatus{
+		Conditions: []cmapi.IssuerCondition{{
+			Type:    cmapi.IssuerConditionReady,
+			Status:  cmmeta.ConditionTrue,
+			Reason:  "ACMEAccountRegistered",
+			Message: "The ACME account was registered with the ACME server",
+		}},
+		ACME: &cmacme.ACMEIssuerStatus{URI: "https://acme-v02.api.letsencrypt.org/acme/acct/1"},
+	}
+
+	status := extractIssuerStatus(KindClusterIssuer, "", "letsencrypt", spec, st)
+
+	if status.Kind != KindClusterIssuer {
+		t.Errorf("Kind = %v, want %v", status.Kind, KindClusterIssuer)
+	}
+	if status.Type != cmtypes.IssuerTypeACME {
+		t.Errorf("Type = %v, want ACME", status.Type)
+	}
+	if !status.Ready {
+		t.Error("Ready = false, want true")
+	}
+	if status.ReadyReason != "ACMEAccountRegistered" {
+		t.Errorf("ReadyReason = %v, want ACMEAccountRegistered", status.ReadyReason)
+	}
+	if !status.ACMERegistered {
+		t.Error("ACMERegistered = false, want true")
+	}
+	if status.ACMEEmail != "ops@example.com" {
+		t.Errorf("ACMEEmail = %v, want ops@example.com", status.ACMEEmail)
+	}
+}
+
+func TestExtractIssuerStatus_NotReady(t *testing.T) {
+	spec := &cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{ACME: &cmacme.ACMEIssuer{}}}
+	st := &cmapi.IssuerStatus{
+		Conditions: []cmapi.IssuerCondition{{
+			Type:    cmapi.IssuerConditionReady,
+			Status:  cmmeta.ConditionFalse,
+			Reason:  "ErrRegisterACMEAccount",
+			Message: "Failed to register ACME account",
+		}},
+	}
+
+	status := extractIssuerStatus(KindIssuer, "default", "acme", spec, st)
+
+	if status.Ready {
+		t.Error("Ready = true, want false")
+	}
+	if status.ACMERegistered {
+		t.Error("ACMERegistered = true, want false")
+	}
+	if status.Namespace != "default" {
+		t.Errorf("Namespace = %v, want default", status.Namespace)
+	}
+}
+
+func TestIssuerReconciler_NotReadyCallback(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := cmapi.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+
+	issuer := &cmapi.ClusterIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca"},
+		Spec:       cmapi.IssuerSpec{IssuerConfig: cmapi.IssuerConfig{CA: &cmapi.CAIssuer{SecretName: "ca"}}},
+		Status: cmapi.IssuerStatus{
+			Conditions: []cmapi.IssuerCondition{{
+				Type:   cmapi.IssuerConditionReady,
+				Status: cmmeta.ConditionTrue,
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(issuer).Build()
+
+	r := NewIssuerReconciler(c, s, zap.NewNop())
+	var notified []string
+	r.OnNotReady = func(i cmtypes.IssuerStatus) { notified = append(notified, i.Name) }
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ca"}}
+	if _, err := r.ReconcileClusterIssuer(context.Background(), req); err != nil {
+		t.Fatalf("ReconcileClusterIssuer() error = %v", err)
+	}
+	if len(notified) != 0 {
+		t.Errorf("notified = %v, want none for ready issuer", notified)
+	}
+
+	// Transition to not ready
+	issuer.Status.Conditions[0].Status = cmmeta.ConditionFalse
+	if err := c.Update(context.Background(), issuer); err != nil {
+		t.Fatalf("failed to update issuer: %v", err)
+	}
+	if _, err := r.ReconcileClusterIssuer(context.Background(), req); err != nil {
+		t.Fatalf("ReconcileClusterIssuer() error = %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("notified = %v, want 1 notification", notified)
+	}
+
+	// Staying not ready doesn't notify again
+	if _, err := r.ReconcileClusterIssuer(context.Background(), req); err != nil {
+		t.Fatalf("ReconcileClusterIssuer() error = %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("notified = %v, want no repeat notification", notified)
+	}
+
+	issuers := r.GetIssuers()
+	if len(issuers) != 1 || issuers[0].Type != cmtypes.IssuerTypeCA {
+		t.Errorf("GetIssuers() = %v, want one CA issuer", issuers)
+	}
+
+	// Deletion removes the issuer
+	if err := c.Delete(context.Background(), issuer); err != nil {
+		t.Fatalf("failed to delete issuer: %v", err)
+	}
+	if _, err := r.ReconcileClusterIssuer(context.Background(), req); err != nil {
+		t.Fatalf("ReconcileClusterIssuer() error = %v", err)
+	}
+	if len(r.GetIssuers()) != 0 {
+		t.Error("expected issuer to be removed after deletion")
+	}
+}
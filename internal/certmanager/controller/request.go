@@ -25,9 +25,12 @@ type CertificateRequestReconciler struct {
 	// Callback for immediate sync on failure
 	OnFailure func(req types.CertificateRequestStatus)
 
-	// Track requests for metrics
+	// Track requests for metrics and sync. Changes the API hasn't acknowledged
+	// are kept in unsynced, including requests deleted in the meantime, so a
+	// short-lived failed request is still delivered.
 	mu       sync.RWMutex
 	requests map[string]types.CertificateRequestStatus // key: namespace/name
+	unsynced map[string]types.CertificateRequestStatus // key: namespace/name
 }
 
 // NewCertificateRequestReconciler creates a new reconciler
@@ -37,6 +40,7 @@ func NewCertificateRequestReconciler(c client.Client, scheme *runtime.Scheme, lo
 		Scheme:   scheme,
 		Logger:   logger,
 		requests: make(map[string]types.CertificateRequestStatus),
+		unsynced: make(map[string]types.CertificateRequestStatus),
 	}
 }
 
@@ -94,9 +98,10 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 func (r *CertificateRequestReconciler) extractStatus(cr *cmapi.CertificateRequest) types.CertificateRequestStatus {
 	status := types.CertificateRequestStatus{
-		Namespace: cr.Namespace,
-		Name:      cr.Name,
-		CreatedAt: cr.CreationTimestamp.Time,
+		Namespace:       cr.Namespace,
+		Name:            cr.Name,
+		ResourceVersion: cr.ResourceVersion,
+		CreatedAt:       cr.CreationTimestamp.Time,
 	}
 
 	// Get owner certificate name from owner references
@@ -162,6 +167,9 @@ func (r *CertificateRequestReconciler) storeRequest(status types.CertificateRequ
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	key := status.Namespace + "/" + status.Name
+	if prev, ok := r.requests[key]; !ok || prev.ResourceVersion != status.ResourceVersion {
+		r.unsynced[key] = status
+	}
 	r.requests[key] = status
 }
 
@@ -172,17 +180,64 @@ func (r *CertificateRequestReconciler) removeRequest(namespace, name string) {
 	delete(r.requests, key)
 }
 
-// GetRequests returns all tracked certificate requests
+// GetRequests returns all tracked certificate requests, and deleted requests
+// whose last state hasn't been synced yet
 func (r *CertificateRequestReconciler) GetRequests() []types.CertificateRequestStatus {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	requests := make([]types.CertificateRequestStatus, 0, len(r.requests))
+	requests := make([]types.CertificateRequestStatus, 0, len(r.requests)+len(r.unsynced))
 	for k := range r.requests {
 		requests = append(requests, r.requests[k])
 	}
+	for k := range r.unsynced {
+		if _, ok := r.requests[k]; !ok {
+			requests = append(requests, r.unsynced[k])
+		}
+	}
+	return requests
+}
+
+// MarkSynced records that the API accepted the given request states. Requests
+// changed since they were read stay unsynced.
+func (r *CertificateRequestReconciler) MarkSynced(requests []types.CertificateRequestStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range requests {
+		key := requests[i].Namespace + "/" + requests[i].Name
+		if u, ok := r.unsynced[key]; ok && u.ResourceVersion == requests[i].ResourceVersion {
+			delete(r.unsynced, key)
+		}
+	}
+}
+
+// PendingCount returns the number of request states not yet synced
+func (r *CertificateRequestReconciler) PendingCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.unsynced)
+}
+
+// Checkpoint returns the request states not yet synced
+func (r *CertificateRequestReconciler) Checkpoint() []types.CertificateRequestStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	requests := make([]types.CertificateRequestStatus, 0, len(r.unsynced))
+	for k := range r.unsynced {
+		requests = append(requests, r.unsynced[k])
+	}
 	return requests
 }
 
+// Restore loads request states saved by Checkpoint. It is called before the
+// reconciler starts; requests that still exist are replaced when reconciled.
+func (r *CertificateRequestReconciler) Restore(requests []types.CertificateRequestStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range requests {
+		r.unsynced[requests[i].Namespace+"/"+requests[i].Name] = requests[i]
+	}
+}
+
 // GetFailedRequests returns only failed or denied certificate requests
 func (r *CertificateRequestReconciler) GetFailedRequests() []types.CertificateRequestStatus {
 	r.mu.RLock()
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	cmtypes "github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+func TestCertificateRequestReconciler_KeepsUnsyncedDeletedRequests(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := cmapi.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+
+	cr := &cmapi.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-1"},
+		Status: cmapi.CertificateRequestStatus{
+			Conditions: []cmapi.CertificateRequestCondition{{
+				Type:   cmapi.CertificateRequestConditionReady,
+				Status: cmmeta.ConditionFalse,
+				Reason: "Failed",
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build()
+	r := NewCertificateRequestReconciler(c, s, zap.NewNop())
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web-1"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	reconcile()
+	if r.PendingCount() != 1 {
+		t.Fatalf("PendingCount() = %d, want 1", r.PendingCount())
+	}
+
+	// Deleted before the failure was synced: still reported
+	if err := c.Delete(ctx, cr); err != nil {
+		t.Fatalf("failed to delete request: %v", err)
+	}
+	reconcile()
+	requests := r.GetRequests()
+	if len(requests) != 1 || !requests[0].Failed {
+		t.Fatalf("GetRequests() = %+v, want the deleted failed request", requests)
+	}
+
+	r.MarkSynced(requests)
+	if got := r.GetRequests(); len(got) != 0 {
+		t.Errorf("GetRequests() after sync = %+v, want none", got)
+	}
+	if r.PendingCount() != 0 {
+		t.Errorf("PendingCount() = %d, want 0", r.PendingCount())
+	}
+}
+
+func TestCertificateRequestReconciler_MarkSyncedKeepsNewerState(t *testing.T) {
+	r := NewCertificateRequestReconciler(nil, nil, zap.NewNop())
+	r.storeRequest(cmtypes.CertificateRequestStatus{Namespace: "default", Name: "web-1", ResourceVersion: "1"})
+	synced := r.GetRequests()
+
+	// Changed while the sync was in flight
+	r.storeRequest(cmtypes.CertificateRequestStatus{Namespace: "default", Name: "web-1", ResourceVersion: "2", Failed: true})
+	r.MarkSynced(synced)
+	if r.PendingCount() != 1 {
+		t.Errorf("PendingCount() = %d, want the newer state to stay pending", r.PendingCount())
+	}
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+// inspectSecret reads the Certificate's Secret and analyzes the stored certificate chain
+func (r *CertificateReconciler) inspectSecret(ctx context.Context, cert *cmapi.Certificate) *types.SecretStatus {
+	var secret corev1.Secret
+	key := client.ObjectKey{Namespace: cert.Namespace, Name: cert.Spec.SecretName}
+	if err := r.SecretReader.Get(ctx, key, &secret); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return &types.SecretStatus{Error: fmt.Sprintf("secret %s not found", cert.Spec.SecretName)}
+		}
+		return &types.SecretStatus{Error: fmt.Sprintf("failed to read secret %s: %v", cert.Spec.SecretName, err)}
+	}
+
+	return analyzeSecret(cert, secret.Data[corev1.TLSCertKey])
+}
+
+// analyzeSecret decodes tls.crt, runs the scanner chain checks on it and compares
+// the leaf against the Certificate spec and status
+func analyzeSecret(cert *cmapi.Certificate, tlsCrt []byte) *types.SecretStatus {
+	if len(tlsCrt) == 0 {
+		return &types.SecretStatus{Error: fmt.Sprintf("secret %s has no %s", cert.Spec.SecretName, corev1.TLSCertKey)}
+	}
+
+	certs, err := scanner.ParsePEMCertificates(tlsCrt)
+	if err != nil {
+		return &types.SecretStatus{Error: fmt.Sprintf("failed to decode %s: %v", corev1.TLSCertKey, err)}
+	}
+
+	leaf := certs[0]
+	info := scanner.ParseCertificate(leaf)
+	chain := scanner.ParseChain(certs, "")
+
+	notBefore := info.NotBefore
+	notAfter := info.NotAfter
+	status := &types.SecretStatus{
+		Subject:           info.Subject,
+		Issuer:            info.Issuer,
+		SerialNumber:      info.SerialNumber,
+		FingerprintSHA256: info.FingerprintSHA256,
+		KeyAlgorithm:      info.KeyAlgorithm,
+		KeySize:           info.KeySize,
+		SANList:           info.SANList,
+		NotBefore:         &notBefore,
+		NotAfter:          &notAfter,
+		ChainLength:       len(certs),
+		ChainValid:        chain.Valid,
+	}
+	for _, issue := range chain.Issues {
+		status.ChainIssues = append(status.ChainIssues, types.SecretIssue{
+			Type:             issue.Type,
+			Message:          issue.Message,
+			CertificateIndex: issue.CertificateIndex,
+		})
+	}
+
+	status.Drift = detectDrift(cert, leaf, info)
+	return status
+}
+
+// detectDrift reports differences between the leaf stored in the Secret and what the
+// Certificate asks for (names, key) or reports as issued (validity window)
+func detectDrift(cert *cmapi.Certificate, leaf *x509.Certificate, info *scanner.CertificateInfo) []types.SecretIssue {
+	var drift []types.SecretIssue
+
+	if cn := cert.Spec.CommonName; cn != "" && !strings.EqualFold(cn, leaf.Subject.CommonName) {
+		drift = append(drift, types.SecretIssue{
+			Type:    types.DriftCommonName,
+			Message: fmt.Sprintf("Secret common name %q does not match spec %q", leaf.Subject.CommonName, cn),
+		})
+	}
+
+	if len(cert.Spec.DNSNames) > 0 {
+		if missing, unexpected := diffDNSNames(cert.Spec.DNSNames, cert.Spec.CommonName, leaf.DNSNames); len(missing) > 0 || len(unexpected) > 0 {
+			drift = append(drift, types.SecretIssue{
+				Type:    types.DriftDNSNames,
+				Message: fmt.Sprintf("Secret DNS names differ from spec (missing: %v, unexpected: %v)", missing, unexpected),
+			})
+		}
+	}
+
+	if pk := cert.Spec.PrivateKey; pk != nil && pk.Algorithm != "" {
+		wantAlgo := string(pk.Algorithm)
+		if !strings.EqualFold(wantAlgo, info.KeyAlgorithm) || (pk.Size > 0 && info.KeySize > 0 && pk.Size != info.KeySize) {
+			drift = append(drift, types.SecretIssue{
+				Type: types.DriftKeyAlgorithm,
+				Message: fmt.Sprintf("Secret key is %s %d, spec requests %s %d",
+					info.KeyAlgorithm, info.KeySize, wantAlgo, pk.Size),
+			})
+		}
+	}
+
+	// After a renewal the status moves to the new certificate; an older leaf in the
+	// Secret means the new certificate was never written (or was overwritten)
+	if cert.Status.NotAfter != nil && leaf.NotAfter.Before(cert.Status.NotAfter.Add(-time.Second)) {
+		drift = append(drift, types.SecretIssue{
+			Type: types.DriftStale,
+			Message: fmt.Sprintf("Secret certificate expires %s, Certificate status reports %s",
+				leaf.NotAfter.UTC().Format(time.RFC3339), cert.Status.NotAfter.UTC().Format(time.RFC3339)),
+		})
+	}
+
+	return drift
+}
+
+// diffDNSNames compares the requested DNS names against the leaf's DNS SANs.
+// The common name is tolerated as an extra SAN since issuers commonly add it.
+func diffDNSNames(spec []string, commonName string, actual []string) (missing, unexpected []string) {
+	want := make(map[string]bool, len(spec)+1)
+	for _, name := range spec {
+		want[strings.ToLower(name)] = true
+	}
+	have := make(map[string]bool, len(actual))
+	for _, name := range actual {
+		have[strings.ToLower(name)] = true
+	}
+
+	for name := range want {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	for name := range have {
+		if !want[name] && name != strings.ToLower(commonName) {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected
+}
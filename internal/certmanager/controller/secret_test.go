@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+)
+
+// newTestPEM creates a PEM-encoded self-signed ECDSA P-256 certificate
+func newTestPEM(t *testing.T, commonName string, dnsNames []string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newTestCertificate(notAfter time.Time) *cmapi.Certificate {
+	return &cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: cmapi.CertificateSpec{
+			SecretName: "web-tls",
+			CommonName: "example.com",
+			DNSNames:   []string{"example.com", "www.example.com"},
+			PrivateKey: &cmapi.CertificatePrivateKey{Algorithm: cmapi.ECDSAKeyAlgorithm, Size: 256},
+		},
+		Status: cmapi.CertificateStatus{
+			NotAfter: &metav1.Time{Time: notAfter},
+		},
+	}
+}
+
+func driftTypes(s *types.SecretStatus) map[string]bool {
+	got := make(map[string]bool, len(s.Drift))
+	for _, d := range s.Drift {
+		got[d.Type] = true
+	}
+	return got
+}
+
+func TestAnalyzeSecret_Matching(t *testing.T) {
+	notAfter := time.Now().Add(60 * 24 * time.Hour).Truncate(time.Second)
+	cert := newTestCertificate(notAfter)
+	data := newTestPEM(t, "example.com", []string{"example.com", "www.example.com"}, notAfter)
+
+	status := analyzeSecret(cert, data)
+
+	if status.Error != "" {
+		t.Fatalf("Error = %v, want empty", status.Error)
+	}
+	if status.Subject != "example.com" {
+		t.Errorf("Subject = %v, want example.com", status.Subject)
+	}
+	if status.SerialNumber != "42" {
+		t.Errorf("SerialNumber = %v, want 42", status.SerialNumber)
+	}
+	if len(status.FingerprintSHA256) != 64 {
+		t.Errorf("FingerprintSHA256 = %v, want 64 hex chars", status.FingerprintSHA256)
+	}
+	if status.KeyAlgorithm != "ECDSA" || status.KeySize != 256 {
+		t.Errorf("key = %v %v, want ECDSA 256", status.KeyAlgorithm, status.KeySize)
+	}
+	if status.ChainLength != 1 {
+		t.Errorf("ChainLength = %v, want 1", status.ChainLength)
+	}
+	if len(status.SANList) != 2 {
+		t.Errorf("len(SANList) = %v, want 2", len(status.SANList))
+	}
+	if len(status.Drift) != 0 {
+		t.Errorf("Drift = %v, want none", status.Drift)
+	}
+
+	// Self-signed test certificate is reported through the shared chain checks
+	var selfSigned bool
+	for _, issue := range status.ChainIssues {
+		if issue.Type == "self_signed" {
+			selfSigned = true
+		}
+	}
+	if !selfSigned {
+		t.Errorf("ChainIssues = %v, want self_signed", status.ChainIssues)
+	}
+}
+
+func TestAnalyzeSecret_Drift(t *testing.T) {
+	renewed := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	old := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second)
+	cert := newTestCertificate(renewed)
+	cert.Spec.PrivateKey = &cmapi.CertificatePrivateKey{Algorithm: cmapi.RSAKeyAlgorithm, Size: 2048}
+
+	// Secret still holds an old certificate with a different name
+	data := newTestPEM(t, "old.example.com", []string{"old.example.com", "example.com"}, old)
+
+	status := analyzeSecret(cert, data)
+
+	got := driftTypes(status)
+	for _, want := range []string{types.DriftCommonName, types.DriftDNSNames, types.DriftKeyAlgorithm, types.DriftStale} {
+		if !got[want] {
+			t.Errorf("Drift missing %v, got %v", want, status.Drift)
+		}
+	}
+}
+
+func TestAnalyzeSecret_InvalidData(t *testing.T) {
+	cert := newTestCertificate(time.Now())
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not pem", []byte("garbage")},
+		{"bad der", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := analyzeSecret(cert, tt.data)
+			if status.Error == "" {
+				t.Error("expected error for invalid tls.crt")
+			}
+		})
+	}
+}
+
+func TestDiffDNSNames(t *testing.T) {
+	missing, unexpected := diffDNSNames(
+		[]string{"a.example.com", "B.example.com"},
+		"cn.example.com",
+		[]string{"b.example.com", "cn.example.com", "c.example.com"},
+	)
+
+	if len(missing) != 1 || missing[0] != "a.example.com" {
+		t.Errorf("missing = %v, want [a.example.com]", missing)
+	}
+	if len(unexpected) != 1 || unexpected[0] != "c.example.com" {
+		t.Errorf("unexpected = %v, want [c.example.com]", unexpected)
+	}
+}
+
+func TestInspectSecret(t *testing.T) {
+	notAfter := time.Now().Add(60 * 24 * time.Hour).Truncate(time.Second)
+	cert := newTestCertificate(notAfter)
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-tls"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: newTestPEM(t, "example.com", []string{"example.com", "www.example.com"}, notAfter),
+		},
+	}
+
+	r := &CertificateReconciler{
+		Logger:       zap.NewNop(),
+		SecretReader: fake.NewClientBuilder().WithScheme(s).WithObjects(secret).Build(),
+		certificates: make(map[string]types.CertificateStatus),
+	}
+
+	status := r.inspectSecret(context.Background(), cert)
+	if status.Error != "" {
+		t.Fatalf("Error = %v, want empty", status.Error)
+	}
+	if status.Subject != "example.com" {
+		t.Errorf("Subject = %v, want example.com", status.Subject)
+	}
+
+	cert.Spec.SecretName = "missing"
+	status = r.inspectSecret(context.Background(), cert)
+	if status.Error == "" {
+		t.Error("expected error for missing secret")
+	}
+}
@@ -14,15 +14,28 @@ func init() {
 		CertificateExpirySeconds,
 		CertificateDaysUntilExpiry,
 		CertificateFailedAttempts,
+		CertificateSecretDrift,
+		// Issuer metrics
+		IssuerReady,
+		IssuerACMERegistered,
+		IssuersWatched,
 		// Controller metrics
 		ReconcileTotal,
 		ReconcileDuration,
 		// Sync metrics
 		SyncTotal,
 		SyncDuration,
+		OutboxPending,
+		BufferPending,
+		BufferDroppedTotal,
+		SyncPayloadBytes,
+		SyncSentBytesTotal,
+		SyncRetriesTotal,
 		HeartbeatTotal,
+		NotificationsTotal,
 		// Agent metrics
 		AgentInfo,
+		Leader,
 		CertificatesWatched,
 		// Phase 2: CertificateRequest metrics
 		RequestTotal,
@@ -31,6 +44,12 @@ func init() {
 		EventTotal,
 		EventSyncTotal,
 		RequestSyncTotal,
+		IssuerSyncTotal,
+		// ACME Order and Challenge metrics
+		ACMEOrders,
+		ACMEChallenges,
+		ACMEFailuresTotal,
+		ACMESyncTotal,
 	)
 }
 
@@ -77,6 +96,40 @@ var (
 		Help:      "Number of failed issuance attempts",
 	}, []string{"namespace", "name"})
 
+	// CertificateSecretDrift tracks mismatches between a certificate's Secret and its spec/status
+	CertificateSecretDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "certificate_secret_drift",
+		Help:      "Number of drift issues between the certificate Secret and the Certificate spec/status",
+	}, []string{"namespace", "name"})
+
+	// Issuer metrics
+
+	// IssuerReady tracks whether an Issuer or ClusterIssuer is ready
+	IssuerReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "issuer_ready",
+		Help:      "Whether the issuer is ready (1=ready, 0=not ready)",
+	}, []string{"namespace", "name", "kind", "type"})
+
+	// IssuerACMERegistered tracks whether an ACME issuer has a registered account
+	IssuerACMERegistered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "issuer_acme_registered",
+		Help:      "Whether the ACME issuer account is registered (1=registered, 0=not registered)",
+	}, []string{"namespace", "name", "kind"})
+
+	// IssuersWatched tracks number of issuers being watched
+	IssuersWatched = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "issuers_watched",
+		Help:      "Number of Issuers and ClusterIssuers being watched",
+	})
+
 	// Controller metrics
 
 	// ReconcileTotal counts reconciliation operations
@@ -115,6 +168,63 @@ var (
 		Buckets:   prometheus.DefBuckets,
 	})
 
+	// OutboxPending tracks sync payloads queued while the API is unreachable
+	OutboxPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "outbox_pending",
+		Help:      "Number of sync payloads queued for replay while the API is unreachable",
+	})
+
+	// SyncPayloadBytes tracks the size of API request payloads before compression
+	SyncPayloadBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "sync_payload_bytes",
+		Help:      "Size of API request payloads before compression",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 9), // 256B to 16MiB
+	}, []string{"kind"})
+
+	// SyncSentBytesTotal tracks the bytes sent to the API after compression
+	SyncSentBytesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "sync_sent_bytes_total",
+		Help:      "Total request body bytes sent to the API after compression",
+	}, []string{"kind"})
+
+	// BufferPending tracks events and CertificateRequest states not yet delivered
+	BufferPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "buffer_pending",
+		Help:      "Number of events, CertificateRequest and ACME states waiting to be delivered",
+	}, []string{"kind"}) // events, requests, acme
+
+	// BufferDroppedTotal counts undelivered items dropped because a buffer was full
+	BufferDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "buffer_dropped_total",
+		Help:      "Total undelivered items dropped because the buffer was full",
+	}, []string{"kind"})
+
+	// SyncRetriesTotal counts retried API calls
+	SyncRetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "sync_retries_total",
+		Help:      "Total number of retried API calls",
+	}, []string{"operation", "reason"})
+
+	// NotificationsTotal counts alert deliveries sent directly from the agent
+	NotificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "notifications_total",
+		Help:      "Total number of alert deliveries by sink",
+	}, []string{"sink", "status"})
+
 	// HeartbeatTotal counts heartbeat operations
 	HeartbeatTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "certwatch",
@@ -133,6 +243,14 @@ var (
 		Help:      "Agent information",
 	}, []string{"version", "cluster_name"})
 
+	// Leader is 1 on the replica that holds the leader lease and syncs
+	Leader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "leader",
+		Help:      "Whether this replica holds the leader lease and syncs (1=leader, 0=standby)",
+	})
+
 	// CertificatesWatched tracks number of watched certificates
 	CertificatesWatched = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "certwatch",
@@ -189,4 +307,46 @@ var (
 		Name:      "request_sync_total",
 		Help:      "Total CertificateRequest sync operations",
 	}, []string{"status"})
+
+	// IssuerSyncTotal counts Issuer/ClusterIssuer sync operations
+	IssuerSyncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "issuer_sync_total",
+		Help:      "Total Issuer and ClusterIssuer sync operations",
+	}, []string{"status"})
+
+	// ACME Order and Challenge metrics
+
+	// ACMEOrders tracks the watched ACME Orders by state
+	ACMEOrders = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "acme_orders",
+		Help:      "Number of ACME Orders by state",
+	}, []string{"state"})
+
+	// ACMEChallenges tracks the watched ACME Challenges by type and state
+	ACMEChallenges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "acme_challenges",
+		Help:      "Number of ACME Challenges by type (HTTP-01, DNS-01) and state",
+	}, []string{"type", "state"})
+
+	// ACMEFailuresTotal counts Orders and Challenges that failed, by problem category
+	ACMEFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "acme_failures_total",
+		Help:      "Total failed ACME Orders and Challenges by resource and problem",
+	}, []string{"resource", "problem"})
+
+	// ACMESyncTotal counts ACME Order and Challenge sync operations
+	ACMESyncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "certwatch",
+		Subsystem: "certmanager",
+		Name:      "acme_sync_total",
+		Help:      "Total ACME Order and Challenge sync operations",
+	}, []string{"status"})
 )
@@ -124,3 +124,42 @@ func IsFailureMessage(message string) bool {
 	}
 	return false
 }
+
+// CategorizeACMEProblem determines the ACME problem category from an Order or
+// Challenge reason. ACME problem type URNs are matched first, then the messages
+// cert-manager uses for its own self checks. An empty reason has no category.
+func CategorizeACMEProblem(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	lower := strings.ToLower(reason)
+
+	switch {
+	case strings.Contains(lower, "error:ratelimited") ||
+		strings.Contains(lower, "rate limit") ||
+		strings.Contains(lower, "too many"):
+		return ACMEProblemRateLimited
+	case strings.Contains(lower, "dns-01 challenge propagation") ||
+		strings.Contains(lower, "not yet propagated"):
+		return ACMEProblemDNSPropagation
+	case strings.Contains(lower, "error:caa"):
+		return ACMEProblemCAA
+	case strings.Contains(lower, "error:rejectedidentifier"):
+		return ACMEProblemRejected
+	case strings.Contains(lower, "error:dns") ||
+		strings.Contains(lower, "nxdomain") ||
+		strings.Contains(lower, "servfail"):
+		return ACMEProblemDNS
+	case strings.Contains(lower, "error:connection") ||
+		strings.Contains(lower, "timeout during connect") ||
+		strings.Contains(lower, "wrong status code") ||
+		strings.Contains(lower, "failed to perform self check get request"):
+		return ACMEProblemConnection
+	case strings.Contains(lower, "error:unauthorized") ||
+		strings.Contains(lower, "error:incorrectresponse") ||
+		strings.Contains(lower, "incorrect txt record") ||
+		strings.Contains(lower, "invalid response"):
+		return ACMEProblemUnauthorized
+	}
+	return ACMEProblemOther
+}
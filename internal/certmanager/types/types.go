@@ -20,6 +20,9 @@ type CertificateStatus struct {
 	IssuerKind  string `json:"issuer_kind"`
 	IssuerGroup string `json:"issuer_group,omitempty"`
 
+	// Selected labels and annotations
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Status (current state)
 	Ready         bool   `json:"ready"`
 	ReadyReason   string `json:"ready_reason,omitempty"`
@@ -37,6 +40,55 @@ type CertificateStatus struct {
 	Revision        int        `json:"revision"`
 	FailedAttempts  int        `json:"failed_attempts"`
 	LastFailureTime *time.Time `json:"last_failure_time,omitempty"`
+
+	// Secret contents (nil when secret scanning is disabled)
+	Secret *SecretStatus `json:"secret,omitempty"`
+}
+
+// CertificateRef identifies a Certificate that was deleted since the last sync
+type CertificateRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Drift types reported when the Secret contents don't match the Certificate
+const (
+	DriftCommonName   = "common_name_mismatch"
+	DriftDNSNames     = "dns_names_mismatch"
+	DriftKeyAlgorithm = "key_algorithm_mismatch"
+	DriftStale        = "stale_certificate"
+)
+
+// SecretStatus describes the certificate actually stored in a Certificate's Secret
+type SecretStatus struct {
+	// Leaf certificate
+	Subject           string     `json:"subject,omitempty"`
+	Issuer            string     `json:"issuer,omitempty"`
+	SerialNumber      string     `json:"serial_number,omitempty"`
+	FingerprintSHA256 string     `json:"fingerprint_sha256,omitempty"`
+	KeyAlgorithm      string     `json:"key_algorithm,omitempty"`
+	KeySize           int        `json:"key_size,omitempty"`
+	SANList           []string   `json:"san_list,omitempty"`
+	NotBefore         *time.Time `json:"not_before,omitempty"`
+	NotAfter          *time.Time `json:"not_after,omitempty"`
+
+	// Chain
+	ChainLength int           `json:"chain_length"`
+	ChainValid  bool          `json:"chain_valid"`
+	ChainIssues []SecretIssue `json:"chain_issues,omitempty"`
+
+	// Differences between the Secret and the Certificate spec/status
+	Drift []SecretIssue `json:"drift,omitempty"`
+
+	// Set when the Secret could not be read or decoded
+	Error string `json:"error,omitempty"`
+}
+
+// SecretIssue is a chain or drift problem found in a Certificate's Secret
+type SecretIssue struct {
+	Type             string `json:"type"`
+	Message          string `json:"message"`
+	CertificateIndex int    `json:"certificate_index,omitempty"`
 }
 
 // CertManagerSyncPayload is the request body for syncing cert-manager data
@@ -48,6 +100,37 @@ type CertManagerSyncPayload struct {
 	Certificates []CertificateStatus `json:"certificates"`
 }
 
+// Issuer types derived from the configured issuer backend
+const (
+	IssuerTypeACME       = "ACME"
+	IssuerTypeCA         = "CA"
+	IssuerTypeVault      = "Vault"
+	IssuerTypeVenafi     = "Venafi"
+	IssuerTypeSelfSigned = "SelfSigned"
+	IssuerTypeUnknown    = "Unknown"
+)
+
+// IssuerStatus represents the extracted state of a cert-manager Issuer or ClusterIssuer
+type IssuerStatus struct {
+	// Identity
+	Namespace string `json:"namespace,omitempty"` // Empty for ClusterIssuer
+	Name      string `json:"name"`
+	Kind      string `json:"kind"` // Issuer or ClusterIssuer
+	Type      string `json:"type"` // ACME, CA, Vault, Venafi, SelfSigned
+
+	// Ready condition
+	Ready          bool       `json:"ready"`
+	ReadyReason    string     `json:"ready_reason,omitempty"`
+	ReadyMessage   string     `json:"ready_message,omitempty"`
+	LastTransition *time.Time `json:"last_transition,omitempty"`
+
+	// ACME account (only for ACME issuers)
+	ACMEServer     string `json:"acme_server,omitempty"`
+	ACMEEmail      string `json:"acme_email,omitempty"`
+	ACMEAccountURI string `json:"acme_account_uri,omitempty"`
+	ACMERegistered bool   `json:"acme_registered,omitempty"`
+}
+
 // ============================================================================
 // Phase 2: CertificateRequest and Event Types
 // ============================================================================
@@ -67,6 +150,7 @@ type CertificateRequestStatus struct {
 	Namespace       string `json:"namespace"`
 	Name            string `json:"name"`
 	CertificateName string `json:"certificate_name"` // Owner reference
+	ResourceVersion string `json:"resource_version,omitempty"`
 
 	// Status conditions
 	Approved bool `json:"approved"`
@@ -93,6 +177,7 @@ type CertManagerEvent struct {
 	CertificateName      string `json:"certificate_name"`
 
 	// Event details
+	Key       string    `json:"key"`    // Event UID and count; identifies one occurrence
 	Reason    string    `json:"reason"` // Issuing, Failed, OrderFailed, etc.
 	Message   string    `json:"message"`
 	Type      string    `json:"event_type"` // Normal, Warning
@@ -110,3 +195,75 @@ type CertManagerEventSyncPayload struct {
 	ClusterName string             `json:"cluster_name"`
 	Events      []CertManagerEvent `json:"events"`
 }
+
+// ============================================================================
+// ACME Order and Challenge Types
+// ============================================================================
+
+// ACME problem categories derived from Order and Challenge errors
+const (
+	ACMEProblemRateLimited    = "rate_limited"    // CA rate limit
+	ACMEProblemDNSPropagation = "dns_propagation" // DNS-01 record not visible to the self check yet
+	ACMEProblemDNS            = "dns"             // CA failed to look up the domain
+	ACMEProblemConnection     = "connection"      // CA couldn't reach the HTTP-01 solver
+	ACMEProblemUnauthorized   = "unauthorized"    // Wrong or missing challenge response
+	ACMEProblemCAA            = "caa"             // CAA records forbid the CA
+	ACMEProblemRejected       = "rejected"        // CA refuses to issue for the identifier
+	ACMEProblemOther          = "other"
+)
+
+// ACMEOrderStatus holds the state of an ACME Order
+type ACMEOrderStatus struct {
+	// Identity
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	ResourceVersion    string `json:"resource_version,omitempty"`
+	CertificateRequest string `json:"certificate_request,omitempty"` // Owner reference
+	CertificateName    string `json:"certificate_name,omitempty"`    // Owner of the CertificateRequest
+
+	// Spec
+	IssuerName string   `json:"issuer_name"`
+	IssuerKind string   `json:"issuer_kind"`
+	DNSNames   []string `json:"dns_names,omitempty"`
+
+	// Status
+	URL     string `json:"url,omitempty"`
+	State   string `json:"state,omitempty"`   // pending, ready, valid, invalid, errored, expired
+	Reason  string `json:"reason,omitempty"`  // ACME error
+	Problem string `json:"problem,omitempty"` // rate_limited, dns, connection, ...
+	Failed  bool   `json:"failed"`
+	Deleted bool   `json:"deleted,omitempty"` // Last state of a removed Order
+
+	// Timing
+	CreatedAt   time.Time  `json:"created_at"`
+	FailureTime *time.Time `json:"failure_time,omitempty"`
+}
+
+// ACMEChallengeStatus holds the state of an ACME Challenge
+type ACMEChallengeStatus struct {
+	// Identity
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	ResourceVersion    string `json:"resource_version,omitempty"`
+	OrderName          string `json:"order_name,omitempty"` // Owner reference
+	CertificateRequest string `json:"certificate_request,omitempty"`
+	CertificateName    string `json:"certificate_name,omitempty"`
+
+	// Spec
+	DNSName  string `json:"dns_name"`
+	Wildcard bool   `json:"wildcard"`
+	Type     string `json:"type"`   // HTTP-01 or DNS-01
+	Solver   string `json:"solver"` // e.g. http01-ingress, dns01-route53
+
+	// Status
+	State      string `json:"state,omitempty"`   // pending, valid, invalid, errored, expired
+	Reason     string `json:"reason,omitempty"`  // ACME error or self check result
+	Problem    string `json:"problem,omitempty"` // rate_limited, dns_propagation, ...
+	Processing bool   `json:"processing"`
+	Presented  bool   `json:"presented"` // Solver record or HTTP endpoint is in place
+	Failed     bool   `json:"failed"`
+	Deleted    bool   `json:"deleted,omitempty"` // Last state of a removed Challenge
+
+	// Timing
+	CreatedAt time.Time `json:"created_at"`
+}
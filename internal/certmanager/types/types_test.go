@@ -214,3 +214,25 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCategorizeACMEProblem(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{"", ""},
+		{"429 urn:ietf:params:acme:error:rateLimited: too many certificates already issued", ACMEProblemRateLimited},
+		{"Waiting for DNS-01 challenge propagation: DNS record for \"example.com\" not yet propagated", ACMEProblemDNSPropagation},
+		{"Waiting for HTTP-01 challenge propagation: failed to perform self check GET request", ACMEProblemConnection},
+		{"400 urn:ietf:params:acme:error:dns: DNS problem: NXDOMAIN looking up A for example.com", ACMEProblemDNS},
+		{"403 urn:ietf:params:acme:error:unauthorized: Incorrect TXT record found", ACMEProblemUnauthorized},
+		{"400 urn:ietf:params:acme:error:connection: Timeout during connect (likely firewall problem)", ACMEProblemConnection},
+		{"403 urn:ietf:params:acme:error:caa: CAA record for example.com prevents issuance", ACMEProblemCAA},
+		{"Failed to finalize order", ACMEProblemOther},
+	}
+	for _, tt := range tests {
+		if got := CategorizeACMEProblem(tt.reason); got != tt.want {
+			t.Errorf("CategorizeACMEProblem(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
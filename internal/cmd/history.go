@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/certwatch-app/cw-agent/internal/cmd/historycmd"
+	"github.com/certwatch-app/cw-agent/internal/cmd/scancmd"
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/history"
+	"github.com/certwatch-app/cw-agent/internal/ui"
+)
+
+var (
+	historyOutput      string
+	historySince       time.Duration
+	historyRegressions bool
+	historyFile        string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [host[:port]]",
+	Short: "Show certificate changes recorded by the agent",
+	Long: `Show the scan history the agent records locally (agent.history).
+
+Without arguments, one line per target summarizes its scans, the certificates
+it served and the regressions seen. With a target, every change is listed:
+renewals, replacements, issuer changes, failing scans, chain and TLS issues.
+Regressions are changes for the worse, such as a scan that starts failing or
+a replacement certificate that expires sooner than the one it replaced.
+
+The history works without the CertWatch API and can be read while the agent
+is running.
+
+Example:
+  cw-agent history -c certwatch.yaml
+  cw-agent history -c certwatch.yaml example.com --since 720h
+  cw-agent history -c certwatch.yaml --regressions -o json`,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         runHistory,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", historycmd.FormatTable,
+		"Output format: table, json")
+	historyCmd.Flags().DurationVar(&historySince, "since", 7*24*time.Hour,
+		"How far back to look")
+	historyCmd.Flags().BoolVar(&historyRegressions, "regressions", false,
+		"Only list regressions")
+	historyCmd.Flags().StringVar(&historyFile, "file", "",
+		"History database (default: agent.history.path, or history.db in the state directory)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(historycmd.Formats, historyOutput) {
+		return fmt.Errorf("unknown output format %q (valid: %v)", historyOutput, historycmd.Formats)
+	}
+
+	// Defaults apply even without a config file
+	cfg, err := config.Load(viper.GetViper())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	since := time.Now().Add(-historySince)
+	filter := history.Filter{Since: since}
+	if len(args) == 1 {
+		target, err := scancmd.ParseTarget(args[0])
+		if err != nil {
+			return err
+		}
+		filter.Target = target.Hostname
+		// Without an explicit port, every port of the host is shown
+		if _, _, err := net.SplitHostPort(args[0]); err == nil {
+			filter.Port = target.Port
+		}
+	}
+
+	path := historyFile
+	if path == "" {
+		path = history.Path(cfg.Agent.History.Path, newStateManager().Dir())
+	}
+	store, err := history.Open(path, true)
+	if errors.Is(err, history.ErrNotFound) {
+		return fmt.Errorf("%w: run `cw-agent start` with agent.history enabled first", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	observations, err := store.Observations(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if historyOutput == historycmd.FormatTable {
+		fmt.Println()
+		fmt.Println(ui.RenderCommandHeader("History"))
+		fmt.Println()
+	}
+
+	report := historycmd.NewReport(observations, since, filter.Target != "", historyRegressions)
+	if err := report.Write(os.Stdout, historyOutput); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package historycmd summarizes the local scan history and renders it as a
+// table or JSON.
+package historycmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+
+	"github.com/certwatch-app/cw-agent/internal/history"
+	"github.com/certwatch-app/cw-agent/internal/ui"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatJSON}
+
+// Summary is the recorded history of one target
+// Fields are ordered for optimal memory alignment
+type Summary struct {
+	FirstSeen         time.Time `json:"first_seen"`
+	LastSeen          time.Time `json:"last_seen"`
+	NotAfter          time.Time `json:"not_after,omitzero"`
+	Target            string    `json:"target"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	Port              int       `json:"port"`
+	Scans             int       `json:"scans"`
+	Certificates      int       `json:"certificates"` // Distinct certificates served
+	Regressions       int       `json:"regressions"`
+	Success           bool      `json:"success"` // Outcome of the latest scan
+}
+
+// Report is the history of the selected targets. Timeline reports list every
+// event; other reports list one summary per target.
+// Fields are ordered for optimal memory alignment
+type Report struct {
+	Since     time.Time
+	Summaries []Summary
+	Events    []history.Event
+	Timeline  bool
+}
+
+// NewReport builds a report from observations ordered as history.Store returns
+// them. With regressions set, only regression events are listed.
+func NewReport(observations []history.Observation, since time.Time, timeline, regressions bool) *Report {
+	r := &Report{Since: since, Timeline: timeline}
+
+	var seen map[string]bool
+	for i := range observations {
+		o := &observations[i]
+		n := len(r.Summaries)
+		if n == 0 || r.Summaries[n-1].Target != o.Target || r.Summaries[n-1].Port != o.Port {
+			r.Summaries = append(r.Summaries, Summary{Target: o.Target, Port: o.Port, FirstSeen: o.FirstSeen})
+			seen = map[string]bool{}
+			n++
+		}
+
+		s := &r.Summaries[n-1]
+		s.LastSeen = o.LastSeen
+		s.Scans += o.Scans
+		s.Success = o.Success
+		s.Error = o.Error
+		if o.Success && o.FingerprintSHA256 != "" {
+			s.FingerprintSHA256 = o.FingerprintSHA256
+			s.NotAfter = o.NotAfter
+			if !seen[o.FingerprintSHA256] {
+				seen[o.FingerprintSHA256] = true
+				s.Certificates++
+			}
+		}
+	}
+
+	for _, e := range history.Events(observations) {
+		if e.Regression {
+			for i := range r.Summaries {
+				if r.Summaries[i].Target == e.Target && r.Summaries[i].Port == e.Port {
+					r.Summaries[i].Regressions++
+				}
+			}
+		}
+		if !regressions || e.Regression {
+			r.Events = append(r.Events, e)
+		}
+	}
+	return r
+}
+
+// Write renders the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return r.WriteTable(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown output format %q (valid: %v)", format, Formats)
+	}
+}
+
+// WriteJSON renders the report as a JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	doc := struct {
+		Since   time.Time       `json:"since"`
+		Targets []Summary       `json:"targets"`
+		Events  []history.Event `json:"events"`
+	}{Since: r.Since.UTC(), Targets: r.Summaries, Events: r.Events}
+	if doc.Targets == nil {
+		doc.Targets = []Summary{}
+	}
+	if doc.Events == nil {
+		doc.Events = []history.Event{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteTable renders the summaries, or the events of a timeline report
+func (r *Report) WriteTable(w io.Writer) error {
+	var b strings.Builder
+	switch {
+	case len(r.Summaries) == 0:
+		b.WriteString(ui.RenderInfo("No scans recorded since "+r.Since.Format("2006-01-02 15:04")) + "\n")
+	case r.Timeline:
+		b.WriteString(r.timelineTable() + "\n")
+	default:
+		b.WriteString(r.summaryTable() + "\n")
+	}
+
+	if regressions := r.regressionCount(); regressions > 0 {
+		b.WriteString(ui.RenderWarning(fmt.Sprintf("%d regressions since %s", regressions, r.Since.Format("2006-01-02"))) + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *Report) regressionCount() int {
+	n := 0
+	for i := range r.Summaries {
+		n += r.Summaries[i].Regressions
+	}
+	return n
+}
+
+func (r *Report) summaryTable() string {
+	rows := make([][]string, 0, len(r.Summaries))
+	for i := range r.Summaries {
+		s := &r.Summaries[i]
+		status, expires := "OK", "-"
+		if !s.Success {
+			status = "FAIL"
+		}
+		if !s.NotAfter.IsZero() {
+			expires = s.NotAfter.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			s.Target + ":" + strconv.Itoa(s.Port), status, expires,
+			s.FirstSeen.Local().Format("2006-01-02 15:04"), s.LastSeen.Local().Format("2006-01-02 15:04"),
+			strconv.Itoa(s.Scans), strconv.Itoa(s.Certificates), strconv.Itoa(s.Regressions),
+		})
+	}
+
+	return newTable(rows, "TARGET", "STATUS", "EXPIRES", "FIRST SEEN", "LAST SEEN", "SCANS", "CERTIFICATES", "REGRESSIONS").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			switch {
+			case row == table.HeaderRow:
+				return style.Bold(true).Foreground(ui.ColorPrimary)
+			case col == 1 && rows[row][1] == "OK":
+				return style.Foreground(ui.ColorSuccess)
+			case col == 1:
+				return style.Foreground(ui.ColorError).Bold(true)
+			case col == 7 && rows[row][7] != "0":
+				return style.Foreground(ui.ColorWarning)
+			}
+			return style
+		}).Render()
+}
+
+func (r *Report) timelineTable() string {
+	rows := make([][]string, 0, len(r.Events))
+	for _, e := range r.Events {
+		rows = append(rows, []string{
+			e.Time.Local().Format("2006-01-02 15:04"), e.Target + ":" + strconv.Itoa(e.Port), e.Kind, e.Detail,
+		})
+	}
+
+	return newTable(rows, "TIME", "TARGET", "EVENT", "DETAIL").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			switch {
+			case row == table.HeaderRow:
+				return style.Bold(true).Foreground(ui.ColorPrimary)
+			case col == 2 && r.Events[row].Regression:
+				return style.Foreground(ui.ColorError).Bold(true)
+			}
+			return style
+		}).Render()
+}
+
+func newTable(rows [][]string, headers ...string) *table.Table {
+	return table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(ui.ColorMuted)).
+		Headers(headers...).
+		Rows(rows...)
+}
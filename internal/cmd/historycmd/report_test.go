@@ -0,0 +1,90 @@
+package historycmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/history"
+)
+
+func testObservations() []history.Observation {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	obs := func(target string, h, scans int, fingerprint string, days int) history.Observation {
+		o := history.Observation{
+			FirstSeen: t0.Add(time.Duration(h) * time.Hour), LastSeen: t0.Add(time.Duration(h+scans-1) * time.Hour),
+			Target: target, Port: 443, Scans: scans, Success: fingerprint != "", ChainValid: true, Issues: []string{},
+			FingerprintSHA256: fingerprint, SerialNumber: fingerprint, Issuer: "CN=Test CA",
+			NotAfter: t0.Add(time.Duration(days) * 24 * time.Hour),
+		}
+		if fingerprint == "" {
+			o.Error, o.NotAfter = "connection refused", time.Time{}
+		}
+		return o
+	}
+	return []history.Observation{
+		obs("a.example.com", 0, 3, "aa", 30),
+		obs("a.example.com", 3, 2, "a2", 90),
+		obs("b.example.com", 0, 2, "bb", 60),
+		obs("b.example.com", 2, 1, "", 0),
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	r := NewReport(testObservations(), time.Time{}, false, false)
+
+	if len(r.Summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(r.Summaries))
+	}
+	a, b := r.Summaries[0], r.Summaries[1]
+	if a.Scans != 5 || a.Certificates != 2 || a.FingerprintSHA256 != "a2" || a.Regressions != 0 || !a.Success {
+		t.Errorf("a.example.com summary = %+v", a)
+	}
+	if b.Scans != 3 || b.Certificates != 1 || b.Success || b.Regressions != 1 || b.FingerprintSHA256 != "bb" {
+		t.Errorf("b.example.com summary = %+v", b)
+	}
+	// first_seen x2, renewed, scan_failed
+	if len(r.Events) != 4 {
+		t.Errorf("got %d events, want 4", len(r.Events))
+	}
+
+	r = NewReport(testObservations(), time.Time{}, true, true)
+	if len(r.Events) != 1 || r.Events[0].Kind != history.EventScanFailed {
+		t.Errorf("regression events = %+v, want only scan_failed", r.Events)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := NewReport(testObservations(), time.Time{}, false, false)
+
+	var out bytes.Buffer
+	if err := r.Write(&out, FormatTable); err != nil {
+		t.Fatalf("Write(table) error = %v", err)
+	}
+	for _, want := range []string{"a.example.com:443", "REGRESSIONS", "1 regressions"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := r.Write(&out, FormatJSON); err != nil {
+		t.Fatalf("Write(json) error = %v", err)
+	}
+	var doc struct {
+		Targets []Summary       `json:"targets"`
+		Events  []history.Event `json:"events"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Targets) != 2 || len(doc.Events) != 4 {
+		t.Errorf("JSON has %d targets and %d events, want 2 and 4", len(doc.Targets), len(doc.Events))
+	}
+
+	if err := r.Write(&out, "sarif"); err == nil {
+		t.Error("Write(sarif) succeeded, want an error")
+	}
+}
@@ -33,7 +33,9 @@ Examples:
   CW_API_KEY=cw_xxx CW_AGENT_NAME=prod CW_CERTIFICATES=api.example.com cw-agent init --non-interactive
 
 Environment variables for non-interactive mode:
-  CW_API_KEY        (required) Your CertWatch API key
+  CW_API_KEY        (required) Your CertWatch API key, unless CW_API_KEY_FILE is set
+  CW_API_KEY_FILE   (optional) Reference this file as api.key_file instead of
+                    storing the key in the config (written if CW_API_KEY is set)
   CW_API_ENDPOINT   (optional) API endpoint (default: https://api.certwatch.app)
   CW_AGENT_NAME     (optional) Agent name (default: default-agent)
   CW_SYNC_INTERVAL  (optional) Sync interval (default: 5m)
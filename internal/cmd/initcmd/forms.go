@@ -44,6 +44,12 @@ func NewAPIForm(state *WizardState) *huh.Form {
 				Value(&state.APIKey).
 				EchoMode(huh.EchoModePassword).
 				Validate(ValidateAPIKey),
+
+			huh.NewInput().
+				Title("API Key File (optional)").
+				Description("Save the key to this file instead of the config file").
+				Placeholder("/etc/certwatch/api-key").
+				Value(&state.APIKeyFile),
 		),
 	).WithTheme(ui.CreateTheme())
 }
@@ -18,6 +18,7 @@ type WizardState struct {
 
 	// API configuration
 	APIKey      string
+	APIKeyFile  string // When set, the config references this file as api.key_file
 	APIEndpoint string
 	APITimeout  string
 
@@ -126,7 +127,6 @@ func (s *WizardState) ToConfig() (*config.Config, error) {
 	cfg := &config.Config{
 		API: config.APIConfig{
 			Endpoint: s.APIEndpoint,
-			Key:      s.APIKey,
 			Timeout:  timeout,
 		},
 		Agent: config.AgentConfig{
@@ -141,6 +141,13 @@ func (s *WizardState) ToConfig() (*config.Config, error) {
 		Certificates: certs,
 	}
 
+	// Keep the key out of the config file when a key file is used
+	if s.APIKeyFile != "" {
+		cfg.API.KeyFile = s.APIKeyFile
+	} else {
+		cfg.API.Key = s.APIKey
+	}
+
 	return cfg, nil
 }
 
@@ -1,6 +1,7 @@
 package initcmd
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -196,6 +197,28 @@ func TestWizardState_SaveAndResetCert(t *testing.T) {
 	}
 }
 
+func TestWizardState_ToConfig_APIKeyFile(t *testing.T) {
+	state := NewWizardState()
+	state.APIKey = "cw_test_key"
+	state.APIKeyFile = "/etc/certwatch/api-key"
+	state.AgentName = "test-agent"
+	state.Certificates = []CertificateInput{{Hostname: "example.com", PortStr: "443"}}
+
+	cfg, err := state.ToConfig()
+	if err != nil {
+		t.Fatalf("ToConfig() error = %v", err)
+	}
+	if cfg.API.Key != "" || cfg.API.KeyFile != "/etc/certwatch/api-key" {
+		t.Errorf("API key = %q, key file = %q, want only the key file", cfg.API.Key, cfg.API.KeyFile)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if yaml := generateYAML(cfg); strings.Contains(yaml, "cw_test_key") || !strings.Contains(yaml, "key_file:") {
+		t.Errorf("generated config should reference the key file only:\n%s", yaml)
+	}
+}
+
 func TestWizardState_ToConfig_InvalidDuration(t *testing.T) {
 	state := &WizardState{
 		APIKey:       "cw_test_key",
@@ -95,6 +95,11 @@ func (w *Wizard) Run() error {
 
 	// Step 7: Write config file
 	fmt.Println()
+	if w.state.APIKeyFile != "" {
+		if err := WriteKeyFile(w.state.APIKeyFile, w.state.APIKey); err != nil {
+			return w.handleError(err)
+		}
+	}
 	if err := WriteConfig(cfg, w.state.ConfigPath); err != nil {
 		return w.handleError(err)
 	}
@@ -236,10 +241,11 @@ func RunNonInteractive(outputPath string) error {
 	state := NewWizardState()
 	state.ConfigPath = outputPath
 
-	// Read from environment variables
+	// Read from environment variables; an existing key file is referenced as is
 	state.APIKey = os.Getenv("CW_API_KEY")
-	if state.APIKey == "" {
-		return fmt.Errorf("CW_API_KEY environment variable is required in non-interactive mode")
+	state.APIKeyFile = os.Getenv("CW_API_KEY_FILE")
+	if state.APIKey == "" && state.APIKeyFile == "" {
+		return fmt.Errorf("CW_API_KEY or CW_API_KEY_FILE environment variable is required in non-interactive mode")
 	}
 
 	if endpoint := os.Getenv("CW_API_ENDPOINT"); endpoint != "" {
@@ -302,7 +308,12 @@ func RunNonInteractive(outputPath string) error {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
-	// Write config
+	// Write config, and the key file if both the key and its file are given
+	if state.APIKey != "" && state.APIKeyFile != "" {
+		if err := WriteKeyFile(state.APIKeyFile, state.APIKey); err != nil {
+			return err
+		}
+	}
 	if err := WriteConfig(cfg, state.ConfigPath); err != nil {
 		return err
 	}
@@ -40,6 +40,25 @@ func WriteConfig(cfg *config.Config, path string) error {
 	return nil
 }
 
+// WriteKeyFile writes the API key to path, readable only by the owner
+func WriteKeyFile(path, key string) error {
+	dir := filepath.Dir(path)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cannot create directory '%s': %w", dir, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(key+"\n"), 0o600); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("permission denied: cannot write to '%s'", path)
+		}
+		return fmt.Errorf("cannot write file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
 // generateYAML creates a YAML string with helpful comments.
 func generateYAML(cfg *config.Config) string {
 	var buf bytes.Buffer
@@ -54,8 +73,13 @@ func generateYAML(cfg *config.Config) string {
 	buf.WriteString("# API connection settings\n")
 	buf.WriteString("api:\n")
 	buf.WriteString(fmt.Sprintf("  endpoint: %q\n", cfg.API.Endpoint))
-	buf.WriteString("  # Your API key (keep this secret!)\n")
-	buf.WriteString(fmt.Sprintf("  key: %q\n", cfg.API.Key))
+	if cfg.API.KeyFile != "" {
+		buf.WriteString("  # File containing your API key (read again when it changes)\n")
+		buf.WriteString(fmt.Sprintf("  key_file: %q\n", cfg.API.KeyFile))
+	} else {
+		buf.WriteString("  # Your API key (keep this secret!)\n")
+		buf.WriteString(fmt.Sprintf("  key: %q\n", cfg.API.Key))
+	}
 	buf.WriteString(fmt.Sprintf("  timeout: %s\n", cfg.API.Timeout.String()))
 	buf.WriteString("\n")
 
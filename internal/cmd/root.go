@@ -66,6 +66,10 @@ func initConfig() {
 	// Explicitly bind API key from environment variable
 	//nolint:errcheck // BindEnv always succeeds when args are valid
 	viper.BindEnv("api.key", "CW_API_KEY")
+	//nolint:errcheck // BindEnv always succeeds when args are valid
+	viper.BindEnv("api.key_file", "CW_API_KEY_FILE")
+	//nolint:errcheck // BindEnv always succeeds when args are valid
+	viper.BindEnv("notify.email.password", "CW_NOTIFY_EMAIL_PASSWORD")
 
 	// If a config file is found, read it in
 	if err := viper.ReadInConfig(); err == nil {
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/cmd/scancmd"
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/discovery"
+	"github.com/certwatch-app/cw-agent/internal/proxy"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/ui"
+)
+
+var (
+	scanOutput       string
+	scanExpiryDays   int
+	scanIgnoreIssues []string
+	scanFailOnAudit  bool
+	scanTimeout      time.Duration
+	scanConcurrency  int
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [host[:port]...]",
+	Short: "Scan certificates once and report the results",
+	Long: `Scan certificates once without starting the agent or syncing to CertWatch.
+
+Targets are taken from the arguments, or from the config file when no
+arguments are given. The command exits with status 1 when any target can't
+be scanned, expires within --expiry-days or has chain issues, so it can be
+used as a CI gate. TLS audit issues are reported but only fail the scan with
+--fail-on-tls-audit.
+
+Example:
+  cw-agent scan example.com api.example.com:8443
+  cw-agent scan -c certwatch.yaml --expiry-days 30
+  cw-agent scan -c certwatch.yaml -o sarif > certwatch.sarif
+  cw-agent scan example.com -o junit --ignore-issue wrong_order > report.xml`,
+	RunE:         runScan,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", scancmd.FormatTable,
+		"Output format: table, json, sarif, junit")
+	scanCmd.Flags().IntVar(&scanExpiryDays, "expiry-days", 14,
+		"Fail when a certificate expires within this many days")
+	scanCmd.Flags().StringSliceVar(&scanIgnoreIssues, "ignore-issue", nil,
+		"Finding types that don't fail the scan (e.g. wrong_order,extra_certificates)")
+	scanCmd.Flags().BoolVar(&scanFailOnAudit, "fail-on-tls-audit", false,
+		"Fail on TLS audit issues such as deprecated protocols (default: report them as notes)")
+	scanCmd.Flags().DurationVar(&scanTimeout, "timeout", 0,
+		"Timeout per target (default: api.timeout from the config)")
+	scanCmd.Flags().IntVar(&scanConcurrency, "concurrency", 0,
+		"Maximum concurrent scans (default: agent.concurrency from the config)")
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(scancmd.Formats, scanOutput) {
+		return fmt.Errorf("unknown output format %q (valid: %v)", scanOutput, scancmd.Formats)
+	}
+
+	// Defaults apply even without a config file
+	cfg, err := config.Load(viper.GetViper())
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	targets, source, err := scanTargets(ctx, cfg, args)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets: pass host[:port] arguments or configure certificates in the config file")
+	}
+
+	timeout := cfg.API.Timeout
+	if scanTimeout > 0 {
+		timeout = scanTimeout
+	}
+	concurrency := cfg.Agent.Concurrency
+	if scanConcurrency > 0 {
+		concurrency = scanConcurrency
+	}
+
+	logger := zap.NewNop()
+	s := scanner.New(timeout, concurrency, logger)
+	if cfg.Agent.Revocation.Enabled {
+		s.SetRevocationChecker(scanner.NewRevocationChecker(cfg.Agent.Revocation, timeout, logger))
+	}
+	if cfg.Agent.TLSAudit.Enabled {
+		s.SetTLSAuditor(scanner.NewTLSAuditor(cfg.Agent.TLSAudit))
+	}
+	if cfg.Agent.ScanProxy.URL != "" {
+		d, err := proxy.New(cfg.Agent.ScanProxy.URL, cfg.Agent.ScanProxy.NoProxy, timeout)
+		if err != nil {
+			return fmt.Errorf("agent.scan_proxy: %w", err)
+		}
+		s.SetDialer(d)
+	}
+
+	if scanOutput == scancmd.FormatTable {
+		fmt.Println()
+		fmt.Println(ui.RenderCommandHeader("Scan"))
+		fmt.Println()
+		fmt.Println(ui.RenderInfo(fmt.Sprintf("Scanning %d targets...", len(targets))))
+		fmt.Println()
+	}
+
+	opts := scancmd.Options{ExpiryDays: scanExpiryDays, IgnoreIssues: scanIgnoreIssues, FailOnTLSAudit: scanFailOnAudit}
+	started := time.Now()
+	results := s.ScanAll(ctx, targets)
+	report := &scancmd.Report{
+		Started:  started,
+		Duration: time.Since(started),
+		Source:   source,
+		Results:  scancmd.Evaluate(results, opts),
+		Options:  opts,
+	}
+
+	if err := report.Write(os.Stdout, scanOutput); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if failed := report.Failures(); failed > 0 {
+		return fmt.Errorf("%d of %d targets failed", failed, len(results))
+	}
+	return nil
+}
+
+// scanTargets returns the targets from the arguments, or from the config file
+// (including discovery) when there are none, and the source used in reports
+func scanTargets(ctx context.Context, cfg *config.Config, args []string) ([]config.CertificateConfig, string, error) {
+	if len(args) > 0 {
+		targets := make([]config.CertificateConfig, 0, len(args))
+		for _, arg := range args {
+			target, err := scancmd.ParseTarget(arg)
+			if err != nil {
+				return nil, "", err
+			}
+			targets = append(targets, target)
+		}
+		return targets, "command-line", nil
+	}
+
+	source := viper.ConfigFileUsed()
+	if source == "" {
+		return nil, "", fmt.Errorf("no targets: pass host[:port] arguments or a config file with -c")
+	}
+
+	if !cfg.Discovery.Enabled() {
+		return cfg.Certificates, source, nil
+	}
+	d, err := discovery.FromConfig(&cfg.Discovery, zap.NewNop())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to setup discovery: %w", err)
+	}
+	targets, _ := d.Discover(ctx, cfg.Certificates)
+	return targets, source, nil
+}
@@ -0,0 +1,92 @@
+package scancmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// JUnit XML as understood by common CI systems (GitLab, Jenkins, Azure DevOps)
+// Fields are ordered for optimal memory alignment
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+}
+
+// Fields are ordered for optimal memory alignment
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Time      string          `xml:"time,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+}
+
+type junitTestCase struct {
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit renders the report as JUnit XML with one test case per target
+func (r *Report) WriteJUnit(w io.Writer) error {
+	seconds := fmt.Sprintf("%.3f", r.Duration.Seconds())
+	suite := junitTestSuite{
+		Name:      "certwatch",
+		Timestamp: r.Started.UTC().Format(time.RFC3339),
+		Time:      seconds,
+		Tests:     len(r.Results),
+		Failures:  r.Failures(),
+	}
+
+	for i := range r.Results {
+		res := &r.Results[i]
+		tc := junitTestCase{Name: res.GetHostPort(), Classname: "certwatch.scan"}
+		if res.Failed() {
+			rules := make([]string, 0, len(res.Findings))
+			lines := make([]string, 0, len(res.Findings))
+			for _, f := range res.Findings {
+				rules = append(rules, f.Rule)
+				lines = append(lines, fmt.Sprintf("[%s] %s", f.Level, f.Message))
+			}
+			tc.Failure = &junitFailure{
+				Message: res.firstFailure().Message,
+				Type:    strings.Join(rules, ","),
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitTestSuites{
+		Name:     "cw-agent scan",
+		Time:     seconds,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
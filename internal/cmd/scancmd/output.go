@@ -0,0 +1,166 @@
+package scancmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+
+	"github.com/certwatch-app/cw-agent/internal/ui"
+)
+
+// jsonReport is the JSON output document
+// Fields are ordered for optimal memory alignment
+type jsonReport struct {
+	ScannedAt  time.Time    `json:"scanned_at"`
+	Results    []jsonResult `json:"results"`
+	Total      int          `json:"total"`
+	Failed     int          `json:"failed"`
+	ExpiryDays int          `json:"expiry_days"`
+}
+
+// jsonResult is a single target in the JSON output
+// Fields are ordered for optimal memory alignment
+type jsonResult struct {
+	Certificate *jsonCertificate `json:"certificate,omitempty"`
+	Hostname    string           `json:"hostname"`
+	Address     string           `json:"address,omitempty"`
+	ServerName  string           `json:"server_name,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	Findings    []Finding        `json:"findings"`
+	Port        int              `json:"port"`
+	ChainValid  bool             `json:"chain_valid"`
+	Passed      bool             `json:"passed"`
+}
+
+// jsonCertificate is the leaf certificate in the JSON output
+// Fields are ordered for optimal memory alignment
+type jsonCertificate struct {
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	KeyAlgorithm      string    `json:"key_algorithm"`
+	SANs              []string  `json:"sans"`
+	KeySize           int       `json:"key_size"`
+	DaysUntilExpiry   int       `json:"days_until_expiry"`
+}
+
+// WriteJSON renders the report as a JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	doc := jsonReport{
+		ScannedAt:  r.Started.UTC(),
+		Results:    make([]jsonResult, 0, len(r.Results)),
+		Total:      len(r.Results),
+		Failed:     r.Failures(),
+		ExpiryDays: r.Options.ExpiryDays,
+	}
+	for i := range r.Results {
+		res := &r.Results[i]
+		out := jsonResult{
+			Hostname:   res.Hostname,
+			Address:    res.Address,
+			ServerName: res.ServerName,
+			Port:       res.Port,
+			Error:      res.Error,
+			Findings:   res.Findings,
+			ChainValid: res.Chain != nil && res.Chain.Valid,
+			Passed:     !res.Failed(),
+		}
+		if out.Findings == nil {
+			out.Findings = []Finding{}
+		}
+		if c := res.Certificate; c != nil {
+			out.Certificate = &jsonCertificate{
+				NotBefore:         c.NotBefore,
+				NotAfter:          c.NotAfter,
+				Subject:           c.Subject,
+				Issuer:            c.Issuer,
+				SerialNumber:      c.SerialNumber,
+				FingerprintSHA256: c.FingerprintSHA256,
+				KeyAlgorithm:      c.KeyAlgorithm,
+				SANs:              c.SANList,
+				KeySize:           c.KeySize,
+				DaysUntilExpiry:   c.DaysUntilExpiry,
+			}
+		}
+		doc.Results = append(doc.Results, out)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteTable renders the report as a styled table followed by the findings
+func (r *Report) WriteTable(w io.Writer) error {
+	rows := make([][]string, 0, len(r.Results))
+	for i := range r.Results {
+		res := &r.Results[i]
+		status, expires, days, issuer := "FAIL", "-", "-", "-"
+		if !res.Failed() {
+			status = "OK"
+		}
+		if c := res.Certificate; c != nil {
+			expires = c.NotAfter.Format("2006-01-02")
+			days = strconv.Itoa(c.DaysUntilExpiry)
+			issuer = c.IssuerOrg
+			if issuer == "" {
+				issuer = c.Issuer
+			}
+		}
+		rows = append(rows, []string{res.GetHostPort(), status, expires, days, issuer, strconv.Itoa(len(res.Findings))})
+	}
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(ui.ColorMuted)).
+		Headers("TARGET", "STATUS", "EXPIRES", "DAYS", "ISSUER", "FINDINGS").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			style := lipgloss.NewStyle().Padding(0, 1)
+			switch {
+			case row == table.HeaderRow:
+				return style.Bold(true).Foreground(ui.ColorPrimary)
+			case col == 1 && rows[row][1] == "OK":
+				return style.Foreground(ui.ColorSuccess)
+			case col == 1:
+				return style.Foreground(ui.ColorError).Bold(true)
+			}
+			return style
+		})
+
+	var b strings.Builder
+	b.WriteString(t.Render() + "\n")
+
+	for i := range r.Results {
+		for _, f := range r.Results[i].Findings {
+			line := fmt.Sprintf("[%s] %s", f.Rule, f.Message)
+			switch f.Level {
+			case LevelError:
+				b.WriteString(ui.RenderError(line) + "\n")
+			case LevelNote:
+				b.WriteString(ui.RenderInfo(line) + "\n")
+			default:
+				b.WriteString(ui.RenderWarning(line) + "\n")
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	if failed := r.Failures(); failed > 0 {
+		b.WriteString(ui.RenderError(fmt.Sprintf("%d of %d targets failed", failed, len(r.Results))) + "\n")
+	} else {
+		b.WriteString(ui.RenderSuccess(fmt.Sprintf("All %d targets passed", len(r.Results))) + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
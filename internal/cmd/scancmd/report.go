@@ -0,0 +1,181 @@
+// Package scancmd evaluates one-shot scan results against CI gate rules and
+// renders them as a table, JSON, SARIF or JUnit XML.
+package scancmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatTable, FormatJSON, FormatSARIF, FormatJUnit}
+
+// Rules reported in addition to the scanner's chain issue types
+const (
+	RuleScanError           = "scan_error"
+	RuleCertificateExpired  = "certificate_expired"
+	RuleCertificateExpiring = "certificate_expiring"
+)
+
+// Finding levels, as used by SARIF
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note" // Reported without failing the gate
+)
+
+// errorIssues are the chain issue types reported at error level; other chain
+// issues are warnings
+var errorIssues = []string{
+	"expired",
+	"not_yet_valid",
+	"hostname_mismatch",
+	scanner.IssueRevoked,
+	scanner.IssueIncompleteChain,
+	scanner.IssueUntrustedRoot,
+}
+
+// Options control which findings fail the gate
+// Fields are ordered for optimal memory alignment
+type Options struct {
+	IgnoreIssues   []string // Rule or chain issue types that aren't reported
+	ExpiryDays     int      // Certificates expiring within this many days fail
+	FailOnTLSAudit bool     // TLS audit issues fail the gate instead of being notes
+}
+
+// Finding is a single reason a target fails the gate
+type Finding struct {
+	Rule    string `json:"rule"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Result is a scan result with its findings
+type Result struct {
+	scanner.ScanResult
+	Findings []Finding
+}
+
+// Failed reports whether the result has any findings other than notes
+func (r *Result) Failed() bool {
+	return r.firstFailure() != nil
+}
+
+// firstFailure returns the first finding that fails the gate, nil for none
+func (r *Result) firstFailure() *Finding {
+	for i := range r.Findings {
+		if r.Findings[i].Level != LevelNote {
+			return &r.Findings[i]
+		}
+	}
+	return nil
+}
+
+// Report is the evaluated outcome of a scan
+// Fields are ordered for optimal memory alignment
+type Report struct {
+	Started  time.Time
+	Source   string // Config file or "command-line"; used as the SARIF artifact
+	Results  []Result
+	Options  Options
+	Duration time.Duration
+}
+
+// Evaluate applies the gate rules to the scan results
+func Evaluate(results []scanner.ScanResult, opts Options) []Result {
+	out := make([]Result, len(results))
+	for i := range results {
+		out[i] = Result{ScanResult: results[i], Findings: findings(&results[i], opts)}
+	}
+	return out
+}
+
+func findings(r *scanner.ScanResult, opts Options) []Finding {
+	var out []Finding
+	add := func(rule, level, message string) {
+		if !slices.Contains(opts.IgnoreIssues, rule) {
+			out = append(out, Finding{Rule: rule, Level: level, Message: message})
+		}
+	}
+
+	if !r.Success || r.Certificate == nil {
+		add(RuleScanError, LevelError, fmt.Sprintf("Scan of %s failed: %s", r.GetHostPort(), r.Error))
+		return out
+	}
+
+	cert := r.Certificate
+	switch {
+	case time.Now().After(cert.NotAfter):
+		add(RuleCertificateExpired, LevelError,
+			fmt.Sprintf("Certificate for %s expired on %s", r.GetHostPort(), cert.NotAfter.Format(time.RFC3339)))
+	case cert.DaysUntilExpiry <= opts.ExpiryDays:
+		add(RuleCertificateExpiring, LevelWarning,
+			fmt.Sprintf("Certificate for %s expires in %d days (threshold %d)", r.GetHostPort(), cert.DaysUntilExpiry, opts.ExpiryDays))
+	}
+
+	if r.Chain != nil {
+		for _, issue := range r.Chain.Issues {
+			// Leaf expiry is reported above
+			if issue.Type == "expired" && issue.CertificateIndex == 0 {
+				continue
+			}
+			level := LevelWarning
+			if slices.Contains(errorIssues, issue.Type) {
+				level = LevelError
+			}
+			add(issue.Type, level, fmt.Sprintf("%s: %s", r.GetHostPort(), issue.Message))
+		}
+	}
+
+	// TLS configuration issues only fail the gate when asked to
+	if r.TLS != nil {
+		level := LevelNote
+		if opts.FailOnTLSAudit {
+			level = LevelWarning
+		}
+		for _, issue := range r.TLS.Issues {
+			add(issue.Type, level, fmt.Sprintf("%s: %s", r.GetHostPort(), issue.Message))
+		}
+	}
+
+	return out
+}
+
+// Failures returns the number of results that fail the gate
+func (r *Report) Failures() int {
+	n := 0
+	for i := range r.Results {
+		if r.Results[i].Failed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Write renders the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return r.WriteTable(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatSARIF:
+		return r.WriteSARIF(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	default:
+		return fmt.Errorf("unknown output format %q (valid: %v)", format, Formats)
+	}
+}
@@ -0,0 +1,217 @@
+package scancmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+func result(hostname string, days int, issues ...scanner.ChainIssue) scanner.ScanResult {
+	return scanner.ScanResult{
+		Hostname: hostname,
+		Port:     443,
+		Success:  true,
+		Certificate: &scanner.CertificateInfo{
+			Subject:         hostname,
+			Issuer:          "Test CA",
+			NotAfter:        time.Now().Add(time.Duration(days)*24*time.Hour + time.Hour),
+			DaysUntilExpiry: days,
+		},
+		Chain: &scanner.ChainInfo{Valid: len(issues) == 0, Issues: issues},
+	}
+}
+
+func testReport() *Report {
+	expired := result("expired.example.com", -2, scanner.ChainIssue{Type: "expired", Message: "Certificate has expired"})
+	expired.Certificate.NotAfter = time.Now().Add(-48 * time.Hour)
+
+	opts := Options{ExpiryDays: 14, IgnoreIssues: []string{scanner.IssueExtraCertificates}}
+	return &Report{
+		Started:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Source:   "certwatch.yaml",
+		Options:  opts,
+		Results: Evaluate([]scanner.ScanResult{
+			result("ok.example.com", 60, scanner.ChainIssue{Type: scanner.IssueExtraCertificates, Message: "Unused certificate"}),
+			result("soon.example.com", 10),
+			expired,
+			result("untrusted.example.com", 60, scanner.ChainIssue{Type: scanner.IssueUntrustedRoot, Message: "Untrusted root", CertificateIndex: 1}),
+			{Hostname: "down.example.com", Port: 8443, Error: "connection refused"},
+		}, opts),
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	r := testReport()
+
+	want := map[string][]string{
+		"ok.example.com":        nil,
+		"soon.example.com":      {RuleCertificateExpiring},
+		"expired.example.com":   {RuleCertificateExpired},
+		"untrusted.example.com": {scanner.IssueUntrustedRoot},
+		"down.example.com":      {RuleScanError},
+	}
+	for i := range r.Results {
+		res := &r.Results[i]
+		var rules []string
+		for _, f := range res.Findings {
+			rules = append(rules, f.Rule)
+		}
+		if strings.Join(rules, ",") != strings.Join(want[res.Hostname], ",") {
+			t.Errorf("%s findings = %v, want %v", res.Hostname, rules, want[res.Hostname])
+		}
+	}
+
+	if got := r.Failures(); got != 4 {
+		t.Errorf("Failures() = %d, want 4", got)
+	}
+}
+
+func TestEvaluate_TLSAuditIssues(t *testing.T) {
+	legacy := result("legacy.example.com", 60)
+	legacy.TLS = &scanner.TLSInfo{Issues: []scanner.ChainIssue{
+		{Type: scanner.IssueDeprecatedProtocol, Message: "Server accepts deprecated protocol TLS 1.0"},
+	}}
+
+	// Reported as a note that doesn't fail the gate by default
+	r := &Report{Results: Evaluate([]scanner.ScanResult{legacy}, Options{ExpiryDays: 14})}
+	if f := r.Results[0].Findings; len(f) != 1 || f[0].Level != LevelNote {
+		t.Fatalf("findings = %+v, want one note", f)
+	}
+	if got := r.Failures(); got != 0 {
+		t.Errorf("Failures() = %d, want 0", got)
+	}
+
+	r = &Report{Results: Evaluate([]scanner.ScanResult{legacy}, Options{ExpiryDays: 14, FailOnTLSAudit: true})}
+	if got := r.Failures(); got != 1 {
+		t.Errorf("Failures() with FailOnTLSAudit = %d, want 1", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().Write(&buf, FormatJSON); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var doc jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Total != 5 || doc.Failed != 4 || doc.ExpiryDays != 14 {
+		t.Errorf("total/failed/expiry_days = %d/%d/%d, want 5/4/14", doc.Total, doc.Failed, doc.ExpiryDays)
+	}
+	if !doc.Results[0].Passed || doc.Results[0].Certificate == nil {
+		t.Errorf("results[0] = %+v, want passed with certificate", doc.Results[0])
+	}
+	if doc.Results[4].Certificate != nil || doc.Results[4].Error == "" {
+		t.Errorf("results[4] = %+v, want error without certificate", doc.Results[4])
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().Write(&buf, FormatSARIF); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version/runs = %s/%d, want 2.1.0/1", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Results) != 4 || len(run.Tool.Driver.Rules) != 4 {
+		t.Errorf("results/rules = %d/%d, want 4/4", len(run.Results), len(run.Tool.Driver.Rules))
+	}
+	for _, res := range run.Results {
+		loc := res.Locations[0]
+		if loc.PhysicalLocation.ArtifactLocation.URI != "certwatch.yaml" || loc.LogicalLocations[0].FullyQualifiedName == "" {
+			t.Errorf("location = %+v, want config file and target", loc)
+		}
+	}
+	if run.Results[0].Level != LevelWarning || run.Results[1].Level != LevelError {
+		t.Errorf("levels = %s/%s, want warning/error", run.Results[0].Level, run.Results[1].Level)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().Write(&buf, FormatJUnit); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if doc.Tests != 5 || doc.Failures != 4 || doc.Time != "1.500" {
+		t.Errorf("tests/failures/time = %d/%d/%s, want 5/4/1.500", doc.Tests, doc.Failures, doc.Time)
+	}
+
+	cases := doc.Suites[0].Cases
+	if cases[0].Failure != nil {
+		t.Errorf("cases[0] failure = %+v, want none", cases[0].Failure)
+	}
+	if cases[4].Name != "down.example.com:8443" || cases[4].Failure == nil || cases[4].Failure.Type != RuleScanError {
+		t.Errorf("cases[4] = %+v, want scan_error failure", cases[4])
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().Write(&buf, FormatTable); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"soon.example.com", "down.example.com:8443", "[untrusted_root]", "4 of 5 targets failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := testReport().Write(&bytes.Buffer{}, "yaml"); err == nil {
+		t.Error("Write() error = nil, want error for unknown format")
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		arg      string
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{arg: "example.com", wantHost: "example.com", wantPort: 443},
+		{arg: "example.com:8443", wantHost: "example.com", wantPort: 8443},
+		{arg: "https://example.com/", wantHost: "example.com", wantPort: 443},
+		{arg: "[2001:db8::1]:443", wantHost: "2001:db8::1", wantPort: 443},
+		{arg: "2001:db8::1", wantHost: "2001:db8::1", wantPort: 443},
+		{arg: "example.com:0", wantErr: true},
+		{arg: "example.com:https", wantErr: true},
+		{arg: "example.com/path", wantErr: true},
+		{arg: ":443", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseTarget(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Hostname != tt.wantHost || got.Port != tt.wantPort) {
+				t.Errorf("ParseTarget() = %s:%d, want %s:%d", got.Hostname, got.Port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
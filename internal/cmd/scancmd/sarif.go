@@ -0,0 +1,142 @@
+package scancmd
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/certwatch-app/cw-agent/internal/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIF 2.1.0 subset understood by GitHub code scanning and other SARIF consumers
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// ruleDescriptions describe the rules for SARIF consumers; unknown chain issue
+// types fall back to their ID
+var ruleDescriptions = map[string]string{
+	RuleScanError:           "The TLS endpoint could not be scanned",
+	RuleCertificateExpired:  "The certificate has expired",
+	RuleCertificateExpiring: "The certificate expires within the threshold",
+	"expired":               "A certificate in the chain has expired",
+	"not_yet_valid":         "A certificate in the chain is not yet valid",
+	"self_signed":           "The certificate is self-signed",
+	"hostname_mismatch":     "The certificate does not match the hostname",
+	"weak_crypto":           "The certificate uses a weak key or signature algorithm",
+	"deprecated_protocol":   "The server accepts TLS 1.0 or TLS 1.1",
+	"weak_cipher":           "The server accepts a weak cipher suite",
+	"no_forward_secrecy":    "The server accepts cipher suites without forward secrecy",
+	"no_alpn":               "The server does not negotiate ALPN",
+	"fingerprint_mismatch":  "The addresses of the hostname serve different certificates",
+}
+
+// WriteSARIF renders the findings as a SARIF 2.1.0 log. Every finding is
+// located in the report source (the config file) with the target as logical
+// location.
+func (r *Report) WriteSARIF(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cw-agent",
+			Version:        version.GetVersion(),
+			InformationURI: "https://certwatch.app/docs/agent",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for i := range r.Results {
+		res := &r.Results[i]
+		target := res.GetHostPort()
+		for _, f := range res.Findings {
+			rules[f.Rule] = true
+			run.Results = append(run.Results, sarifResult{
+				RuleID:  f.Rule,
+				Level:   f.Level,
+				Message: sarifMessage{Text: f.Message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: r.Source}},
+					LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: target, Kind: "resource"}},
+				}},
+				// Keeps alerts stable across runs when messages change (e.g. days left)
+				PartialFingerprints: map[string]string{"certwatchTarget/v1": target + "/" + f.Rule},
+			})
+		}
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		desc, ok := ruleDescriptions[id]
+		if !ok {
+			desc = id
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: desc}})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
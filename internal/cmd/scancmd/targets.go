@@ -0,0 +1,39 @@
+package scancmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// ParseTarget parses a command line target: host, host:port, [ipv6]:port or an
+// https:// URL
+func ParseTarget(arg string) (config.CertificateConfig, error) {
+	target := strings.TrimPrefix(arg, "https://")
+	target = strings.TrimSuffix(target, "/")
+	if target == "" || strings.Contains(target, "/") {
+		return config.CertificateConfig{}, fmt.Errorf("invalid target %q: expected host or host:port", arg)
+	}
+
+	host, port := target, 443
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return config.CertificateConfig{}, fmt.Errorf("invalid port in target %q", arg)
+		}
+		host, port = h, n
+	} else if strings.Count(target, ":") > 1 {
+		// Bare IPv6 address
+		host = strings.Trim(target, "[]")
+	} else if strings.Contains(target, ":") {
+		return config.CertificateConfig{}, fmt.Errorf("invalid target %q: %w", arg, err)
+	}
+
+	if host == "" {
+		return config.CertificateConfig{}, fmt.Errorf("invalid target %q: empty host", arg)
+	}
+	return config.CertificateConfig{Hostname: host, Port: port}, nil
+}
@@ -61,19 +61,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	}
 
 	// Initialize state manager
-	// Use dedicated state directory if it exists (for containers with read-only config mounts)
-	configPath := viper.ConfigFileUsed()
-	if configPath == "" {
-		configPath = "./certwatch.yaml" // fallback
-	}
-
-	var stateManager *state.Manager
-	if _, statErr := os.Stat(state.DefaultStateDir); statErr == nil {
-		stateManager = state.NewManagerWithStateDir(state.DefaultStateDir)
-	} else {
-		stateManager = state.NewManager(configPath)
-	}
-
+	stateManager := newStateManager()
 	if loadErr := stateManager.Load(); loadErr != nil {
 		// Log warning but continue - corrupted state is treated as first run
 		fmt.Println(ui.RenderWarning(loadErr.Error()))
@@ -98,18 +86,32 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create agent: %w", err)
 	}
 
+	// Reload the config file when it changes or on SIGHUP
+	a.SetConfigWatcher(viper.ConfigFileUsed(), func() (*config.Config, error) {
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		return config.Load(viper.GetViper())
+	})
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		sig := <-sigChan
-		fmt.Println()
-		fmt.Println(ui.RenderWarning(fmt.Sprintf("Received signal %v, shutting down...", sig)))
-		cancel()
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				a.Reload()
+				continue
+			}
+			fmt.Println()
+			fmt.Println(ui.RenderWarning(fmt.Sprintf("Received signal %v, shutting down...", sig)))
+			cancel()
+			return
+		}
 	}()
 
 	// Display styled startup info
@@ -126,6 +128,9 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	fmt.Println(ui.RenderKeyValue("Certificates", fmt.Sprintf("%d", len(cfg.Certificates))))
 	fmt.Println(ui.RenderKeyValue("Sync", cfg.Agent.SyncInterval.String()))
+	if le := cfg.Agent.LeaderElection; le.Enabled {
+		fmt.Println(ui.RenderKeyValue("Leader lease", le.Namespace()+"/"+le.LeaseName))
+	}
 	fmt.Println()
 
 	fmt.Println(ui.RenderSuccess("Agent started"))
@@ -142,6 +147,21 @@ func runStart(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newStateManager returns the state manager for the config file in use. The
+// dedicated state directory is used if it exists (for containers with
+// read-only config mounts).
+func newStateManager() *state.Manager {
+	if _, err := os.Stat(state.DefaultStateDir); err == nil {
+		return state.NewManagerWithStateDir(state.DefaultStateDir)
+	}
+
+	configPath := viper.ConfigFileUsed()
+	if configPath == "" {
+		configPath = "./certwatch.yaml" // fallback
+	}
+	return state.NewManager(configPath)
+}
+
 // handleNameChangeWarning displays a warning when agent name has changed and exits
 func handleNameChangeWarning(sm *state.Manager, cfg *config.Config) error {
 	fmt.Println()
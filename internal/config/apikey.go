@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/secret"
+)
+
+// APIKeyConfig selects where the API key is read from. Key may reference an
+// environment variable as ${NAME}; an external source (key_file, key_command
+// or key_vault) takes precedence over it. It gathers the api.key* settings
+// shared by the standalone and cert-manager agents.
+// Fields are ordered for optimal memory alignment
+type APIKeyConfig struct {
+	Key     string
+	File    string
+	Command []string
+	Vault   VaultConfig
+	Refresh time.Duration // How often an external key is read again; 0 only after the API rejects it
+}
+
+// envReference matches a key that is exactly one ${NAME} reference
+var envReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// VaultConfig reads the API key from a HashiCorp Vault KV secret
+// Fields are ordered for optimal memory alignment
+type VaultConfig struct {
+	Address   string `mapstructure:"address"`    // Defaults to VAULT_ADDR
+	Namespace string `mapstructure:"namespace"`  // Vault Enterprise namespace
+	Mount     string `mapstructure:"mount"`      // KV mount; default "secret"
+	Path      string `mapstructure:"path"`       // Secret path within the mount
+	Field     string `mapstructure:"field"`      // Default "api_key"
+	Token     string `mapstructure:"token"`      // Defaults to VAULT_TOKEN
+	TokenFile string `mapstructure:"token_file"` // e.g. a Vault Agent sink; read on every fetch
+	CAFile    string `mapstructure:"ca_file"`    // PEM roots trusted for the Vault server
+	KVVersion int    `mapstructure:"kv_version"` // 1 or 2; default 2
+}
+
+// Enabled reports whether the key is read from Vault
+func (v *VaultConfig) Enabled() bool {
+	return v.Path != ""
+}
+
+// Validate checks the Vault settings
+func (v *VaultConfig) Validate() error {
+	if !v.Enabled() {
+		if v.Address != "" || v.Mount != "" || v.Field != "" || v.Token != "" || v.TokenFile != "" {
+			return fmt.Errorf("path is required")
+		}
+		return nil
+	}
+	if v.Address != "" {
+		if err := validateHTTPURL(v.Address); err != nil {
+			return fmt.Errorf("address: %w", err)
+		}
+	}
+	if v.KVVersion != 0 && v.KVVersion != 1 && v.KVVersion != 2 {
+		return fmt.Errorf("kv_version must be 1 or 2")
+	}
+	return nil
+}
+
+// Validate checks that exactly one source of the API key is configured
+func (k *APIKeyConfig) Validate() error {
+	sources := 0
+	if k.File != "" {
+		sources++
+	}
+	if len(k.Command) > 0 {
+		sources++
+		if k.Command[0] == "" {
+			return fmt.Errorf("key_command: program is required")
+		}
+	}
+	if err := k.Vault.Validate(); err != nil {
+		return fmt.Errorf("key_vault: %w", err)
+	}
+	if k.Vault.Enabled() {
+		sources++
+	}
+
+	if sources > 1 {
+		return fmt.Errorf("key_file, key_command and key_vault are mutually exclusive")
+	}
+	if sources == 0 && k.StaticKey() == "" {
+		return fmt.Errorf("key is required")
+	}
+	if k.Refresh != 0 && k.Refresh < 10*time.Second {
+		return fmt.Errorf("key_refresh must be at least 10 seconds (or 0 to disable)")
+	}
+	return nil
+}
+
+// External reports whether the key is read from a file, command or Vault
+func (k *APIKeyConfig) External() bool {
+	return k.File != "" || len(k.Command) > 0 || k.Vault.Enabled()
+}
+
+// Source returns the configured source of the API key
+func (k *APIKeyConfig) Source() secret.Source {
+	switch {
+	case k.File != "":
+		return secret.File(k.File)
+	case len(k.Command) > 0:
+		return secret.Command(k.Command)
+	case k.Vault.Enabled():
+		return &secret.Vault{
+			Address:   k.Vault.Address,
+			Namespace: k.Vault.Namespace,
+			Mount:     k.Vault.Mount,
+			Path:      k.Vault.Path,
+			Field:     k.Vault.Field,
+			Token:     k.Vault.Token,
+			TokenFile: k.Vault.TokenFile,
+			CAFile:    k.Vault.CAFile,
+			KVVersion: k.Vault.KVVersion,
+		}
+	default:
+		return secret.Static(k.StaticKey())
+	}
+}
+
+// StaticKey returns the key set in the config. A key that is exactly ${NAME}
+// reads the environment variable; any other key, even one containing $, is used
+// as written.
+func (k *APIKeyConfig) StaticKey() string {
+	if m := envReference.FindStringSubmatch(k.Key); m != nil {
+		return os.Getenv(m[1])
+	}
+	return k.Key
+}
+
+// RefreshInterval returns how often the key is read again; 0 means never
+func (k *APIKeyConfig) RefreshInterval() time.Duration {
+	if !k.External() {
+		return 0
+	}
+	return k.Refresh
+}
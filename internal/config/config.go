@@ -2,47 +2,257 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/certwatch-app/cw-agent/internal/proxy"
 )
 
 // Config represents the complete agent configuration
 type Config struct {
 	API          APIConfig           `mapstructure:"api"`
 	Agent        AgentConfig         `mapstructure:"agent"`
+	Discovery    DiscoveryConfig     `mapstructure:"discovery"`
+	Notify       NotifyConfig        `mapstructure:"notify"`
 	Certificates []CertificateConfig `mapstructure:"certificates"`
 }
 
 // APIConfig contains API connection settings
 type APIConfig struct {
-	Endpoint string        `mapstructure:"endpoint"`
-	Key      string        `mapstructure:"key"`
-	Timeout  time.Duration `mapstructure:"timeout"`
+	Endpoint    string        `mapstructure:"endpoint"`
+	Key         string        `mapstructure:"key"`
+	KeyFile     string        `mapstructure:"key_file"`    // Read the key from a file, e.g. a mounted secret
+	KeyCommand  []string      `mapstructure:"key_command"` // Read the key from the output of a helper program
+	KeyVault    VaultConfig   `mapstructure:"key_vault"`   // Read the key from a HashiCorp Vault KV secret
+	KeyRefresh  time.Duration `mapstructure:"key_refresh"` // How often key_file, key_command or key_vault is read again
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Retry       RetryConfig   `mapstructure:"retry"`
+	Proxy       ProxyConfig   `mapstructure:"proxy"`
+	TLS         APITLSConfig  `mapstructure:"tls"`
+	Compression string        `mapstructure:"compression"` // Request body compression: gzip or none
+}
+
+// Request body compression
+const (
+	CompressionGzip = "gzip"
+	CompressionNone = "none"
+)
+
+// ValidateCompression checks an api.compression value
+func ValidateCompression(c string) error {
+	if c != CompressionGzip && c != CompressionNone {
+		return fmt.Errorf("compression must be %q or %q", CompressionGzip, CompressionNone)
+	}
+	return nil
+}
+
+// APIKey returns the API key settings
+func (a *APIConfig) APIKey() APIKeyConfig {
+	return APIKeyConfig{Key: a.Key, File: a.KeyFile, Command: a.KeyCommand, Vault: a.KeyVault, Refresh: a.KeyRefresh}
+}
+
+// APITLSConfig configures TLS for the connection to the CertWatch API
+type APITLSConfig struct {
+	CertFile   string `mapstructure:"cert_file"`   // PEM client certificate presented to the API; reloaded when it changes
+	KeyFile    string `mapstructure:"key_file"`    // PEM private key of the client certificate
+	CAFile     string `mapstructure:"ca_file"`     // PEM roots trusted in addition to the system roots
+	MinVersion string `mapstructure:"min_version"` // "1.2" or "1.3"; empty means 1.2
+}
+
+// TLS versions accepted by APITLSConfig.MinVersion
+var apiTLSVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// MinTLSVersion returns the minimum TLS version as a crypto/tls constant
+func (t *APITLSConfig) MinTLSVersion() uint16 {
+	return apiTLSVersions[t.MinVersion]
+}
+
+// Validate checks that the certificate, key and CA files can be loaded
+func (t *APITLSConfig) Validate() error {
+	if _, ok := apiTLSVersions[t.MinVersion]; !ok {
+		return fmt.Errorf("min_version must be 1.2 or 1.3")
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if t.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return fmt.Errorf("cert_file: %w", err)
+		}
+	}
+
+	if t.CAFile != "" {
+		data, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return fmt.Errorf("ca_file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("ca_file: no PEM certificates found")
+		}
+	}
+	return nil
+}
+
+// RetryConfig controls retries of failed API calls
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// ProxyConfig routes outbound connections through an HTTP CONNECT or SOCKS5 proxy
+type ProxyConfig struct {
+	URL     string   `mapstructure:"url"`      // http, https or socks5 URL, optionally with user:password
+	NoProxy []string `mapstructure:"no_proxy"` // Hosts (and their subdomains), .domains, IPs and CIDRs connected to directly
+}
+
+// Validate checks the proxy URL
+func (p *ProxyConfig) Validate() error {
+	if p.URL == "" {
+		return nil
+	}
+	_, err := proxy.Parse(p.URL)
+	return err
 }
 
 // AgentConfig contains agent behavior settings
 // Fields are ordered for optimal memory alignment
 type AgentConfig struct {
-	Name              string        `mapstructure:"name"`
-	LogLevel          string        `mapstructure:"log_level"`
-	SyncInterval      time.Duration `mapstructure:"sync_interval"`
-	ScanInterval      time.Duration `mapstructure:"scan_interval"`
-	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
-	Concurrency       int           `mapstructure:"concurrency"`
-	MetricsPort       int           `mapstructure:"metrics_port"`
+	Name              string               `mapstructure:"name"`
+	LogLevel          string               `mapstructure:"log_level"`
+	SyncInterval      time.Duration        `mapstructure:"sync_interval"`
+	ScanInterval      time.Duration        `mapstructure:"scan_interval"`
+	HeartbeatInterval time.Duration        `mapstructure:"heartbeat_interval"`
+	Concurrency       int                  `mapstructure:"concurrency"`
+	MetricsPort       int                  `mapstructure:"metrics_port"`
+	Outbox            OutboxConfig         `mapstructure:"outbox"`
+	Revocation        RevocationConfig     `mapstructure:"revocation"`
+	TLSAudit          TLSAuditConfig       `mapstructure:"tls_audit"`
+	ScanProxy         ProxyConfig          `mapstructure:"scan_proxy"`
+	History           HistoryConfig        `mapstructure:"history"`
+	LeaderElection    LeaderElectionConfig `mapstructure:"leader_election"`
+}
+
+// OutboxConfig controls the disk-backed queue of sync payloads that failed to reach the API
+type OutboxConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	MaxSizeMB int           `mapstructure:"max_size_mb"`
+	MaxAge    time.Duration `mapstructure:"max_age"`
+}
+
+// RevocationConfig controls OCSP and CRL revocation checks during scans
+type RevocationConfig struct {
+	OCSPResponder string `mapstructure:"ocsp_responder"` // Overrides the OCSP URL in certificates
+	CRLURL        string `mapstructure:"crl_url"`        // Overrides the CRL distribution point in certificates
+	Enabled       bool   `mapstructure:"enabled"`
+}
+
+// TLSAuditConfig controls probing of the TLS versions and cipher suites each endpoint accepts
+type TLSAuditConfig struct {
+	Interval time.Duration `mapstructure:"interval"` // How long audit results are reused before probing again
+	Enabled  bool          `mapstructure:"enabled"`
+}
+
+// HistoryConfig controls the local SQLite history of scan results
+type HistoryConfig struct {
+	Path      string        `mapstructure:"path"`      // Database file; default history.db in the state directory
+	Retention time.Duration `mapstructure:"retention"` // Observations last seen longer ago are deleted
+	Enabled   bool          `mapstructure:"enabled"`
+}
+
+// DiscoveryConfig controls dynamic discovery of certificates to monitor.
+// Discovered targets are merged with the static certificates list.
+type DiscoveryConfig struct {
+	ZoneFiles  []ZoneFileConfig          `mapstructure:"zone_files"`
+	Kubernetes KubernetesDiscoveryConfig `mapstructure:"kubernetes"`
+	Interval   time.Duration             `mapstructure:"interval"`
+}
+
+// ZoneFileConfig describes a BIND zone file to discover hostnames from
+// Fields are ordered for optimal memory alignment
+type ZoneFileConfig struct {
+	Path    string   `mapstructure:"path"`
+	Origin  string   `mapstructure:"origin"`  // Used when the file has no $ORIGIN
+	Exclude []string `mapstructure:"exclude"` // Glob patterns of hostnames to skip
+	Tags    []string `mapstructure:"tags"`
+	Port    int      `mapstructure:"port"`
+}
+
+// KubernetesDiscoveryConfig controls discovery from Kubernetes resources
+type KubernetesDiscoveryConfig struct {
+	Kubeconfig    string   `mapstructure:"kubeconfig"`     // Empty uses in-cluster or default kubeconfig
+	LabelSelector string   `mapstructure:"label_selector"` // Applied to all listed resources
+	Namespaces    []string `mapstructure:"namespaces"`     // Empty means all namespaces
+	Sources       []string `mapstructure:"sources"`        // ingress, gateway, service
+	Tags          []string `mapstructure:"tags"`
+}
+
+// Kubernetes discovery sources
+const (
+	DiscoverIngress = "ingress"
+	DiscoverGateway = "gateway"
+	DiscoverService = "service"
+)
+
+// Enabled reports whether any discovery source is configured
+func (d *DiscoveryConfig) Enabled() bool {
+	return len(d.ZoneFiles) > 0 || len(d.Kubernetes.Sources) > 0
 }
 
 // CertificateConfig represents a certificate to monitor
 // Fields are ordered for optimal memory alignment
 type CertificateConfig struct {
-	Hostname string   `mapstructure:"hostname"`
-	Notes    string   `mapstructure:"notes"`
-	Tags     []string `mapstructure:"tags"`
-	Port     int      `mapstructure:"port"`
+	Hostname     string   `mapstructure:"hostname"`
+	Address      string   `mapstructure:"address"`     // Host or IP to connect to instead of hostname
+	ServerName   string   `mapstructure:"server_name"` // SNI sent instead of hostname; NoSNI sends none
+	Notes        string   `mapstructure:"notes"`
+	StartTLS     string   `mapstructure:"starttls"`     // Protocol to upgrade via STARTTLS (empty for direct TLS)
+	CABundle     string   `mapstructure:"ca_bundle"`    // PEM roots trusted in addition to the system roots
+	ServerNames  []string `mapstructure:"server_names"` // Scanned once per name; expanded into ServerName by Load
+	Tags         []string `mapstructure:"tags"`
+	Port         int      `mapstructure:"port"`
+	AllAddresses bool     `mapstructure:"all_addresses"` // Scan every A/AAAA record instead of one
+}
+
+// NoSNI as server_name scans without sending SNI, which returns the server's
+// default certificate
+const NoSNI = "-"
+
+// Supported STARTTLS protocols
+const (
+	StartTLSSMTP     = "smtp"
+	StartTLSIMAP     = "imap"
+	StartTLSPOP3     = "pop3"
+	StartTLSLDAP     = "ldap"
+	StartTLSFTP      = "ftp"
+	StartTLSXMPP     = "xmpp"
+	StartTLSPostgres = "postgres"
+)
+
+// startTLSDefaultPorts maps each STARTTLS protocol to its well-known plaintext port
+var startTLSDefaultPorts = map[string]int{
+	StartTLSSMTP:     25,
+	StartTLSIMAP:     143,
+	StartTLSPOP3:     110,
+	StartTLSLDAP:     389,
+	StartTLSFTP:      21,
+	StartTLSXMPP:     5222,
+	StartTLSPostgres: 5432,
 }
 
 // Load reads configuration from viper
@@ -55,13 +265,34 @@ func Load(v *viper.Viper) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Scan each of several server names as its own target
+	certs, err := expandServerNames(cfg.Certificates)
+	if err != nil {
+		return nil, fmt.Errorf("certificates: %w", err)
+	}
+	cfg.Certificates = certs
+
 	// Apply defaults for certificate ports
 	for i := range cfg.Certificates {
+		cfg.Certificates[i].StartTLS = strings.ToLower(cfg.Certificates[i].StartTLS)
 		if cfg.Certificates[i].Port == 0 {
-			cfg.Certificates[i].Port = 443
+			if port, ok := startTLSDefaultPorts[cfg.Certificates[i].StartTLS]; ok {
+				cfg.Certificates[i].Port = port
+			} else {
+				cfg.Certificates[i].Port = 443
+			}
 		}
 	}
 
+	for i := range cfg.Discovery.ZoneFiles {
+		if cfg.Discovery.ZoneFiles[i].Port == 0 {
+			cfg.Discovery.ZoneFiles[i].Port = 443
+		}
+	}
+	for i, src := range cfg.Discovery.Kubernetes.Sources {
+		cfg.Discovery.Kubernetes.Sources[i] = strings.ToLower(src)
+	}
+
 	return cfg, nil
 }
 
@@ -70,6 +301,11 @@ func setDefaults(v *viper.Viper) {
 	// API defaults
 	v.SetDefault("api.endpoint", "https://api.certwatch.app")
 	v.SetDefault("api.timeout", "30s")
+	v.SetDefault("api.key_refresh", "5m")
+	v.SetDefault("api.retry.max_attempts", 3)
+	v.SetDefault("api.retry.initial_backoff", "1s")
+	v.SetDefault("api.retry.max_backoff", "30s")
+	v.SetDefault("api.compression", CompressionNone)
 
 	// Agent defaults
 	v.SetDefault("agent.name", "default-agent")
@@ -79,6 +315,21 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.concurrency", 10)
 	v.SetDefault("agent.log_level", "info")
 	v.SetDefault("agent.metrics_port", 8080)
+	v.SetDefault("agent.outbox.enabled", true)
+	v.SetDefault("agent.outbox.max_size_mb", 10)
+	v.SetDefault("agent.outbox.max_age", "24h")
+	v.SetDefault("agent.revocation.enabled", true)
+	v.SetDefault("agent.tls_audit.enabled", true)
+	v.SetDefault("agent.tls_audit.interval", "6h")
+	v.SetDefault("agent.history.enabled", true)
+	v.SetDefault("agent.history.retention", "2160h")
+	SetLeaderElectionDefaults(v, "cw-agent")
+
+	// Discovery defaults
+	v.SetDefault("discovery.interval", "5m")
+
+	// Notify defaults
+	SetNotifyDefaults(v)
 }
 
 // Validate validates the configuration
@@ -93,6 +344,16 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("agent: %w", err)
 	}
 
+	// Validate discovery
+	if err := c.validateDiscovery(); err != nil {
+		return fmt.Errorf("discovery: %w", err)
+	}
+
+	// Validate notifications
+	if err := c.Notify.Validate(); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
 	// Validate certificates
 	if err := c.validateCertificates(); err != nil {
 		return fmt.Errorf("certificates: %w", err)
@@ -115,11 +376,13 @@ func (c *Config) validateAPI() error {
 		return fmt.Errorf("endpoint must use http or https scheme")
 	}
 
-	if c.API.Key == "" {
-		return fmt.Errorf("key is required")
+	apiKey := c.API.APIKey()
+	if err := apiKey.Validate(); err != nil {
+		return err
 	}
 
-	if !strings.HasPrefix(c.API.Key, "cw_") {
+	// Keys from external sources are checked when they are read
+	if !apiKey.External() && !strings.HasPrefix(apiKey.StaticKey(), "cw_") {
 		return fmt.Errorf("key must start with 'cw_' prefix")
 	}
 
@@ -127,6 +390,36 @@ func (c *Config) validateAPI() error {
 		return fmt.Errorf("timeout must be at least 1 second")
 	}
 
+	// Retry max_attempts of 0 means the client default is used
+	if c.API.Retry.MaxAttempts != 0 {
+		if c.API.Retry.MaxAttempts < 1 || c.API.Retry.MaxAttempts > 10 {
+			return fmt.Errorf("retry.max_attempts must be between 1 and 10")
+		}
+
+		if c.API.Retry.InitialBackoff < 100*time.Millisecond {
+			return fmt.Errorf("retry.initial_backoff must be at least 100ms")
+		}
+
+		if c.API.Retry.MaxBackoff < c.API.Retry.InitialBackoff {
+			return fmt.Errorf("retry.max_backoff must be at least retry.initial_backoff")
+		}
+	}
+
+	if err := c.API.Proxy.Validate(); err != nil {
+		return fmt.Errorf("proxy: %w", err)
+	}
+
+	if err := c.API.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+
+	// Empty when Config is constructed directly; the client then doesn't compress
+	if c.API.Compression != "" {
+		if err := ValidateCompression(c.API.Compression); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -168,12 +461,59 @@ func (c *Config) validateAgent() error {
 		return fmt.Errorf("metrics_port must be between 1 and 65535 (or 0 to disable)")
 	}
 
+	if c.Agent.Outbox.Enabled {
+		if c.Agent.Outbox.MaxSizeMB < 1 || c.Agent.Outbox.MaxSizeMB > 1024 {
+			return fmt.Errorf("outbox.max_size_mb must be between 1 and 1024")
+		}
+		if c.Agent.Outbox.MaxAge < time.Minute {
+			return fmt.Errorf("outbox.max_age must be at least 1 minute")
+		}
+	}
+
+	if err := validateHTTPURL(c.Agent.Revocation.OCSPResponder); err != nil {
+		return fmt.Errorf("revocation.ocsp_responder: %w", err)
+	}
+	if err := validateHTTPURL(c.Agent.Revocation.CRLURL); err != nil {
+		return fmt.Errorf("revocation.crl_url: %w", err)
+	}
+
+	if c.Agent.TLSAudit.Enabled && c.Agent.TLSAudit.Interval < time.Minute {
+		return fmt.Errorf("tls_audit.interval must be at least 1 minute")
+	}
+
+	if err := c.Agent.ScanProxy.Validate(); err != nil {
+		return fmt.Errorf("scan_proxy: %w", err)
+	}
+
+	if c.Agent.History.Enabled && c.Agent.History.Retention < time.Hour {
+		return fmt.Errorf("history.retention must be at least 1 hour")
+	}
+
+	if err := c.Agent.LeaderElection.Validate(); err != nil {
+		return fmt.Errorf("leader_election: %w", err)
+	}
+
+	return nil
+}
+
+// validateHTTPURL checks that an optional URL uses http or https
+func validateHTTPURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL")
+	}
 	return nil
 }
 
 func (c *Config) validateCertificates() error {
-	if len(c.Certificates) == 0 {
-		return fmt.Errorf("at least one certificate is required")
+	if len(c.Certificates) == 0 && !c.Discovery.Enabled() {
+		return fmt.Errorf("at least one certificate is required (or configure discovery)")
 	}
 
 	if len(c.Certificates) > 1000 {
@@ -190,9 +530,13 @@ func (c *Config) validateCertificates() error {
 			return fmt.Errorf("[%d]: port must be between 1 and 65535", i)
 		}
 
-		key := fmt.Sprintf("%s:%d", cert.Hostname, cert.Port)
+		// Targets are identified by name, so each server name of a host is its own
+		key := cert.GetHostPort()
+		if cert.Address != "" {
+			key += " (" + cert.Address + ")"
+		}
 		if seen[key] {
-			return fmt.Errorf("[%d]: duplicate hostname:port '%s'", i, key)
+			return fmt.Errorf("[%d]: duplicate target '%s'", i, key)
 		}
 		seen[key] = true
 
@@ -205,12 +549,125 @@ func (c *Config) validateCertificates() error {
 		if len(cert.Notes) > 500 {
 			return fmt.Errorf("[%d]: notes must be at most 500 characters", i)
 		}
+
+		if cert.StartTLS != "" {
+			if _, ok := startTLSDefaultPorts[cert.StartTLS]; !ok {
+				return fmt.Errorf("[%d]: starttls must be one of: smtp, imap, pop3, ldap, ftp, xmpp, postgres", i)
+			}
+		}
+
+		if cert.Address != "" {
+			if _, _, err := net.SplitHostPort(cert.Address); err == nil {
+				return fmt.Errorf("[%d]: address must not include a port", i)
+			}
+		}
+
+		if cert.ServerName != "" && cert.ServerName != NoSNI && strings.ContainsAny(cert.ServerName, ":/ ") {
+			return fmt.Errorf("[%d]: server_name must be a hostname or %q for no SNI", i, NoSNI)
+		}
+
+		if cert.CABundle != "" {
+			if _, err := os.Stat(cert.CABundle); err != nil {
+				return fmt.Errorf("[%d]: ca_bundle: %w", i, err)
+			}
+		}
 	}
 
 	return nil
 }
 
-// GetHostPort returns the hostname:port string for a certificate config
+func (c *Config) validateDiscovery() error {
+	if !c.Discovery.Enabled() {
+		return nil
+	}
+
+	if c.Discovery.Interval < 30*time.Second {
+		return fmt.Errorf("interval must be at least 30 seconds")
+	}
+
+	for i, zf := range c.Discovery.ZoneFiles {
+		if zf.Path == "" {
+			return fmt.Errorf("zone_files[%d]: path is required", i)
+		}
+		if zf.Port < 1 || zf.Port > 65535 {
+			return fmt.Errorf("zone_files[%d]: port must be between 1 and 65535", i)
+		}
+		for _, pattern := range zf.Exclude {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("zone_files[%d]: invalid exclude pattern '%s'", i, pattern)
+			}
+		}
+	}
+
+	for _, src := range c.Discovery.Kubernetes.Sources {
+		switch src {
+		case DiscoverIngress, DiscoverGateway, DiscoverService:
+		default:
+			return fmt.Errorf("kubernetes.sources must contain only: ingress, gateway, service")
+		}
+	}
+
+	return nil
+}
+
+// GetHostPort returns the name:port string for a certificate config
 func (c *CertificateConfig) GetHostPort() string {
-	return fmt.Sprintf("%s:%d", c.Hostname, c.Port)
+	return fmt.Sprintf("%s:%d", c.Name(), c.Port)
+}
+
+// Name identifies the target: its hostname, or sni@hostname when a different
+// server name is sent
+func (c *CertificateConfig) Name() string {
+	return TargetName(c.Hostname, c.ServerName)
+}
+
+// SNI returns the server name sent in the handshake, empty for none
+func (c *CertificateConfig) SNI() string {
+	switch c.ServerName {
+	case "":
+		return c.Hostname
+	case NoSNI:
+		return ""
+	}
+	return c.ServerName
+}
+
+// DialAddress returns the host:port to connect to
+func (c *CertificateConfig) DialAddress() string {
+	host := c.Address
+	if host == "" {
+		host = c.Hostname
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.Port))
+}
+
+// TargetName returns the name of a target scanned with serverName: the hostname,
+// or sni@hostname when serverName differs from it
+func TargetName(hostname, serverName string) string {
+	if serverName == "" || strings.EqualFold(serverName, hostname) {
+		return hostname
+	}
+	return serverName + "@" + hostname
+}
+
+// expandServerNames replaces every certificate with server_names by one copy per
+// name with server_name set
+func expandServerNames(certs []CertificateConfig) ([]CertificateConfig, error) {
+	out := make([]CertificateConfig, 0, len(certs))
+	for i, cert := range certs {
+		if len(cert.ServerNames) == 0 {
+			out = append(out, cert)
+			continue
+		}
+		if cert.ServerName != "" {
+			return nil, fmt.Errorf("[%d]: server_name and server_names are mutually exclusive", i)
+		}
+		for _, name := range cert.ServerNames {
+			c := cert
+			c.ServerName = name
+			c.ServerNames = nil
+			out = append(out, c)
+		}
+	}
+	return out, nil
 }
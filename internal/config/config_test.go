@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoad_ServerNames(t *testing.T) {
+	v := viper.New()
+	v.Set("api.key", "cw_test")
+	v.Set("agent.name", "test-agent")
+	v.Set("certificates", []map[string]any{
+		{"hostname": "lb.example.com", "server_names": []string{"a.example.com", "b.example.com", NoSNI}},
+		{"hostname": "lb.example.com", "address": "10.0.0.1", "server_names": []string{"a.example.com"}},
+	})
+
+	cfg, err := Load(v)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if len(cfg.Certificates) != 4 {
+		t.Fatalf("len(Certificates) = %d, want 4", len(cfg.Certificates))
+	}
+	for i, want := range []string{"a.example.com@lb.example.com", "b.example.com@lb.example.com"} {
+		if got := cfg.Certificates[i].Name(); got != want {
+			t.Errorf("Certificates[%d].Name() = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestValidate_DuplicateServerName(t *testing.T) {
+	v := viper.New()
+	v.Set("api.key", "cw_test")
+	v.Set("agent.name", "test-agent")
+	v.Set("certificates", []map[string]any{
+		{"hostname": "lb.example.com", "server_names": []string{"a.example.com", "a.example.com"}},
+	})
+
+	cfg, err := Load(v)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate target") {
+		t.Errorf("Validate() error = %v, want duplicate target", err)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// serviceAccountNamespaceFile holds the pod's namespace when running in Kubernetes
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// LeaderElectionConfig lets several replicas of an agent run with one active at
+// a time. The replica holding a Kubernetes Lease does the work; the others wait
+// to take over. It is shared by the standalone and cert-manager agents.
+// Fields are ordered for optimal memory alignment
+type LeaderElectionConfig struct {
+	LeaseName      string        `mapstructure:"lease_name"`
+	LeaseNamespace string        `mapstructure:"lease_namespace"` // Defaults to the pod's namespace
+	LeaseDuration  time.Duration `mapstructure:"lease_duration"`  // How long standbys wait before taking over
+	RenewDeadline  time.Duration `mapstructure:"renew_deadline"`  // How long the leader retries renewing before giving up
+	RetryPeriod    time.Duration `mapstructure:"retry_period"`
+	Enabled        bool          `mapstructure:"enabled"`
+}
+
+// SetLeaderElectionDefaults sets the defaults of agent.leader_election
+func SetLeaderElectionDefaults(v *viper.Viper, leaseName string) {
+	v.SetDefault("agent.leader_election.lease_name", leaseName)
+	v.SetDefault("agent.leader_election.lease_duration", "15s")
+	v.SetDefault("agent.leader_election.renew_deadline", "10s")
+	v.SetDefault("agent.leader_election.retry_period", "2s")
+}
+
+// Validate checks the lease timings
+func (l *LeaderElectionConfig) Validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.LeaseName == "" {
+		return fmt.Errorf("lease_name is required")
+	}
+	if l.RetryPeriod <= 0 {
+		return fmt.Errorf("retry_period must be positive")
+	}
+	if l.RenewDeadline <= l.RetryPeriod {
+		return fmt.Errorf("renew_deadline must be greater than retry_period")
+	}
+	if l.LeaseDuration <= l.RenewDeadline {
+		return fmt.Errorf("lease_duration must be greater than renew_deadline")
+	}
+	return nil
+}
+
+// Namespace returns the namespace of the Lease: lease_namespace, $POD_NAMESPACE,
+// the service account's namespace or "default"
+func (l *LeaderElectionConfig) Namespace() string {
+	if l.LeaseNamespace != "" {
+		return l.LeaseNamespace
+	}
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return "default"
+}
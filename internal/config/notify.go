@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// NotifyConfig configures alerts sent directly from the agent, independent of the
+// CertWatch API. It is shared by the standalone and cert-manager agents.
+// Fields are ordered for optimal memory alignment
+type NotifyConfig struct {
+	Webhooks   []WebhookConfig `mapstructure:"webhooks"`
+	Slack      []SlackConfig   `mapstructure:"slack"`
+	Thresholds []int           `mapstructure:"thresholds"` // Days before expiry to alert at
+	Email      EmailConfig     `mapstructure:"email"`
+	Timeout    time.Duration   `mapstructure:"timeout"`
+}
+
+// WebhookConfig is a generic webhook receiving alerts as JSON
+type WebhookConfig struct {
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"` // e.g. Authorization
+}
+
+// SlackConfig is a Slack-compatible incoming webhook (Slack, Mattermost, Rocket.Chat)
+type SlackConfig struct {
+	WebhookURL string `mapstructure:"webhook_url"`
+	Channel    string `mapstructure:"channel"`  // Optional channel override
+	Username   string `mapstructure:"username"` // Optional sender name override
+}
+
+// EmailConfig sends alerts over SMTP. STARTTLS is used when the server offers it.
+// Fields are ordered for optimal memory alignment
+type EmailConfig struct {
+	Host     string   `mapstructure:"host"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+	Port     int      `mapstructure:"port"`
+}
+
+// DefaultNotifyThresholds are the days before expiry at which alerts are sent
+var DefaultNotifyThresholds = []int{30, 14, 7, 1}
+
+// Enabled reports whether any notification sink is configured
+func (n *NotifyConfig) Enabled() bool {
+	return len(n.Webhooks) > 0 || len(n.Slack) > 0 || n.Email.Host != ""
+}
+
+// SetNotifyDefaults sets defaults for the top-level notify section
+func SetNotifyDefaults(v *viper.Viper) {
+	v.SetDefault("notify.thresholds", DefaultNotifyThresholds)
+	v.SetDefault("notify.timeout", "10s")
+	v.SetDefault("notify.email.port", 587)
+}
+
+// Validate validates the notify configuration
+func (n *NotifyConfig) Validate() error {
+	if !n.Enabled() {
+		return nil
+	}
+
+	if n.Timeout < time.Second {
+		return fmt.Errorf("timeout must be at least 1 second")
+	}
+
+	for i, days := range n.Thresholds {
+		if days < 1 || days > 365 {
+			return fmt.Errorf("thresholds[%d]: must be between 1 and 365 days", i)
+		}
+	}
+
+	for i, w := range n.Webhooks {
+		if err := validateHTTPURL(w.URL); err != nil || w.URL == "" {
+			return fmt.Errorf("webhooks[%d]: url must be an http or https URL", i)
+		}
+	}
+
+	for i, s := range n.Slack {
+		if err := validateHTTPURL(s.WebhookURL); err != nil || s.WebhookURL == "" {
+			return fmt.Errorf("slack[%d]: webhook_url must be an http or https URL", i)
+		}
+	}
+
+	if n.Email.Host != "" {
+		if n.Email.Port < 1 || n.Email.Port > 65535 {
+			return fmt.Errorf("email.port must be between 1 and 65535")
+		}
+		if n.Email.From == "" {
+			return fmt.Errorf("email.from is required")
+		}
+		if len(n.Email.To) == 0 {
+			return fmt.Errorf("email.to requires at least one recipient")
+		}
+	}
+
+	return nil
+}
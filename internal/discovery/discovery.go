@@ -0,0 +1,147 @@
+// Package discovery finds certificates to monitor from dynamic sources such as
+// BIND zone files and Kubernetes resources. Discovered targets are merged with the
+// static certificates list before scanning.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// SourceTagPrefix prefixes the tag identifying where a target was discovered
+const SourceTagPrefix = "source:"
+
+// Source discovers certificate targets
+type Source interface {
+	// Name uniquely identifies the source. Targets are tagged "source:<kind>",
+	// where kind is the part of the name before any ":".
+	Name() string
+	// Discover returns the targets currently known to the source
+	Discover(ctx context.Context) ([]config.CertificateConfig, error)
+}
+
+// SourceResult summarizes one source's contribution to a discovery run
+type SourceResult struct {
+	Err     error
+	Name    string
+	Targets int
+}
+
+// Discoverer runs all sources and merges their targets with the static list.
+// A source that fails keeps contributing its last successful result so a transient
+// error doesn't drop targets.
+// Fields are ordered for optimal memory alignment
+type Discoverer struct {
+	logger  *zap.Logger
+	last    map[string][]config.CertificateConfig
+	sources []Source
+}
+
+// New creates a Discoverer for the given sources
+func New(sources []Source, logger *zap.Logger) *Discoverer {
+	return &Discoverer{
+		logger:  logger,
+		last:    make(map[string][]config.CertificateConfig),
+		sources: sources,
+	}
+}
+
+// FromConfig creates a Discoverer with the sources enabled in cfg
+func FromConfig(cfg *config.DiscoveryConfig, logger *zap.Logger) (*Discoverer, error) {
+	sources := make([]Source, 0, len(cfg.ZoneFiles)+len(cfg.Kubernetes.Sources))
+
+	for i := range cfg.ZoneFiles {
+		sources = append(sources, NewZoneFileSource(cfg.ZoneFiles[i]))
+	}
+
+	if len(cfg.Kubernetes.Sources) > 0 {
+		reader, err := NewKubernetesClient(cfg.Kubernetes.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		k8sSources, err := NewKubernetesSources(reader, &cfg.Kubernetes, logger)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, k8sSources...)
+	}
+
+	return New(sources, logger), nil
+}
+
+// Discover runs every source and returns static followed by the discovered targets.
+// Static entries win over discovered ones with the same hostname:port; targets found
+// by several sources are reported once with all their source tags.
+func (d *Discoverer) Discover(ctx context.Context, static []config.CertificateConfig) ([]config.CertificateConfig, []SourceResult) {
+	results := make([]SourceResult, 0, len(d.sources))
+	discovered := make(map[string]*config.CertificateConfig)
+
+	for _, src := range d.sources {
+		name := src.Name()
+		targets, err := src.Discover(ctx)
+		if err != nil {
+			d.logger.Warn("discovery source failed, using last known targets",
+				zap.String("source", name),
+				zap.Error(err),
+			)
+			targets = d.last[name]
+		} else {
+			d.last[name] = targets
+		}
+		results = append(results, SourceResult{Name: name, Targets: len(targets), Err: err})
+
+		kind, _, _ := strings.Cut(name, ":")
+		tag := SourceTagPrefix + kind
+		for _, t := range targets {
+			key := targetKey(t.Hostname, t.Port)
+			if existing, ok := discovered[key]; ok {
+				existing.Tags = appendUnique(existing.Tags, t.Tags...)
+				existing.Tags = appendUnique(existing.Tags, tag)
+				continue
+			}
+			t.Tags = appendUnique(slices.Clone(t.Tags), tag)
+			discovered[key] = &t
+		}
+	}
+
+	seen := make(map[string]bool, len(static))
+	for _, c := range static {
+		seen[targetKey(c.Hostname, c.Port)] = true
+	}
+
+	keys := make([]string, 0, len(discovered))
+	for key := range discovered {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	merged := make([]config.CertificateConfig, 0, len(static)+len(keys))
+	merged = append(merged, static...)
+	for _, key := range keys {
+		merged = append(merged, *discovered[key])
+	}
+
+	return merged, results
+}
+
+func targetKey(hostname string, port int) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(hostname), port)
+}
+
+func appendUnique(tags []string, add ...string) []string {
+	for _, tag := range add {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// stubSource returns fixed targets or an error
+type stubSource struct {
+	err     error
+	name    string
+	targets []config.CertificateConfig
+}
+
+func (s *stubSource) Name() string { return s.name }
+
+func (s *stubSource) Discover(context.Context) ([]config.CertificateConfig, error) {
+	return s.targets, s.err
+}
+
+func TestDiscover_MergesWithStatic(t *testing.T) {
+	static := []config.CertificateConfig{
+		{Hostname: "static.example.com", Port: 443, Tags: []string{"prod"}},
+		{Hostname: "shared.example.com", Port: 443},
+	}
+	zone := &stubSource{name: "zonefile:/etc/bind/db.example.com", targets: []config.CertificateConfig{
+		{Hostname: "b.example.com", Port: 443},
+		{Hostname: "shared.example.com", Port: 443},
+	}}
+	ingress := &stubSource{name: "ingress", targets: []config.CertificateConfig{
+		{Hostname: "a.example.com", Port: 443, Tags: []string{"k8s"}},
+		{Hostname: "b.example.com", Port: 443},
+	}}
+
+	d := New([]Source{zone, ingress}, zap.NewNop())
+	targets, results := d.Discover(context.Background(), static)
+
+	hosts := make([]string, 0, len(targets))
+	for _, tgt := range targets {
+		hosts = append(hosts, tgt.Hostname)
+	}
+	want := []string{"static.example.com", "shared.example.com", "a.example.com", "b.example.com"}
+	if !slices.Equal(hosts, want) {
+		t.Fatalf("hosts = %v, want %v", hosts, want)
+	}
+
+	// Static entries are untouched
+	if !slices.Equal(targets[1].Tags, nil) {
+		t.Errorf("static Tags = %v, want none", targets[1].Tags)
+	}
+	if !slices.Equal(targets[2].Tags, []string{"k8s", "source:ingress"}) {
+		t.Errorf("a.example.com Tags = %v, want [k8s source:ingress]", targets[2].Tags)
+	}
+	if !slices.Equal(targets[3].Tags, []string{"source:zonefile", "source:ingress"}) {
+		t.Errorf("b.example.com Tags = %v, want both source tags", targets[3].Tags)
+	}
+
+	if len(results) != 2 || results[0].Targets != 2 || results[1].Targets != 2 {
+		t.Errorf("results = %+v, want 2 targets per source", results)
+	}
+
+	// The source's own slice must not be modified by tagging
+	if len(ingress.targets[0].Tags) != 1 {
+		t.Errorf("source targets mutated: %v", ingress.targets[0].Tags)
+	}
+}
+
+func TestDiscover_FailingSourceKeepsLastTargets(t *testing.T) {
+	src := &stubSource{name: "service", targets: []config.CertificateConfig{
+		{Hostname: "lb.example.com", Port: 443},
+	}}
+	d := New([]Source{src}, zap.NewNop())
+
+	if targets, _ := d.Discover(context.Background(), nil); len(targets) != 1 {
+		t.Fatalf("len(targets) = %d, want 1", len(targets))
+	}
+
+	src.targets = nil
+	src.err = errors.New("api unavailable")
+	targets, results := d.Discover(context.Background(), nil)
+	if len(targets) != 1 || targets[0].Hostname != "lb.example.com" {
+		t.Errorf("targets = %v, want last known lb.example.com", targets)
+	}
+	if results[0].Err == nil {
+		t.Error("expected source error in results")
+	}
+}
@@ -0,0 +1,333 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// HostnameAnnotation overrides the hostname scanned for a LoadBalancer Service
+const HostnameAnnotation = "certwatch.app/hostname"
+
+// kubeSource holds the list options shared by all Kubernetes sources
+// Fields are ordered for optimal memory alignment
+type kubeSource struct {
+	reader     client.Reader
+	selector   labels.Selector
+	logger     *zap.Logger
+	namespaces []string
+	tags       []string
+}
+
+// NewKubernetesClient creates an uncached client for discovery. An empty kubeconfig
+// uses $KUBECONFIG, ~/.kube/config or the in-cluster service account.
+func NewKubernetesClient(kubeconfig string) (client.Reader, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := gatewayv1.Install(scheme); err != nil {
+		return nil, err
+	}
+
+	c, err := client.New(restCfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return c, nil
+}
+
+// NewKubernetesSources creates the Kubernetes sources enabled in cfg
+func NewKubernetesSources(reader client.Reader, cfg *config.KubernetesDiscoveryConfig, logger *zap.Logger) ([]Source, error) {
+	selector := labels.Everything()
+	if cfg.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(cfg.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubernetes label_selector: %w", err)
+		}
+	}
+
+	base := kubeSource{
+		reader:     reader,
+		selector:   selector,
+		logger:     logger,
+		namespaces: cfg.Namespaces,
+		tags:       cfg.Tags,
+	}
+
+	sources := make([]Source, 0, len(cfg.Sources))
+	for _, name := range cfg.Sources {
+		switch name {
+		case config.DiscoverIngress:
+			sources = append(sources, &IngressSource{base})
+		case config.DiscoverGateway:
+			sources = append(sources, &GatewaySource{base})
+		case config.DiscoverService:
+			sources = append(sources, &ServiceSource{base})
+		default:
+			return nil, fmt.Errorf("unknown kubernetes discovery source '%s'", name)
+		}
+	}
+	return sources, nil
+}
+
+// list fills list from every configured namespace (or all namespaces), calling fn after each page
+func (k *kubeSource) list(ctx context.Context, list client.ObjectList, fn func()) error {
+	namespaces := k.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	for _, ns := range namespaces {
+		opts := []client.ListOption{client.MatchingLabelsSelector{Selector: k.selector}}
+		if ns != "" {
+			opts = append(opts, client.InNamespace(ns))
+		}
+		if err := k.reader.List(ctx, list, opts...); err != nil {
+			return err
+		}
+		fn()
+	}
+	return nil
+}
+
+func (k *kubeSource) target(hostname string, port int) config.CertificateConfig {
+	return config.CertificateConfig{
+		Hostname: strings.ToLower(hostname),
+		Port:     port,
+		Tags:     k.tags,
+	}
+}
+
+// IngressSource discovers hosts from the TLS section of Ingress resources
+type IngressSource struct {
+	kubeSource
+}
+
+// Name returns the source name
+func (s *IngressSource) Name() string {
+	return config.DiscoverIngress
+}
+
+// Discover returns one target per non-wildcard Ingress TLS host on port 443
+func (s *IngressSource) Discover(ctx context.Context) ([]config.CertificateConfig, error) {
+	var targets []config.CertificateConfig
+	var list networkingv1.IngressList
+	err := s.list(ctx, &list, func() {
+		for i := range list.Items {
+			for _, tls := range list.Items[i].Spec.TLS {
+				for _, host := range tls.Hosts {
+					if isConcreteHost(host) {
+						targets = append(targets, s.target(host, 443))
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	return targets, nil
+}
+
+// GatewaySource discovers hosts from Gateway API HTTPS/TLS listeners and the
+// HTTPRoutes attached to them
+type GatewaySource struct {
+	kubeSource
+}
+
+// Name returns the source name
+func (s *GatewaySource) Name() string {
+	return config.DiscoverGateway
+}
+
+// Discover returns listener hostnames, plus route hostnames for listeners that
+// use a wildcard or no hostname. Returns no targets if the Gateway API CRDs are
+// not installed.
+func (s *GatewaySource) Discover(ctx context.Context) ([]config.CertificateConfig, error) {
+	var targets []config.CertificateConfig
+
+	// Secure listeners by gateway namespace/name
+	listeners := make(map[string][]gatewayv1.Listener)
+
+	var gateways gatewayv1.GatewayList
+	err := s.list(ctx, &gateways, func() {
+		for i := range gateways.Items {
+			gw := &gateways.Items[i]
+			for _, l := range gw.Spec.Listeners {
+				if l.Protocol != gatewayv1.HTTPSProtocolType && l.Protocol != gatewayv1.TLSProtocolType {
+					continue
+				}
+				key := gw.Namespace + "/" + gw.Name
+				listeners[key] = append(listeners[key], l)
+				if l.Hostname != nil && isConcreteHost(string(*l.Hostname)) {
+					targets = append(targets, s.target(string(*l.Hostname), int(l.Port)))
+				}
+			}
+		}
+	})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			s.logger.Debug("gateway API not installed, skipping gateway discovery")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list gateways: %w", err)
+	}
+	if len(listeners) == 0 {
+		return targets, nil
+	}
+
+	var routes gatewayv1.HTTPRouteList
+	err = s.list(ctx, &routes, func() {
+		for i := range routes.Items {
+			targets = append(targets, s.routeTargets(&routes.Items[i], listeners)...)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list httproutes: %w", err)
+	}
+	return targets, nil
+}
+
+// routeTargets matches an HTTPRoute's hostnames against the secure listeners of its parent gateways
+func (s *GatewaySource) routeTargets(route *gatewayv1.HTTPRoute, listeners map[string][]gatewayv1.Listener) []config.CertificateConfig {
+	var targets []config.CertificateConfig
+	for _, ref := range route.Spec.ParentRefs {
+		if ref.Kind != nil && *ref.Kind != "Gateway" {
+			continue
+		}
+		ns := route.Namespace
+		if ref.Namespace != nil {
+			ns = string(*ref.Namespace)
+		}
+
+		for _, l := range listeners[ns+"/"+string(ref.Name)] {
+			if ref.SectionName != nil && *ref.SectionName != l.Name {
+				continue
+			}
+			if ref.Port != nil && *ref.Port != l.Port {
+				continue
+			}
+			// Concrete listener hostnames were already added from the gateway
+			if l.Hostname != nil && isConcreteHost(string(*l.Hostname)) {
+				continue
+			}
+			for _, h := range route.Spec.Hostnames {
+				host := string(h)
+				if isConcreteHost(host) && listenerMatches(l.Hostname, host) {
+					targets = append(targets, s.target(host, int(l.Port)))
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// listenerMatches reports whether a route hostname is served by a listener hostname
+func listenerMatches(listener *gatewayv1.Hostname, host string) bool {
+	if listener == nil || *listener == "" {
+		return true
+	}
+	l := strings.ToLower(string(*listener))
+	host = strings.ToLower(host)
+	if strings.HasPrefix(l, "*.") {
+		return strings.HasSuffix(host, l[1:])
+	}
+	return l == host
+}
+
+// ServiceSource discovers LoadBalancer Services exposing TLS ports
+type ServiceSource struct {
+	kubeSource
+}
+
+// Name returns the source name
+func (s *ServiceSource) Name() string {
+	return config.DiscoverService
+}
+
+// Discover returns a target per load balancer address and TLS port. The hostname
+// comes from the certwatch.app/hostname annotation when set, otherwise from the
+// load balancer status. TLS ports are port 443 or ports named or app-protocol
+// "https"/"tls".
+func (s *ServiceSource) Discover(ctx context.Context) ([]config.CertificateConfig, error) {
+	var targets []config.CertificateConfig
+	var list corev1.ServiceList
+	err := s.list(ctx, &list, func() {
+		for i := range list.Items {
+			svc := &list.Items[i]
+			if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+				continue
+			}
+
+			hosts := serviceHosts(svc)
+			for _, p := range svc.Spec.Ports {
+				if !isTLSPort(p) {
+					continue
+				}
+				for _, host := range hosts {
+					targets = append(targets, s.target(host, int(p.Port)))
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return targets, nil
+}
+
+func serviceHosts(svc *corev1.Service) []string {
+	if host := svc.Annotations[HostnameAnnotation]; host != "" {
+		return []string{host}
+	}
+	var hosts []string
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		switch {
+		case ing.Hostname != "":
+			hosts = append(hosts, ing.Hostname)
+		case ing.IP != "":
+			hosts = append(hosts, ing.IP)
+		}
+	}
+	return hosts
+}
+
+func isTLSPort(p corev1.ServicePort) bool {
+	if p.Port == 443 {
+		return true
+	}
+	if p.AppProtocol != nil {
+		proto := strings.ToLower(*p.AppProtocol)
+		if proto == "https" || proto == "tls" {
+			return true
+		}
+	}
+	name := strings.ToLower(p.Name)
+	return name == "https" || name == "tls" || strings.HasPrefix(name, "https-") || strings.HasPrefix(name, "tls-")
+}
+
+// isConcreteHost reports whether host is a non-empty, non-wildcard hostname
+func isConcreteHost(host string) bool {
+	return host != "" && !strings.Contains(host, "*")
+}
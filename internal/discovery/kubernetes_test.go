@@ -0,0 +1,179 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+func newFakeReader(t *testing.T, objs ...client.Object) client.Reader {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	if err := gatewayv1.Install(s); err != nil {
+		t.Fatalf("failed to build scheme: %v", err)
+	}
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+}
+
+func discoverAll(t *testing.T, reader client.Reader, cfg *config.KubernetesDiscoveryConfig) []string {
+	t.Helper()
+
+	sources, err := NewKubernetesSources(reader, cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewKubernetesSources() error = %v", err)
+	}
+
+	var got []string
+	for _, src := range sources {
+		targets, err := src.Discover(context.Background())
+		if err != nil {
+			t.Fatalf("%s Discover() error = %v", src.Name(), err)
+		}
+		for _, tgt := range targets {
+			got = append(got, tgt.GetHostPort())
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+func assertTargets(t *testing.T, got, want []string) {
+	t.Helper()
+
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("targets = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("targets = %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func TestIngressSource(t *testing.T) {
+	reader := newFakeReader(t,
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "site", Labels: map[string]string{"team": "web"}},
+			Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"www.example.com", "*.example.com"}},
+				{Hosts: []string{"Shop.example.com"}},
+			}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "site"},
+			Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"other.example.com"}},
+			}},
+		},
+	)
+
+	got := discoverAll(t, reader, &config.KubernetesDiscoveryConfig{
+		Sources:    []string{config.DiscoverIngress},
+		Namespaces: []string{"web"},
+	})
+	assertTargets(t, got, []string{"www.example.com:443", "shop.example.com:443"})
+
+	got = discoverAll(t, reader, &config.KubernetesDiscoveryConfig{
+		Sources:       []string{config.DiscoverIngress},
+		LabelSelector: "team=web",
+	})
+	assertTargets(t, got, []string{"www.example.com:443", "shop.example.com:443"})
+}
+
+func TestGatewaySource(t *testing.T) {
+	wildcard := gatewayv1.Hostname("*.apps.example.com")
+	concrete := gatewayv1.Hostname("gw.example.com")
+	section := gatewayv1.SectionName("https-apps")
+
+	reader := newFakeReader(t,
+		&gatewayv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "infra", Name: "public"},
+			Spec: gatewayv1.GatewaySpec{
+				GatewayClassName: "example",
+				Listeners: []gatewayv1.Listener{
+					{Name: "http", Protocol: gatewayv1.HTTPProtocolType, Port: 80},
+					{Name: "https-gw", Protocol: gatewayv1.HTTPSProtocolType, Port: 443, Hostname: &concrete},
+					{Name: "https-apps", Protocol: gatewayv1.HTTPSProtocolType, Port: 8443, Hostname: &wildcard},
+				},
+			},
+		},
+		&gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "shop"},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{
+					Name:        "public",
+					Namespace:   ptrTo(gatewayv1.Namespace("infra")),
+					SectionName: &section,
+				}}},
+				Hostnames: []gatewayv1.Hostname{"shop.apps.example.com", "shop.example.org"},
+			},
+		},
+	)
+
+	got := discoverAll(t, reader, &config.KubernetesDiscoveryConfig{Sources: []string{config.DiscoverGateway}})
+	assertTargets(t, got, []string{"gw.example.com:443", "shop.apps.example.com:8443"})
+}
+
+func TestServiceSource(t *testing.T) {
+	reader := newFakeReader(t,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "lb"},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{
+					{Name: "http", Port: 80},
+					{Name: "https-alt", Port: 8443},
+				},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.elb.example.com"}, {IP: "192.0.2.5"}},
+			}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "annotated",
+				Annotations: map[string]string{HostnameAnnotation: "api.example.com"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Name: "web", Port: 443}},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.6"}},
+			}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "internal"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Port: 443}},
+			},
+		},
+	)
+
+	got := discoverAll(t, reader, &config.KubernetesDiscoveryConfig{Sources: []string{config.DiscoverService}})
+	assertTargets(t, got, []string{"lb.elb.example.com:8443", "192.0.2.5:8443", "api.example.com:443"})
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
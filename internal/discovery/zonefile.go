@@ -0,0 +1,256 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// SourceZoneFile is the name of the BIND zone file source
+const SourceZoneFile = "zonefile"
+
+// zoneHostTypes are the record types whose owners are treated as TLS hosts
+var zoneHostTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+}
+
+// zoneClasses are the DNS classes that may appear before or after the TTL
+var zoneClasses = map[string]bool{
+	"IN": true,
+	"CH": true,
+	"HS": true,
+	"CS": true,
+}
+
+// ZoneFileSource discovers hostnames from the A, AAAA and CNAME records of a BIND zone file
+type ZoneFileSource struct {
+	cfg config.ZoneFileConfig
+}
+
+// NewZoneFileSource creates a source for a single zone file
+func NewZoneFileSource(cfg config.ZoneFileConfig) *ZoneFileSource {
+	return &ZoneFileSource{cfg: cfg}
+}
+
+// Name returns the source name, unique per zone file
+func (s *ZoneFileSource) Name() string {
+	return SourceZoneFile + ":" + s.cfg.Path
+}
+
+// Discover parses the zone file and returns one target per host
+func (s *ZoneFileSource) Discover(_ context.Context) ([]config.CertificateConfig, error) {
+	f, err := os.Open(s.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zone file: %w", err)
+	}
+	defer f.Close()
+
+	hosts, err := parseZone(f, s.cfg.Origin)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.cfg.Path, err)
+	}
+
+	port := s.cfg.Port
+	if port == 0 {
+		port = 443
+	}
+
+	targets := make([]config.CertificateConfig, 0, len(hosts))
+	for _, host := range hosts {
+		if s.excluded(host) {
+			continue
+		}
+		targets = append(targets, config.CertificateConfig{
+			Hostname: host,
+			Port:     port,
+			Tags:     s.cfg.Tags,
+		})
+	}
+	return targets, nil
+}
+
+func (s *ZoneFileSource) excluded(host string) bool {
+	for _, pattern := range s.cfg.Exclude {
+		if ok, _ := path.Match(pattern, host); ok { //nolint:errcheck // patterns are validated in config
+			return true
+		}
+	}
+	return false
+}
+
+// parseZone returns the distinct owner names of A, AAAA and CNAME records in a BIND
+// zone file, in order of appearance. Wildcard owners and names with underscore labels
+// (e.g. _acme-challenge) are skipped since they are not TLS hosts.
+func parseZone(r io.Reader, origin string) ([]string, error) {
+	origin = strings.TrimSuffix(strings.ToLower(origin), ".")
+
+	var hosts []string
+	seen := make(map[string]bool)
+	owner := ""
+
+	lines, err := zoneLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "$ORIGIN":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("$ORIGIN requires a domain name")
+			}
+			name, err := absoluteName(fields[1], origin)
+			if err != nil {
+				return nil, err
+			}
+			origin = name
+			continue
+		case "$TTL":
+			continue
+		case "$INCLUDE", "$GENERATE":
+			return nil, fmt.Errorf("%s is not supported", fields[0])
+		}
+
+		// A line starting with whitespace reuses the previous owner
+		if line[0] != ' ' && line[0] != '\t' {
+			name, err := absoluteName(fields[0], origin)
+			if err != nil {
+				return nil, err
+			}
+			owner = name
+			fields = fields[1:]
+		}
+
+		rrType := recordType(fields)
+		if !zoneHostTypes[rrType] || owner == "" || !isHostName(owner) || seen[owner] {
+			continue
+		}
+		seen[owner] = true
+		hosts = append(hosts, owner)
+	}
+
+	return hosts, nil
+}
+
+// zoneLines strips comments and joins parenthesized records into single logical lines
+func zoneLines(r io.Reader) ([]string, error) {
+	var lines []string
+	var current strings.Builder
+	depth := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+
+		for _, c := range line {
+			switch c {
+			case '(':
+				depth++
+				current.WriteRune(' ')
+			case ')':
+				depth--
+				current.WriteRune(' ')
+			default:
+				current.WriteRune(c)
+			}
+		}
+		if depth < 0 {
+			return nil, fmt.Errorf("unbalanced parentheses")
+		}
+		if depth == 0 {
+			lines = append(lines, current.String())
+			current.Reset()
+		} else {
+			current.WriteRune(' ')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return lines, nil
+}
+
+// stripComment removes a trailing ';' comment, ignoring semicolons inside quotes
+func stripComment(line string) string {
+	inQuote := false
+	for i, c := range line {
+		switch c {
+		case '"':
+			inQuote = !inQuote
+		case ';':
+			if !inQuote {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// recordType skips the optional TTL and class (in either order) and returns the type
+func recordType(fields []string) string {
+	for _, f := range fields {
+		upper := strings.ToUpper(f)
+		if zoneClasses[upper] || isTTL(f) {
+			continue
+		}
+		return upper
+	}
+	return ""
+}
+
+// isTTL reports whether f is a TTL in seconds or BIND duration form (e.g. 1h30m)
+func isTTL(f string) bool {
+	if f == "" || f[0] < '0' || f[0] > '9' {
+		return false
+	}
+	for _, c := range strings.ToLower(f) {
+		if (c < '0' || c > '9') && !strings.ContainsRune("smhdw", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// absoluteName resolves a zone file owner name against origin
+func absoluteName(name, origin string) (string, error) {
+	name = strings.ToLower(name)
+	switch {
+	case name == "@":
+		if origin == "" {
+			return "", fmt.Errorf("'@' used without an origin")
+		}
+		return origin, nil
+	case strings.HasSuffix(name, "."):
+		return strings.TrimSuffix(name, "."), nil
+	case origin == "":
+		return "", fmt.Errorf("relative name '%s' used without an origin", name)
+	default:
+		return name + "." + origin, nil
+	}
+}
+
+// isHostName reports whether name can carry a TLS certificate hostname
+func isHostName(name string) bool {
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || label == "*" || strings.HasPrefix(label, "_") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+const testZone = `$ORIGIN example.com.
+$TTL 3600
+@	IN	SOA	ns1.example.com. hostmaster.example.com. (
+		2024010101 ; serial
+		7200       ; refresh
+		3600       ; retry
+		1209600    ; expire
+		3600 )     ; minimum
+	IN	NS	ns1.example.com.
+	IN	A	192.0.2.1
+www	300	IN	A	192.0.2.10
+	IN	AAAA	2001:db8::10
+api	IN	300	CNAME	www
+mail	IN	MX	10 mx.example.com.
+mx	IN	A	192.0.2.20
+*	IN	A	192.0.2.99
+_acme-challenge	IN	TXT	"token;with;semicolons"
+_dmarc	IN	CNAME	dmarc.example.net.
+internal	IN	A	10.0.0.1
+legacy.example.org.	IN	A	192.0.2.30
+
+$ORIGIN dev.example.com.
+app	IN	A	192.0.2.40
+`
+
+func TestParseZone(t *testing.T) {
+	hosts, err := parseZone(strings.NewReader(testZone), "")
+	if err != nil {
+		t.Fatalf("parseZone() error = %v", err)
+	}
+
+	want := []string{
+		"example.com",
+		"www.example.com",
+		"api.example.com",
+		"mx.example.com",
+		"internal.example.com",
+		"legacy.example.org",
+		"app.dev.example.com",
+	}
+	if !slices.Equal(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+}
+
+func TestParseZone_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		zone string
+	}{
+		{"relative without origin", "www IN A 192.0.2.1\n"},
+		{"unbalanced", "@ IN SOA ns. host. ( 1 2 3\n"},
+		{"include", "$INCLUDE other.zone\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseZone(strings.NewReader(tt.zone), ""); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestZoneFileSource_Discover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.example.com")
+	zone := "www IN A 192.0.2.1\ninternal IN A 10.0.0.1\n"
+	if err := os.WriteFile(path, []byte(zone), 0o600); err != nil {
+		t.Fatalf("failed to write zone: %v", err)
+	}
+
+	src := NewZoneFileSource(config.ZoneFileConfig{
+		Path:    path,
+		Origin:  "example.com",
+		Exclude: []string{"internal.*"},
+		Tags:    []string{"dns"},
+		Port:    8443,
+	})
+
+	if src.Name() != "zonefile:"+path {
+		t.Errorf("Name() = %q, want zonefile:%s", src.Name(), path)
+	}
+
+	targets, err := src.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("len(targets) = %d, want 1", len(targets))
+	}
+	if targets[0].Hostname != "www.example.com" || targets[0].Port != 8443 {
+		t.Errorf("target = %s:%d, want www.example.com:8443", targets[0].Hostname, targets[0].Port)
+	}
+	if !slices.Equal(targets[0].Tags, []string{"dns"}) {
+		t.Errorf("Tags = %v, want [dns]", targets[0].Tags)
+	}
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+// Certificate change kinds, shared by history events and the change events the
+// agent syncs to the API
+const (
+	ChangeRenewed             = "renewed"               // New certificate expiring later
+	ChangeValidityDowngraded  = "validity_downgraded"   // New certificate expiring at the same time or earlier
+	ChangeIssuerChanged       = "issuer_changed"        // New certificate from another issuer
+	ChangeKeyAlgorithmChanged = "key_algorithm_changed" // Key algorithm or size changed
+	ChangeSANsAdded           = "sans_added"
+	ChangeSANsRemoved         = "sans_removed"
+)
+
+// Certificate is what CompareCertificates looks at. Empty KeyType and SANs are
+// not compared.
+// Fields are ordered for optimal memory alignment
+type Certificate struct {
+	NotAfter          time.Time
+	FingerprintSHA256 string
+	Issuer            string
+	KeyType           string // e.g. "RSA 2048"
+	SANs              []string
+}
+
+// CertificateOf returns the comparable fields of a scanned certificate
+func CertificateOf(c *scanner.CertificateInfo) Certificate {
+	keyType := c.KeyAlgorithm
+	if c.KeySize != 0 {
+		keyType = fmt.Sprintf("%s %d", c.KeyAlgorithm, c.KeySize)
+	}
+	return Certificate{
+		NotAfter:          c.NotAfter,
+		FingerprintSHA256: c.FingerprintSHA256,
+		Issuer:            c.Issuer,
+		KeyType:           keyType,
+		SANs:              c.SANList,
+	}
+}
+
+// Change is one way a certificate differs from the one served before it
+type Change struct {
+	Kind       string
+	Message    string
+	Previous   string // Fingerprint, expiry, issuer, key type or names before the change
+	Current    string // The same after the change
+	Regression bool
+}
+
+// CompareCertificates describes how cur differs from prev. Certificates with
+// the same fingerprint are unchanged.
+func CompareCertificates(prev, cur Certificate) []Change {
+	if prev.FingerprintSHA256 == cur.FingerprintSHA256 {
+		return nil
+	}
+
+	var changes []Change
+	change := func(kind, previous, current string, regression bool, format string, args ...any) {
+		changes = append(changes, Change{
+			Kind: kind, Previous: previous, Current: current, Regression: regression,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if cur.NotAfter.After(prev.NotAfter) {
+		change(ChangeRenewed, prev.FingerprintSHA256, cur.FingerprintSHA256, false,
+			"Certificate renewed, now expires %s (was %s)", date(cur.NotAfter), date(prev.NotAfter))
+	} else {
+		change(ChangeValidityDowngraded, prev.NotAfter.UTC().Format(time.RFC3339), cur.NotAfter.UTC().Format(time.RFC3339), true,
+			"Certificate replaced by one expiring %s, no later than the previous %s", date(cur.NotAfter), date(prev.NotAfter))
+	}
+	if prev.Issuer != cur.Issuer {
+		change(ChangeIssuerChanged, prev.Issuer, cur.Issuer, false,
+			"Issuer changed from %s to %s", prev.Issuer, cur.Issuer)
+	}
+	if prev.KeyType != cur.KeyType {
+		change(ChangeKeyAlgorithmChanged, prev.KeyType, cur.KeyType, false,
+			"Key changed from %s to %s", prev.KeyType, cur.KeyType)
+	}
+	if added := difference(cur.SANs, prev.SANs); len(added) > 0 {
+		change(ChangeSANsAdded, "", strings.Join(added, ","), false,
+			"Names added: %s", strings.Join(added, ", "))
+	}
+	if removed := difference(prev.SANs, cur.SANs); len(removed) > 0 {
+		change(ChangeSANsRemoved, strings.Join(removed, ","), "", false,
+			"Names removed: %s", strings.Join(removed, ", "))
+	}
+	return changes
+}
+
+// difference returns the elements of a that are not in b
+func difference(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func date(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event kinds detected between consecutive observations of a target. A new
+// certificate is reported with the Change kinds of CompareCertificates.
+const (
+	EventFirstSeen      = "first_seen"
+	EventScanFailed     = "scan_failed"     // Target could no longer be scanned
+	EventScanRecovered  = "scan_recovered"  // Target could be scanned again
+	EventChainInvalid   = "chain_invalid"   // Chain stopped validating
+	EventChainValid     = "chain_valid"     // Chain validates again
+	EventIssuesAdded    = "issues_added"    // New chain or TLS issues
+	EventIssuesResolved = "issues_resolved" // Chain or TLS issues went away
+)
+
+// Event is a change between two observations of a target. Regressions are
+// changes for the worse: failing scans, an invalid chain, new issues or a
+// replacement certificate that expires no later than the one it replaced.
+// Fields are ordered for optimal memory alignment
+type Event struct {
+	Time       time.Time `json:"time"`
+	Target     string    `json:"target"`
+	Kind       string    `json:"kind"`
+	Detail     string    `json:"detail"`
+	Port       int       `json:"port"`
+	Regression bool      `json:"regression"`
+}
+
+// Events returns the changes between consecutive observations of each target.
+// observations must be ordered by target, port and time, as Observations returns
+// them. Certificates are compared with the last successful scan, so a renewal
+// during an outage is still detected.
+func Events(observations []Observation) []Event {
+	var events []Event
+	var prev, lastCert *Observation
+
+	for i := range observations {
+		o := &observations[i]
+		if prev == nil || prev.Target != o.Target || prev.Port != o.Port {
+			prev, lastCert = nil, nil
+			events = append(events, newEvent(o, EventFirstSeen, describe(o), false))
+		}
+
+		if prev != nil {
+			events = append(events, stateEvents(prev, o)...)
+		}
+		if o.Success && o.FingerprintSHA256 != "" {
+			if lastCert != nil && lastCert.FingerprintSHA256 != o.FingerprintSHA256 {
+				events = append(events, certificateEvents(lastCert, o)...)
+			}
+			lastCert = o
+		}
+		prev = o
+	}
+	return events
+}
+
+// stateEvents compares the scan outcome, chain validity and issues
+func stateEvents(prev, o *Observation) []Event {
+	var events []Event
+	switch {
+	case prev.Success && !o.Success:
+		return []Event{newEvent(o, EventScanFailed, o.Error, true)}
+	case !prev.Success && o.Success:
+		events = append(events, newEvent(o, EventScanRecovered, "", false))
+	case !o.Success:
+		return nil
+	}
+
+	// Compare chain and issues with the previous successful state only
+	if !prev.Success {
+		return events
+	}
+	if prev.ChainValid && !o.ChainValid {
+		events = append(events, newEvent(o, EventChainInvalid, "", true))
+	} else if !prev.ChainValid && o.ChainValid {
+		events = append(events, newEvent(o, EventChainValid, "", false))
+	}
+	if added := difference(o.Issues, prev.Issues); len(added) > 0 {
+		events = append(events, newEvent(o, EventIssuesAdded, strings.Join(added, ", "), true))
+	}
+	if resolved := difference(prev.Issues, o.Issues); len(resolved) > 0 {
+		events = append(events, newEvent(o, EventIssuesResolved, strings.Join(resolved, ", "), false))
+	}
+	return events
+}
+
+// certificateEvents describes the replacement of old's certificate by o's
+func certificateEvents(old, o *Observation) []Event {
+	var events []Event
+	for _, c := range CompareCertificates(old.certificate(), o.certificate()) {
+		detail := fmt.Sprintf("%s → %s", c.Previous, c.Current)
+		if c.Kind == ChangeRenewed || c.Kind == ChangeValidityDowngraded {
+			detail = fmt.Sprintf("serial %s → %s, expires %s → %s",
+				short(old.SerialNumber), short(o.SerialNumber), date(old.NotAfter), date(o.NotAfter))
+		}
+		events = append(events, newEvent(o, c.Kind, detail, c.Regression))
+	}
+	return events
+}
+
+// certificate returns the fields of o compared by CompareCertificates
+func (o *Observation) certificate() Certificate {
+	return Certificate{NotAfter: o.NotAfter, FingerprintSHA256: o.FingerprintSHA256, Issuer: o.Issuer}
+}
+
+func newEvent(o *Observation, kind, detail string, regression bool) Event {
+	return Event{Time: o.FirstSeen, Target: o.Target, Port: o.Port, Kind: kind, Detail: detail, Regression: regression}
+}
+
+// describe summarizes the first observation of a target
+func describe(o *Observation) string {
+	if !o.Success {
+		return o.Error
+	}
+	return fmt.Sprintf("serial %s, expires %s, issuer %s", short(o.SerialNumber), date(o.NotAfter), o.Issuer)
+}
+
+// short abbreviates long serial numbers
+func short(serial string) string {
+	if len(serial) > 16 {
+		return serial[:16] + "…"
+	}
+	return serial
+}
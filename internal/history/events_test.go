@@ -0,0 +1,66 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	expiry := t0.Add(60 * 24 * time.Hour)
+	at := func(h int) time.Time { return t0.Add(time.Duration(h) * time.Hour) }
+	cert := func(h int, fingerprint string, notAfter time.Time, issuer string, issues ...string) Observation {
+		return Observation{
+			FirstSeen: at(h), LastSeen: at(h), Target: "example.com", Port: 443, Success: true,
+			FingerprintSHA256: fingerprint, SerialNumber: fingerprint, Issuer: issuer, NotAfter: notAfter,
+			ChainValid: len(issues) == 0, Issues: append([]string{}, issues...),
+		}
+	}
+	failed := Observation{FirstSeen: at(3), LastSeen: at(3), Target: "example.com", Port: 443, Error: "timeout"}
+
+	observations := []Observation{
+		cert(0, "aa", expiry, "CN=R3"),
+		cert(1, "aa", expiry, "CN=R3", "incomplete_chain"),
+		cert(2, "bb", expiry.Add(24*time.Hour), "CN=R3"),
+		failed,
+		cert(4, "cc", expiry, "CN=E1"),
+		cert(0, "dd", expiry, "CN=R3"), // Another port starts a new target
+	}
+	observations[5].Port = 8443
+
+	want := []struct {
+		kind       string
+		regression bool
+	}{
+		{EventFirstSeen, false},
+		{EventChainInvalid, true},
+		{EventIssuesAdded, true},
+		{EventChainValid, false},
+		{EventIssuesResolved, false},
+		{ChangeRenewed, false},
+		{EventScanFailed, true},
+		{EventScanRecovered, false},
+		{ChangeValidityDowngraded, true}, // Expires before the certificate served before the outage
+		{ChangeIssuerChanged, false},
+		{EventFirstSeen, false},
+	}
+
+	got := Events(observations)
+	if len(got) != len(want) {
+		for _, e := range got {
+			t.Logf("%s %s %s", e.Time, e.Kind, e.Detail)
+		}
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Regression != w.regression {
+			t.Errorf("event %d = %s (regression %v), want %s (regression %v)",
+				i, got[i].Kind, got[i].Regression, w.kind, w.regression)
+		}
+	}
+	if got[10].Port != 8443 {
+		t.Errorf("last event port = %d, want 8443", got[10].Port)
+	}
+	if got[9].Detail != "CN=R3 → CN=E1" {
+		t.Errorf("issuer_changed detail = %q", got[9].Detail)
+	}
+}
@@ -0,0 +1,316 @@
+// Package history keeps a local SQLite record of scan results, so certificate
+// rotations and regressions can be audited without the CertWatch API.
+//
+// Consecutive scans of a target with the same outcome are stored as a single
+// observation with the time of the first and last scan and the number of scans,
+// which keeps the database small at short scan intervals.
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver, registered as "sqlite"
+
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+// DefaultFileName is the database file created in the state directory
+const DefaultFileName = "history.db"
+
+// Path returns the configured database path, or DefaultFileName in stateDir
+func Path(configured, stateDir string) string {
+	if configured != "" {
+		return configured
+	}
+	return filepath.Join(stateDir, DefaultFileName)
+}
+
+// schemaVersion is stored in PRAGMA user_version
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS observations (
+	id          INTEGER PRIMARY KEY,
+	target      TEXT    NOT NULL,
+	port        INTEGER NOT NULL,
+	first_seen  INTEGER NOT NULL,
+	last_seen   INTEGER NOT NULL,
+	scans       INTEGER NOT NULL,
+	success     INTEGER NOT NULL,
+	error       TEXT    NOT NULL,
+	fingerprint TEXT    NOT NULL,
+	serial      TEXT    NOT NULL,
+	subject     TEXT    NOT NULL,
+	issuer      TEXT    NOT NULL,
+	not_before  INTEGER NOT NULL,
+	not_after   INTEGER NOT NULL,
+	chain_valid INTEGER NOT NULL,
+	issues      TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS observations_target ON observations (target, port, last_seen);
+CREATE INDEX IF NOT EXISTS observations_last_seen ON observations (last_seen);
+`
+
+// ErrNotFound is returned by Open with ReadOnly when the database does not exist
+var ErrNotFound = errors.New("no scan history recorded")
+
+// Observation is a run of consecutive scans of a target with the same outcome
+// Fields are ordered for optimal memory alignment
+type Observation struct {
+	FirstSeen         time.Time `json:"first_seen"`
+	LastSeen          time.Time `json:"last_seen"`
+	NotBefore         time.Time `json:"not_before,omitzero"`
+	NotAfter          time.Time `json:"not_after,omitzero"`
+	Target            string    `json:"target"` // Hostname, or server_name@hostname
+	Error             string    `json:"error,omitempty"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256,omitempty"`
+	SerialNumber      string    `json:"serial_number,omitempty"`
+	Subject           string    `json:"subject,omitempty"`
+	Issuer            string    `json:"issuer,omitempty"`
+	Issues            []string  `json:"issues"` // Chain and TLS issue types, sorted
+	Port              int       `json:"port"`
+	Scans             int       `json:"scans"`
+	Success           bool      `json:"success"`
+	ChainValid        bool      `json:"chain_valid"`
+}
+
+// sameOutcome reports whether o and other describe the same certificate and state
+func (o *Observation) sameOutcome(other *Observation) bool {
+	return o.Success == other.Success &&
+		o.Error == other.Error &&
+		o.FingerprintSHA256 == other.FingerprintSHA256 &&
+		o.ChainValid == other.ChainValid &&
+		slices.Equal(o.Issues, other.Issues)
+}
+
+// observe converts a scan result into a single-scan observation
+func observe(r *scanner.ScanResult, at time.Time) Observation {
+	o := Observation{
+		FirstSeen: at,
+		LastSeen:  at,
+		Target:    r.Name(),
+		Port:      r.Port,
+		Scans:     1,
+		Success:   r.Success,
+		Error:     r.Error,
+		Issues:    []string{},
+	}
+	if c := r.Certificate; c != nil {
+		o.FingerprintSHA256 = c.FingerprintSHA256
+		o.SerialNumber = c.SerialNumber
+		o.Subject = c.Subject
+		o.Issuer = c.Issuer
+		o.NotBefore = c.NotBefore
+		o.NotAfter = c.NotAfter
+	}
+	if r.Chain != nil {
+		o.ChainValid = r.Chain.Valid
+		for _, issue := range r.Chain.Issues {
+			o.Issues = append(o.Issues, issue.Type)
+		}
+	}
+	if r.TLS != nil {
+		for _, issue := range r.TLS.Issues {
+			o.Issues = append(o.Issues, issue.Type)
+		}
+	}
+	slices.Sort(o.Issues)
+	o.Issues = slices.Compact(o.Issues)
+	return o
+}
+
+// Store is the scan history database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it unless readOnly is set
+func Open(path string, readOnly bool) (*Store, error) {
+	if readOnly {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w at %s", ErrNotFound, path)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// WAL lets the history command read while the agent writes
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	if readOnly {
+		dsn += "&mode=ro"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	// SQLite allows one writer; a single connection avoids SQLITE_BUSY between them
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if !readOnly {
+		if err := s.migrate(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// migrate creates the schema
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("history was written by a newer version (schema %d)", version)
+	}
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create history schema: %w", err)
+	}
+	if _, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return fmt.Errorf("failed to create history schema: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record adds the results of a scan at the given time. A result with the same
+// outcome as the target's latest observation extends it instead of adding one.
+func (s *Store) Record(ctx context.Context, at time.Time, results []scanner.ScanResult) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // No-op after Commit
+
+	for i := range results {
+		o := observe(&results[i], at)
+
+		var id int64
+		var latest Observation
+		row := tx.QueryRowContext(ctx, `SELECT id, `+columns+` FROM observations
+			WHERE target = ? AND port = ? ORDER BY last_seen DESC, id DESC LIMIT 1`, o.Target, o.Port)
+		err := scanObservation(row, &id, &latest)
+		switch {
+		case err == nil && latest.sameOutcome(&o):
+			_, err = tx.ExecContext(ctx, `UPDATE observations SET last_seen = ?, scans = scans + 1 WHERE id = ?`,
+				at.Unix(), id)
+		case err == nil || errors.Is(err, sql.ErrNoRows):
+			_, err = tx.ExecContext(ctx, `INSERT INTO observations (`+columns+`)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				o.Target, o.Port, o.FirstSeen.Unix(), o.LastSeen.Unix(), o.Scans, o.Success, o.Error,
+				o.FingerprintSHA256, o.SerialNumber, o.Subject, o.Issuer, unix(o.NotBefore), unix(o.NotAfter),
+				o.ChainValid, strings.Join(o.Issues, ","))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes observations last seen before cutoff and returns how many
+func (s *Store) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM observations WHERE last_seen < ?`, cutoff.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Filter selects observations. Zero values match everything.
+type Filter struct {
+	Since  time.Time // Observations last seen at or after Since
+	Target string
+	Port   int
+}
+
+// Observations returns the matching observations ordered by target, port and time
+func (s *Store) Observations(ctx context.Context, f Filter) ([]Observation, error) {
+	query := `SELECT id, ` + columns + ` FROM observations WHERE last_seen >= ?`
+	args := []any{f.Since.Unix()}
+	if f.Target != "" {
+		query += ` AND target = ?`
+		args = append(args, f.Target)
+	}
+	if f.Port != 0 {
+		query += ` AND port = ?`
+		args = append(args, f.Port)
+	}
+	query += ` ORDER BY target, port, first_seen, id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Observation
+	for rows.Next() {
+		var id int64
+		var o Observation
+		if err := scanObservation(rows, &id, &o); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// columns lists the observation columns in the order read by scanObservation
+const columns = `target, port, first_seen, last_seen, scans, success, error, fingerprint, serial,
+	subject, issuer, not_before, not_after, chain_valid, issues`
+
+// scanObservation reads a row of id followed by columns
+func scanObservation(row interface{ Scan(...any) error }, id *int64, o *Observation) error {
+	var firstSeen, lastSeen, notBefore, notAfter int64
+	var issues string
+	err := row.Scan(id, &o.Target, &o.Port, &firstSeen, &lastSeen, &o.Scans, &o.Success, &o.Error,
+		&o.FingerprintSHA256, &o.SerialNumber, &o.Subject, &o.Issuer, &notBefore, &notAfter,
+		&o.ChainValid, &issues)
+	if err != nil {
+		return err
+	}
+
+	o.FirstSeen = time.Unix(firstSeen, 0).UTC()
+	o.LastSeen = time.Unix(lastSeen, 0).UTC()
+	o.NotBefore = fromUnix(notBefore)
+	o.NotAfter = fromUnix(notAfter)
+	o.Issues = []string{}
+	if issues != "" {
+		o.Issues = strings.Split(issues, ",")
+	}
+	return nil
+}
+
+// unix returns t as Unix seconds, 0 for the zero time
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
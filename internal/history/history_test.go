@@ -0,0 +1,166 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+)
+
+var t0 = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func served(hostname, fingerprint string, notAfter time.Time, issues ...string) scanner.ScanResult {
+	r := scanner.ScanResult{
+		Hostname: hostname,
+		Port:     443,
+		Success:  true,
+		Certificate: &scanner.CertificateInfo{
+			FingerprintSHA256: fingerprint,
+			SerialNumber:      "serial-" + fingerprint,
+			Subject:           "CN=" + hostname,
+			Issuer:            "CN=Test CA",
+			NotBefore:         notAfter.Add(-90 * 24 * time.Hour),
+			NotAfter:          notAfter,
+		},
+		Chain: &scanner.ChainInfo{Valid: len(issues) == 0},
+	}
+	for _, issue := range issues {
+		r.Chain.Issues = append(r.Chain.Issues, scanner.ChainIssue{Type: issue})
+	}
+	return r
+}
+
+func openTemp(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	s, err := Open(path, false)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestRecordCollapsesIdenticalScans(t *testing.T) {
+	s, _ := openTemp(t)
+	ctx := context.Background()
+	expiry := t0.Add(60 * 24 * time.Hour)
+
+	scans := [][]scanner.ScanResult{
+		{served("a.example.com", "aa", expiry), served("b.example.com", "bb", expiry)},
+		{served("a.example.com", "aa", expiry), served("b.example.com", "bb", expiry)},
+		{served("a.example.com", "a2", expiry.Add(time.Hour)), {Hostname: "b.example.com", Port: 443, Error: "timeout"}},
+		{served("a.example.com", "a2", expiry.Add(time.Hour)), served("b.example.com", "bb", expiry)},
+	}
+	for i, results := range scans {
+		if err := s.Record(ctx, t0.Add(time.Duration(i)*time.Hour), results); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	got, err := s.Observations(ctx, Filter{})
+	if err != nil {
+		t.Fatalf("Observations() error = %v", err)
+	}
+	want := []struct {
+		target, fingerprint string
+		scans               int
+		success             bool
+	}{
+		{"a.example.com", "aa", 2, true},
+		{"a.example.com", "a2", 2, true},
+		{"b.example.com", "bb", 2, true},
+		{"b.example.com", "", 1, false},
+		{"b.example.com", "bb", 1, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d observations, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		o := got[i]
+		if o.Target != w.target || o.FingerprintSHA256 != w.fingerprint || o.Scans != w.scans || o.Success != w.success {
+			t.Errorf("observation %d = %s %s scans=%d success=%v, want %+v",
+				i, o.Target, o.FingerprintSHA256, o.Scans, o.Success, w)
+		}
+	}
+	if !got[0].FirstSeen.Equal(t0) || !got[0].LastSeen.Equal(t0.Add(time.Hour)) {
+		t.Errorf("first observation spans %s-%s, want %s-%s", got[0].FirstSeen, got[0].LastSeen, t0, t0.Add(time.Hour))
+	}
+	if !got[0].NotAfter.Equal(expiry) {
+		t.Errorf("NotAfter = %s, want %s", got[0].NotAfter, expiry)
+	}
+}
+
+func TestObservationsFilter(t *testing.T) {
+	s, _ := openTemp(t)
+	ctx := context.Background()
+	expiry := t0.Add(60 * 24 * time.Hour)
+
+	other := served("a.example.com", "cc", expiry)
+	other.Port = 8443
+	if err := s.Record(ctx, t0, []scanner.ScanResult{served("a.example.com", "aa", expiry), other}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record(ctx, t0.Add(48*time.Hour), []scanner.ScanResult{served("b.example.com", "bb", expiry)}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"all", Filter{}, 3},
+		{"target", Filter{Target: "a.example.com"}, 2},
+		{"target and port", Filter{Target: "a.example.com", Port: 8443}, 1},
+		{"since", Filter{Since: t0.Add(24 * time.Hour)}, 1},
+	}
+	for _, tt := range tests {
+		got, err := s.Observations(ctx, tt.filter)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("%s: Observations() = %d observations, %v; want %d", tt.name, len(got), err, tt.want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, _ := openTemp(t)
+	ctx := context.Background()
+	expiry := t0.Add(60 * 24 * time.Hour)
+
+	_ = s.Record(ctx, t0, []scanner.ScanResult{served("old.example.com", "aa", expiry)})
+	_ = s.Record(ctx, t0.Add(48*time.Hour), []scanner.ScanResult{served("new.example.com", "bb", expiry)})
+
+	pruned, err := s.Prune(ctx, t0.Add(24*time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune() = %d, %v, want 1", pruned, err)
+	}
+	got, _ := s.Observations(ctx, Filter{})
+	if len(got) != 1 || got[0].Target != "new.example.com" {
+		t.Errorf("Observations() after Prune = %+v, want only new.example.com", got)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), DefaultFileName)
+	if _, err := Open(missing, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() error = %v, want ErrNotFound", err)
+	}
+
+	// The history can be read while the agent has it open for writing
+	w, path := openTemp(t)
+	if err := w.Record(context.Background(), t0, []scanner.ScanResult{served("a.example.com", "aa", t0)}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(path, true)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer r.Close()
+	if got, err := r.Observations(context.Background(), Filter{}); err != nil || len(got) != 1 {
+		t.Errorf("Observations() = %d observations, %v; want 1", len(got), err)
+	}
+}
@@ -0,0 +1,168 @@
+// Package leader runs the standalone agent on one replica at a time using a
+// Kubernetes Lease. Standby replicas wait and take over when the leader stops
+// renewing the Lease.
+package leader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// ErrLost is returned by Run when the Lease is lost while the work is running.
+// The process should exit so that it restarts as a standby.
+var ErrLost = errors.New("leader election lost")
+
+// Elector runs work while this replica holds the Lease
+// Fields are ordered for optimal memory alignment
+type Elector struct {
+	client   kubernetes.Interface
+	logger   *zap.Logger
+	observer func(leading bool)
+	identity string
+	cfg      config.LeaderElectionConfig
+}
+
+// New creates an elector using $KUBECONFIG, ~/.kube/config or the in-cluster
+// service account
+func New(cfg *config.LeaderElectionConfig, logger *zap.Logger) (*Elector, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return NewWithClient(client, cfg, Identity(), logger), nil
+}
+
+// NewWithClient creates an elector that uses the given client and identity
+func NewWithClient(client kubernetes.Interface, cfg *config.LeaderElectionConfig, identity string, logger *zap.Logger) *Elector {
+	return &Elector{client: client, cfg: *cfg, identity: identity, logger: logger}
+}
+
+// SetObserver sets a function called when this replica gains or loses the Lease
+func (e *Elector) SetObserver(fn func(leading bool)) {
+	e.observer = fn
+}
+
+// Identity returns the hostname (the pod name in Kubernetes) with a random
+// suffix, so a restarted pod doesn't reuse the Lease of its previous run
+func Identity() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "cw-agent"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "_" + hex.EncodeToString(b)
+}
+
+// Run blocks until this replica holds the Lease, then runs work until it
+// returns or ctx is done, and releases the Lease. If the Lease is lost first,
+// work's context is cancelled and ErrLost is returned.
+func (e *Elector) Run(ctx context.Context, work func(context.Context) error) error {
+	electCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var workErr error
+	done := make(chan struct{})
+
+	lock := &trackingLock{Interface: &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: e.cfg.LeaseName, Namespace: e.cfg.Namespace()},
+		Client:     e.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: e.identity},
+	}}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            e.cfg.LeaseName,
+		LeaseDuration:   e.cfg.LeaseDuration,
+		RenewDeadline:   e.cfg.RenewDeadline,
+		RetryPeriod:     e.cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leadCtx context.Context) {
+				defer close(done)
+				e.logger.Info("acquired leader lease", zap.String("identity", e.identity))
+				e.notify(true)
+				workErr = work(leadCtx)
+				// Stop renewing and release the Lease
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				e.notify(false)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != e.identity {
+					e.logger.Info("waiting for leader lease", zap.String("leader", identity))
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid leader election config: %w", err)
+	}
+
+	e.logger.Info("starting leader election",
+		zap.String("lease", e.cfg.Namespace()+"/"+e.cfg.LeaseName),
+		zap.String("identity", e.identity),
+	)
+	elector.Run(electCtx)
+
+	if !lock.acquired.Load() {
+		return ctx.Err()
+	}
+	// Run returns once renewing stops; wait for work to finish
+	<-done
+
+	if ctx.Err() == nil && errors.Is(workErr, context.Canceled) {
+		return ErrLost
+	}
+	return workErr
+}
+
+func (e *Elector) notify(leading bool) {
+	if e.observer != nil {
+		e.observer(leading)
+	}
+}
+
+// trackingLock records whether this replica ever acquired the Lease, which
+// tells Run whether work was started
+type trackingLock struct {
+	resourcelock.Interface
+	acquired atomic.Bool
+}
+
+func (l *trackingLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Create(ctx, ler)
+	l.record(ler, err)
+	return err
+}
+
+func (l *trackingLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	err := l.Interface.Update(ctx, ler)
+	l.record(ler, err)
+	return err
+}
+
+func (l *trackingLock) record(ler resourcelock.LeaderElectionRecord, err error) {
+	if err == nil && ler.HolderIdentity == l.Identity() {
+		l.acquired.Store(true)
+	}
+}
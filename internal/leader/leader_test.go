@@ -0,0 +1,119 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+var testConfig = config.LeaderElectionConfig{
+	Enabled:        true,
+	LeaseName:      "cw-agent",
+	LeaseNamespace: "certwatch",
+	LeaseDuration:  time.Second,
+	RenewDeadline:  500 * time.Millisecond,
+	RetryPeriod:    100 * time.Millisecond,
+}
+
+func TestRunOneReplicaAtATime(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var running, overlaps atomic.Int32
+	work := func(release <-chan struct{}) func(context.Context) error {
+		return func(ctx context.Context) error {
+			if running.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			defer running.Add(-1)
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	first := NewWithClient(client, &testConfig, "first", zap.NewNop())
+	second := NewWithClient(client, &testConfig, "second", zap.NewNop())
+	var secondLeading atomic.Bool
+	second.SetObserver(func(leading bool) { secondLeading.Store(leading) })
+
+	releaseFirst, releaseSecond := make(chan struct{}), make(chan struct{})
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- first.Run(ctx, work(releaseFirst)) }()
+
+	// Wait for the first replica to lead before starting the standby
+	for running.Load() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	secondDone := make(chan error, 1)
+	go func() { secondDone <- second.Run(ctx, work(releaseSecond)) }()
+
+	time.Sleep(300 * time.Millisecond)
+	if secondLeading.Load() {
+		t.Fatal("standby acquired the lease while the leader holds it")
+	}
+
+	// The leader finishes and releases the lease; the standby takes over
+	close(releaseFirst)
+	if err := <-firstDone; err != nil {
+		t.Errorf("first Run() error = %v, want nil", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !secondLeading.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !secondLeading.Load() {
+		t.Fatal("standby did not take over the released lease")
+	}
+
+	close(releaseSecond)
+	if err := <-secondDone; err != nil {
+		t.Errorf("second Run() error = %v, want nil", err)
+	}
+	if overlaps.Load() != 0 {
+		t.Error("both replicas ran at the same time")
+	}
+}
+
+func TestRunStandbyStopsWithContext(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	leader := NewWithClient(client, &testConfig, "leader", zap.NewNop())
+	leading := make(chan struct{})
+	go func() {
+		_ = leader.Run(ctx, func(ctx context.Context) error {
+			close(leading)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-leading
+
+	standbyCtx, stopStandby := context.WithCancel(context.Background())
+	standbyDone := make(chan error, 1)
+	go func() {
+		standbyDone <- NewWithClient(client, &testConfig, "standby", zap.NewNop()).Run(standbyCtx, func(context.Context) error {
+			t.Error("standby ran its work")
+			return nil
+		})
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	stopStandby()
+	if err := <-standbyDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("standby Run() error = %v, want context.Canceled", err)
+	}
+	cancel()
+}
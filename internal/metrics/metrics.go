@@ -48,6 +48,57 @@ var (
 		[]string{"hostname", "port"},
 	)
 
+	CertAddressDaysUntilExpiry = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "certificate",
+			Name:      "address_days_until_expiry",
+			Help:      "Days until the certificate served by each resolved address expires",
+		},
+		[]string{"hostname", "port", "address"},
+	)
+
+	CertAddressUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "certificate",
+			Name:      "address_up",
+			Help:      "Whether each resolved address served a certificate (1=success, 0=failed)",
+		},
+		[]string{"hostname", "port", "address"},
+	)
+
+	CertChangesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "certificate",
+			Name:      "changes_total",
+			Help:      "Total number of changes to the served certificate by type",
+		},
+		[]string{"hostname", "port", "type"},
+	)
+
+	// TLS audit metrics
+	TLSProtocolSupported = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "tls",
+			Name:      "protocol_supported",
+			Help:      "Whether the endpoint accepts the TLS protocol version (1=accepted, 0=rejected)",
+		},
+		[]string{"hostname", "port", "version"},
+	)
+
+	TLSIssues = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "tls",
+			Name:      "issues",
+			Help:      "Number of TLS configuration issues by type",
+		},
+		[]string{"hostname", "port", "type"},
+	)
+
 	// Scan metrics
 	ScanTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -118,6 +169,48 @@ var (
 		},
 	)
 
+	SyncOutboxPending = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "sync",
+			Name:      "outbox_pending",
+			Help:      "Number of sync payloads queued for replay while the API is unreachable",
+		},
+	)
+
+	SyncRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "sync",
+			Name:      "retries_total",
+			Help:      "Total number of retried API calls",
+		},
+		[]string{"operation", "reason"}, // reason: "network", "rate_limited", "server_error", "timeout"
+	)
+
+	// SyncPayloadBytes tracks the size of API request payloads before compression
+	SyncPayloadBytes = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "certwatch",
+			Subsystem: "sync",
+			Name:      "payload_bytes",
+			Help:      "Size of API request payloads before compression",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 9), // 256B to 16MiB
+		},
+		[]string{"kind"},
+	)
+
+	// SyncSentBytesTotal tracks the bytes sent to the API after compression
+	SyncSentBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "sync",
+			Name:      "sent_bytes_total",
+			Help:      "Total request body bytes sent to the API after compression",
+		},
+		[]string{"kind"},
+	)
+
 	// Heartbeat metrics
 	HeartbeatTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -150,6 +243,15 @@ var (
 		[]string{"version", "name", "agent_id"},
 	)
 
+	Leader = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "agent",
+			Name:      "leader",
+			Help:      "Whether this replica holds the leader lease (1=leader, 0=standby)",
+		},
+	)
+
 	CertificatesConfigured = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "certwatch",
@@ -159,6 +261,48 @@ var (
 		},
 	)
 
+	ConfigReloadsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "agent",
+			Name:      "config_reloads_total",
+			Help:      "Total number of configuration reloads by status",
+		},
+		[]string{"status"}, // "success", "failure"
+	)
+
+	// Discovery metrics
+	DiscoveryTargets = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "certwatch",
+			Subsystem: "discovery",
+			Name:      "targets",
+			Help:      "Number of targets found by each discovery source",
+		},
+		[]string{"source"},
+	)
+
+	DiscoveryErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "discovery",
+			Name:      "errors_total",
+			Help:      "Total number of failed discovery runs per source",
+		},
+		[]string{"source"},
+	)
+
+	// Notification metrics
+	NotificationsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "certwatch",
+			Subsystem: "notify",
+			Name:      "notifications_total",
+			Help:      "Total number of alert deliveries by sink",
+		},
+		[]string{"sink", "status"}, // sink: "webhook", "slack", "email"; status: "success", "failure"
+	)
+
 	AgentUptime = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "certwatch",
@@ -187,6 +331,73 @@ func RecordCertificateMetrics(hostname, port string, daysUntilExpiry, expiryTime
 	}
 }
 
+// DeleteCertificateMetrics removes the certificate metrics of a target that is no longer monitored.
+func DeleteCertificateMetrics(hostname, port string) {
+	CertDaysUntilExpiry.DeleteLabelValues(hostname, port)
+	CertExpiryTimestamp.DeleteLabelValues(hostname, port)
+	CertValid.DeleteLabelValues(hostname, port)
+	CertChainValid.DeleteLabelValues(hostname, port)
+	CertAddressDaysUntilExpiry.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	CertAddressUp.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	CertChangesTotal.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	TLSProtocolSupported.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	TLSIssues.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+}
+
+// RecordAddressExpiry updates the per-address expiry of a target scanned on all
+// its addresses. Addresses that failed or no longer resolve are dropped.
+func RecordAddressExpiry(hostname, port string, daysUntilExpiry map[string]float64) {
+	CertAddressDaysUntilExpiry.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	for address, days := range daysUntilExpiry {
+		CertAddressDaysUntilExpiry.WithLabelValues(hostname, port, address).Set(days)
+	}
+}
+
+// RecordAddressUp records which addresses of a target scanned on all its
+// addresses succeeded. Addresses that no longer resolve are dropped.
+func RecordAddressUp(hostname, port string, up map[string]bool) {
+	CertAddressUp.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	for address, ok := range up {
+		value := 0.0
+		if ok {
+			value = 1
+		}
+		CertAddressUp.WithLabelValues(hostname, port, address).Set(value)
+	}
+}
+
+// RecordCertificateChange counts a change to the certificate served by a target.
+func RecordCertificateChange(hostname, port, changeType string) {
+	CertChangesTotal.WithLabelValues(hostname, port, changeType).Inc()
+}
+
+// RecordTLSAudit updates the TLS audit metrics for a single endpoint. versions
+// lists every probed version, supported the accepted ones.
+func RecordTLSAudit(hostname, port string, versions, supported, issueTypes []string) {
+	for _, v := range versions {
+		value := 0.0
+		for _, s := range supported {
+			if s == v {
+				value = 1
+			}
+		}
+		TLSProtocolSupported.WithLabelValues(hostname, port, v).Set(value)
+	}
+
+	// Issue types that went away must not keep their last count
+	TLSIssues.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	for _, t := range issueTypes {
+		TLSIssues.WithLabelValues(hostname, port, t).Inc()
+	}
+}
+
+// ClearTLSAudit removes the TLS audit metrics of an endpoint whose audit did not
+// complete, so a stale result isn't reported as current.
+func ClearTLSAudit(hostname, port string) {
+	TLSProtocolSupported.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+	TLSIssues.DeletePartialMatch(prometheus.Labels{"hostname": hostname, "port": port})
+}
+
 // RecordScanSuccess records a successful scan operation.
 func RecordScanSuccess(hostname string, duration float64) {
 	ScanTotal.WithLabelValues("success").Inc()
@@ -214,6 +425,22 @@ func RecordSyncFailure(duration float64) {
 	SyncDurationSeconds.Observe(duration)
 }
 
+// SetOutboxPending sets the number of sync payloads waiting in the outbox.
+func SetOutboxPending(count int) {
+	SyncOutboxPending.Set(float64(count))
+}
+
+// RecordSyncRetry records a retried API call.
+func RecordSyncRetry(operation, reason string) {
+	SyncRetriesTotal.WithLabelValues(operation, reason).Inc()
+}
+
+// RecordSyncPayload records the size of an API request before and after compression.
+func RecordSyncPayload(kind string, size, sent int) {
+	SyncPayloadBytes.WithLabelValues(kind).Observe(float64(size))
+	SyncSentBytesTotal.WithLabelValues(kind).Add(float64(sent))
+}
+
 // RecordHeartbeatSuccess records a successful heartbeat operation.
 func RecordHeartbeatSuccess(duration float64) {
 	HeartbeatTotal.WithLabelValues("success").Inc()
@@ -231,7 +458,42 @@ func SetAgentInfo(version, name, agentID string) {
 	AgentInfo.WithLabelValues(version, name, agentID).Set(1)
 }
 
+// SetLeader records whether this replica holds the leader lease.
+func SetLeader(leading bool) {
+	if leading {
+		Leader.Set(1)
+	} else {
+		Leader.Set(0)
+	}
+}
+
 // SetCertificatesConfigured sets the number of configured certificates.
 func SetCertificatesConfigured(count int) {
 	CertificatesConfigured.Set(float64(count))
 }
+
+// RecordConfigReload records a configuration reload attempt.
+func RecordConfigReload(success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	ConfigReloadsTotal.WithLabelValues(status).Inc()
+}
+
+// RecordNotification records an alert delivery attempt.
+func RecordNotification(sink string, success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	NotificationsTotal.WithLabelValues(sink, status).Inc()
+}
+
+// RecordDiscovery records the result of a discovery run for one source.
+func RecordDiscovery(source string, targets int, failed bool) {
+	DiscoveryTargets.WithLabelValues(source).Set(float64(targets))
+	if failed {
+		DiscoveryErrorsTotal.WithLabelValues(source).Inc()
+	}
+}
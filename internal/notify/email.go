@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// EmailSink sends alerts over SMTP, upgrading with STARTTLS when the server offers it
+type EmailSink struct {
+	cfg     config.EmailConfig
+	timeout time.Duration
+}
+
+// NewEmailSink creates an SMTP sink
+func NewEmailSink(cfg config.EmailConfig, timeout time.Duration) *EmailSink {
+	return &EmailSink{cfg: cfg, timeout: timeout}
+}
+
+// Name returns the sink type
+func (e *EmailSink) Name() string {
+	return "email"
+}
+
+// Send delivers the alert as a plain-text email to all recipients
+func (e *EmailSink) Send(ctx context.Context, alert Alert) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	dialer := &net.Dialer{Timeout: e.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(e.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("RCPT TO %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(e.message(alert)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// message renders the alert as an RFC 5322 message
+func (e *EmailSink) message(alert Alert) []byte {
+	var b strings.Builder
+	header := func(k, v string) {
+		// Header values must not contain line breaks
+		v = strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+
+	header("From", e.cfg.From)
+	header("To", strings.Join(e.cfg.To, ", "))
+	header("Subject", fmt.Sprintf("[CertWatch %s] %s", alert.Severity, alert.Summary))
+	header("Date", alert.Time.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+
+	b.WriteString(alert.Summary + "\r\n\r\n")
+	if alert.Details != "" {
+		b.WriteString(strings.ReplaceAll(alert.Details, "\n", "\r\n") + "\r\n\r\n")
+	}
+	fmt.Fprintf(&b, "Kind: %s\r\nTarget: %s\r\nAgent: %s\r\n", alert.Kind, alert.Target, alert.Agent)
+
+	return []byte(b.String())
+}
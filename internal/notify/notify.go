@@ -0,0 +1,317 @@
+// Package notify sends alerts straight from the agent to webhooks, Slack-compatible
+// webhooks and email, so expiring certificates and cert-manager failures are reported
+// even when the CertWatch API is unreachable or the site is air-gapped.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/state"
+)
+
+// Alert kinds
+const (
+	KindExpiring                 = "certificate_expiring"
+	KindExpired                  = "certificate_expired"
+	KindChainInvalid             = "chain_invalid"
+	KindCertificateRequestFailed = "certificate_request_failed"
+	KindIssuerNotReady           = "issuer_not_ready"
+)
+
+// Alert severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// criticalDays is the threshold at or below which expiry alerts are critical
+const criticalDays = 7
+
+// retention is how long sent alerts are remembered for de-duplication
+const retention = 90 * 24 * time.Hour
+
+// chainAlertIssues are the chain issues that raise a chain_invalid alert. Expiry is
+// reported by its own alerts.
+var chainAlertIssues = []string{
+	scanner.IssueRevoked,
+	scanner.IssueIncompleteChain,
+	scanner.IssueUntrustedRoot,
+}
+
+// Alert is a single notification. Key identifies the condition: an alert is sent
+// once per key and sink.
+// Fields are ordered for optimal memory alignment
+type Alert struct {
+	Time     time.Time  `json:"time"`
+	NotAfter *time.Time `json:"not_after,omitempty"`
+	Key      string     `json:"key"`
+	Kind     string     `json:"kind"`
+	Severity string     `json:"severity"`
+	Agent    string     `json:"agent"`
+	Target   string     `json:"target"` // hostname[:port] or namespace/name
+	Summary  string     `json:"summary"`
+	Details  string     `json:"details,omitempty"`
+}
+
+// Sink delivers alerts
+type Sink interface {
+	// Name identifies the sink type (webhook, slack, email)
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// sinkEntry pairs a sink with its unique ID used for de-duplication
+type sinkEntry struct {
+	sink Sink
+	id   string
+}
+
+// Notifier turns scan results and cert-manager failures into alerts and sends each
+// alert once per sink, recording what was sent in the agent state file.
+// Fields are ordered for optimal memory alignment
+type Notifier struct {
+	state      *state.Manager
+	logger     *zap.Logger
+	observer   func(sink string, success bool)
+	agent      string
+	sinks      []sinkEntry
+	thresholds []int // Descending
+	mu         sync.Mutex
+}
+
+// New creates a Notifier with the sinks configured in cfg
+func New(cfg *config.NotifyConfig, agentName string, stateManager *state.Manager, logger *zap.Logger) *Notifier {
+	var sinks []Sink
+	for i := range cfg.Webhooks {
+		sinks = append(sinks, NewWebhookSink(cfg.Webhooks[i], cfg.Timeout))
+	}
+	for i := range cfg.Slack {
+		sinks = append(sinks, NewSlackSink(cfg.Slack[i], cfg.Timeout))
+	}
+	if cfg.Email.Host != "" {
+		sinks = append(sinks, NewEmailSink(cfg.Email, cfg.Timeout))
+	}
+	return newNotifier(sinks, cfg.Thresholds, agentName, stateManager, logger)
+}
+
+func newNotifier(sinks []Sink, thresholds []int, agentName string, stateManager *state.Manager, logger *zap.Logger) *Notifier {
+	if len(thresholds) == 0 {
+		thresholds = config.DefaultNotifyThresholds
+	}
+	sorted := slices.Clone(thresholds)
+	slices.Sort(sorted)
+	slices.Reverse(sorted)
+
+	entries := make([]sinkEntry, 0, len(sinks))
+	counts := make(map[string]int)
+	for _, s := range sinks {
+		entries = append(entries, sinkEntry{sink: s, id: fmt.Sprintf("%s/%d", s.Name(), counts[s.Name()])})
+		counts[s.Name()]++
+	}
+
+	return &Notifier{
+		state:      stateManager,
+		logger:     logger,
+		agent:      agentName,
+		sinks:      entries,
+		thresholds: sorted,
+	}
+}
+
+// SetObserver registers a callback invoked after every delivery attempt,
+// e.g. to record metrics
+func (n *Notifier) SetObserver(fn func(sink string, success bool)) {
+	n.observer = fn
+}
+
+// EvaluateScan alerts on certificates crossing an expiry threshold, expired
+// certificates and chains that are revoked or untrusted
+func (n *Notifier) EvaluateScan(ctx context.Context, results []scanner.ScanResult) {
+	var alerts []Alert
+	for i := range results {
+		r := &results[i]
+		if !r.Success || r.Certificate == nil {
+			continue
+		}
+		if alert, ok := n.expiryAlert(r); ok {
+			alerts = append(alerts, alert)
+		}
+		if alert, ok := n.chainAlert(r); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	n.send(ctx, alerts)
+}
+
+func (n *Notifier) expiryAlert(r *scanner.ScanResult) (Alert, bool) {
+	info := r.Certificate
+	target := r.GetHostPort()
+	notAfter := info.NotAfter
+
+	if time.Now().After(notAfter) {
+		return Alert{
+			Key:      fmt.Sprintf("%s/%s/%s", KindExpired, target, info.FingerprintSHA256),
+			Kind:     KindExpired,
+			Severity: SeverityCritical,
+			Target:   target,
+			NotAfter: &notAfter,
+			Summary:  fmt.Sprintf("Certificate for %s has expired", target),
+			Details:  fmt.Sprintf("Expired on %s (subject %q, issuer %q)", notAfter.Format(time.RFC3339), info.Subject, info.Issuer),
+		}, true
+	}
+
+	// Alert at the smallest threshold crossed so a first scan doesn't send one alert per threshold
+	threshold := 0
+	for _, days := range n.thresholds {
+		if info.DaysUntilExpiry <= days {
+			threshold = days
+		}
+	}
+	if threshold == 0 {
+		return Alert{}, false
+	}
+
+	severity := SeverityWarning
+	if threshold <= criticalDays {
+		severity = SeverityCritical
+	}
+	return Alert{
+		Key:      fmt.Sprintf("%s/%s/%s/%d", KindExpiring, target, info.FingerprintSHA256, threshold),
+		Kind:     KindExpiring,
+		Severity: severity,
+		Target:   target,
+		NotAfter: &notAfter,
+		Summary:  fmt.Sprintf("Certificate for %s expires in %d days", target, info.DaysUntilExpiry),
+		Details:  fmt.Sprintf("Expires on %s (subject %q, issuer %q)", notAfter.Format(time.RFC3339), info.Subject, info.Issuer),
+	}, true
+}
+
+func (n *Notifier) chainAlert(r *scanner.ScanResult) (Alert, bool) {
+	if r.Chain == nil || r.Chain.Valid {
+		return Alert{}, false
+	}
+
+	var issueTypes, messages []string
+	for _, issue := range r.Chain.Issues {
+		if slices.Contains(chainAlertIssues, issue.Type) {
+			issueTypes = append(issueTypes, issue.Type)
+			messages = append(messages, issue.Message)
+		}
+	}
+	if len(issueTypes) == 0 {
+		return Alert{}, false
+	}
+	slices.Sort(issueTypes)
+
+	target := r.GetHostPort()
+	return Alert{
+		Key:      fmt.Sprintf("%s/%s/%s/%s", KindChainInvalid, target, r.Certificate.FingerprintSHA256, strings.Join(issueTypes, ",")),
+		Kind:     KindChainInvalid,
+		Severity: SeverityCritical,
+		Target:   target,
+		Summary:  fmt.Sprintf("Certificate chain for %s is invalid: %s", target, strings.Join(issueTypes, ", ")),
+		Details:  strings.Join(messages, "\n"),
+	}, true
+}
+
+// CertificateRequestFailed alerts on a failed or denied cert-manager CertificateRequest
+func (n *Notifier) CertificateRequestFailed(ctx context.Context, req types.CertificateRequestStatus) {
+	target := req.Namespace + "/" + req.CertificateName
+	status := "failed"
+	if req.Denied {
+		status = "denied"
+	}
+	n.send(ctx, []Alert{{
+		Key:      fmt.Sprintf("%s/%s/%s", KindCertificateRequestFailed, req.Namespace, req.Name),
+		Kind:     KindCertificateRequestFailed,
+		Severity: SeverityWarning,
+		Target:   target,
+		Summary:  fmt.Sprintf("CertificateRequest %s/%s for %s %s", req.Namespace, req.Name, req.CertificateName, status),
+		Details:  fmt.Sprintf("%s: %s (category: %s)", req.FailureReason, req.FailureMessage, req.FailureCategory),
+	}})
+}
+
+// IssuerNotReady alerts when a cert-manager Issuer or ClusterIssuer stops being ready
+func (n *Notifier) IssuerNotReady(ctx context.Context, issuer types.IssuerStatus) {
+	target := issuer.Name
+	if issuer.Namespace != "" {
+		target = issuer.Namespace + "/" + issuer.Name
+	}
+	// A new outage has a new transition time and alerts again
+	since := ""
+	if issuer.LastTransition != nil {
+		since = issuer.LastTransition.UTC().Format(time.RFC3339)
+	}
+	n.send(ctx, []Alert{{
+		Key:      fmt.Sprintf("%s/%s/%s/%s", KindIssuerNotReady, issuer.Kind, target, since),
+		Kind:     KindIssuerNotReady,
+		Severity: SeverityCritical,
+		Target:   target,
+		Summary:  fmt.Sprintf("%s %s is not ready", issuer.Kind, target),
+		Details:  fmt.Sprintf("%s: %s", issuer.ReadyReason, issuer.ReadyMessage),
+	}})
+}
+
+// send delivers each alert to every sink that hasn't received it yet. Failed
+// deliveries aren't recorded, so they are retried on the next evaluation.
+func (n *Notifier) send(ctx context.Context, alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now().UTC()
+	sent := false
+	for _, alert := range alerts {
+		alert.Time = now
+		alert.Agent = n.agent
+
+		for _, entry := range n.sinks {
+			key := alert.Key + "@" + entry.id
+			if _, ok := n.state.NotifiedAt(key); ok {
+				continue
+			}
+
+			err := entry.sink.Send(ctx, alert)
+			if n.observer != nil {
+				n.observer(entry.sink.Name(), err == nil)
+			}
+			if err != nil {
+				n.logger.Warn("failed to send notification",
+					zap.String("sink", entry.id),
+					zap.String("kind", alert.Kind),
+					zap.String("target", alert.Target),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			n.logger.Info("notification sent",
+				zap.String("sink", entry.id),
+				zap.String("kind", alert.Kind),
+				zap.String("target", alert.Target),
+			)
+			n.state.SetNotified(key, now)
+			sent = true
+		}
+	}
+
+	if sent {
+		n.state.PruneNotifications(now.Add(-retention))
+		if err := n.state.Save(); err != nil {
+			n.logger.Warn("failed to save notification state", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/certmanager/types"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/state"
+)
+
+// recordingSink records delivered alerts and fails while err is set
+type recordingSink struct {
+	err    error
+	alerts []Alert
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, alert Alert) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func newTestState(t *testing.T) *state.Manager {
+	t.Helper()
+	return state.NewManagerWithStateDir(t.TempDir())
+}
+
+func scanResult(hostname, fingerprint string, days int) scanner.ScanResult {
+	notAfter := time.Now().Add(time.Duration(days)*24*time.Hour + time.Hour)
+	return scanner.ScanResult{
+		Hostname: hostname,
+		Port:     443,
+		Success:  true,
+		Certificate: &scanner.CertificateInfo{
+			Subject:           hostname,
+			FingerprintSHA256: fingerprint,
+			NotAfter:          notAfter,
+			DaysUntilExpiry:   days,
+		},
+		Chain: &scanner.ChainInfo{Valid: true},
+	}
+}
+
+func TestNotifier_ExpiryThresholds(t *testing.T) {
+	sink := &recordingSink{}
+	n := newNotifier([]Sink{sink}, []int{7, 30, 14}, "test-agent", newTestState(t), zap.NewNop())
+	ctx := context.Background()
+
+	// Far from expiry: nothing
+	n.EvaluateScan(ctx, []scanner.ScanResult{scanResult("example.com", "aa", 60)})
+	if len(sink.alerts) != 0 {
+		t.Fatalf("alerts = %d, want 0", len(sink.alerts))
+	}
+
+	// First scan inside 14 days alerts once, at the 14 day threshold only
+	n.EvaluateScan(ctx, []scanner.ScanResult{scanResult("example.com", "aa", 10)})
+	n.EvaluateScan(ctx, []scanner.ScanResult{scanResult("example.com", "aa", 9)})
+	if len(sink.alerts) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(sink.alerts))
+	}
+	if got := sink.alerts[0]; got.Kind != KindExpiring || got.Severity != SeverityWarning || got.Agent != "test-agent" {
+		t.Errorf("alert = %+v, want warning %s from test-agent", got, KindExpiring)
+	}
+
+	// Crossing the next threshold alerts again, as critical
+	n.EvaluateScan(ctx, []scanner.ScanResult{scanResult("example.com", "aa", 6)})
+	if len(sink.alerts) != 2 || sink.alerts[1].Severity != SeverityCritical {
+		t.Fatalf("alerts = %+v, want a second critical alert", sink.alerts)
+	}
+
+	// A renewed certificate (new fingerprint) far from expiry stays quiet
+	n.EvaluateScan(ctx, []scanner.ScanResult{scanResult("example.com", "bb", 89)})
+	if len(sink.alerts) != 2 {
+		t.Errorf("alerts = %d, want 2 after renewal", len(sink.alerts))
+	}
+}
+
+func TestNotifier_ExpiredAndChain(t *testing.T) {
+	sink := &recordingSink{}
+	n := newNotifier([]Sink{sink}, nil, "test-agent", newTestState(t), zap.NewNop())
+
+	expired := scanResult("expired.example.com", "aa", -3)
+	expired.Certificate.NotAfter = time.Now().Add(-72 * time.Hour)
+
+	revoked := scanResult("revoked.example.com", "bb", 60)
+	revoked.Chain = &scanner.ChainInfo{
+		Valid: false,
+		Issues: []scanner.ChainIssue{
+			{Type: scanner.IssueRevoked, Message: "Certificate revoked"},
+			{Type: "weak_crypto", Message: "Weak signature algorithm"},
+		},
+	}
+
+	failed := scanner.ScanResult{Hostname: "down.example.com", Port: 443, Error: "connection refused"}
+
+	n.EvaluateScan(context.Background(), []scanner.ScanResult{expired, revoked, failed})
+
+	if len(sink.alerts) != 2 {
+		t.Fatalf("alerts = %+v, want 2", sink.alerts)
+	}
+	if sink.alerts[0].Kind != KindExpired {
+		t.Errorf("alerts[0].Kind = %v, want %v", sink.alerts[0].Kind, KindExpired)
+	}
+	if sink.alerts[1].Kind != KindChainInvalid || sink.alerts[1].Details != "Certificate revoked" {
+		t.Errorf("alerts[1] = %+v, want chain_invalid for the revocation only", sink.alerts[1])
+	}
+}
+
+func TestNotifier_RetriesFailedSinks(t *testing.T) {
+	good := &recordingSink{}
+	flaky := &recordingSink{err: errors.New("unavailable")}
+	var observed []bool
+	n := newNotifier([]Sink{good, flaky}, nil, "test-agent", newTestState(t), zap.NewNop())
+	n.SetObserver(func(_ string, success bool) { observed = append(observed, success) })
+
+	results := []scanner.ScanResult{scanResult("example.com", "aa", 5)}
+	n.EvaluateScan(context.Background(), results)
+
+	flaky.err = nil
+	n.EvaluateScan(context.Background(), results)
+
+	if len(good.alerts) != 1 {
+		t.Errorf("good sink alerts = %d, want 1", len(good.alerts))
+	}
+	if len(flaky.alerts) != 1 {
+		t.Errorf("flaky sink alerts = %d, want 1 after recovering", len(flaky.alerts))
+	}
+	if want := []bool{true, false, true}; len(observed) != len(want) {
+		t.Errorf("observed = %v, want %v", observed, want)
+	}
+}
+
+func TestNotifier_DedupeSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	results := []scanner.ScanResult{scanResult("example.com", "aa", 20)}
+
+	first := &recordingSink{}
+	n := newNotifier([]Sink{first}, nil, "test-agent", state.NewManagerWithStateDir(dir), zap.NewNop())
+	n.EvaluateScan(context.Background(), results)
+
+	reloaded := state.NewManagerWithStateDir(dir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+	second := &recordingSink{}
+	n = newNotifier([]Sink{second}, nil, "test-agent", reloaded, zap.NewNop())
+	n.EvaluateScan(context.Background(), results)
+
+	if len(first.alerts) != 1 || len(second.alerts) != 0 {
+		t.Errorf("alerts before/after restart = %d/%d, want 1/0", len(first.alerts), len(second.alerts))
+	}
+}
+
+func TestNotifier_CertManager(t *testing.T) {
+	sink := &recordingSink{}
+	n := newNotifier([]Sink{sink}, nil, "cluster-agent", newTestState(t), zap.NewNop())
+	ctx := context.Background()
+
+	req := types.CertificateRequestStatus{
+		Namespace:       "default",
+		Name:            "web-1",
+		CertificateName: "web",
+		Failed:          true,
+		FailureReason:   "Failed",
+		FailureMessage:  "ACME order failed",
+		FailureCategory: types.FailureCategoryACME,
+	}
+	n.CertificateRequestFailed(ctx, req)
+	n.CertificateRequestFailed(ctx, req)
+
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	issuer := types.IssuerStatus{Kind: "ClusterIssuer", Name: "letsencrypt", ReadyReason: "ErrRegisterACMEAccount", LastTransition: &first}
+	n.IssuerNotReady(ctx, issuer)
+	n.IssuerNotReady(ctx, issuer)
+
+	// A later outage alerts again
+	second := first.Add(48 * time.Hour)
+	issuer.LastTransition = &second
+	n.IssuerNotReady(ctx, issuer)
+
+	kinds := make([]string, 0, len(sink.alerts))
+	for _, a := range sink.alerts {
+		kinds = append(kinds, a.Kind)
+	}
+	want := []string{KindCertificateRequestFailed, KindIssuerNotReady, KindIssuerNotReady}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("kinds = %v, want %v", kinds, want)
+		}
+	}
+	if sink.alerts[0].Target != "default/web" {
+		t.Errorf("Target = %v, want default/web", sink.alerts[0].Target)
+	}
+}
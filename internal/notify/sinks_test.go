@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+func testAlert() Alert {
+	return Alert{
+		Time:     time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Key:      "certificate_expiring/example.com/aa/7",
+		Kind:     KindExpiring,
+		Severity: SeverityCritical,
+		Agent:    "test-agent",
+		Target:   "example.com",
+		Summary:  "Certificate for example.com expires in 5 days",
+		Details:  "Expires on 2025-01-06T12:00:00Z",
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got Alert
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(config.WebhookConfig{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}, 5*time.Second)
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Key != testAlert().Key || got.Summary != testAlert().Summary {
+		t.Errorf("received %+v, want %+v", got, testAlert())
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Authorization = %q, want Bearer token", auth)
+	}
+}
+
+func TestWebhookSink_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(config.WebhookConfig{URL: srv.URL}, 5*time.Second)
+	if err := sink.Send(context.Background(), testAlert()); err == nil {
+		t.Error("Send() error = nil, want error for 502")
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	var got slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewSlackSink(config.SlackConfig{WebhookURL: srv.URL, Channel: "#certs"}, 5*time.Second)
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !strings.HasPrefix(got.Text, ":rotating_light: *Certificate for example.com expires in 5 days*") {
+		t.Errorf("Text = %q, want critical summary first", got.Text)
+	}
+	if !strings.Contains(got.Text, "test-agent") {
+		t.Errorf("Text = %q, want agent name", got.Text)
+	}
+	if got.Channel != "#certs" {
+		t.Errorf("Channel = %q, want #certs", got.Channel)
+	}
+}
+
+// startSMTPStub runs a minimal SMTP server for one session and returns its port
+// and a channel receiving the message data
+func startSMTPStub(t *testing.T) (int, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 stub ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250-stub")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(cmd, "AUTH PLAIN"):
+				reply("235 authenticated")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 ok")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				messages <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unknown")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, messages
+}
+
+func TestEmailSink(t *testing.T) {
+	port, messages := startSMTPStub(t)
+
+	sink := NewEmailSink(config.EmailConfig{
+		Host:     "127.0.0.1",
+		Port:     port,
+		Username: "agent",
+		Password: "secret",
+		From:     "certwatch@example.com",
+		To:       []string{"ops@example.com", "oncall@example.com"},
+	}, 5*time.Second)
+
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case msg := <-messages:
+		for _, want := range []string{
+			"From: certwatch@example.com\r\n",
+			"To: ops@example.com, oncall@example.com\r\n",
+			"Subject: [CertWatch critical] Certificate for example.com expires in 5 days\r\n",
+			"Agent: test-agent",
+		} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("message missing %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/version"
+)
+
+// WebhookSink posts alerts as JSON to a URL
+type WebhookSink struct {
+	client  *http.Client
+	headers map[string]string
+	url     string
+}
+
+// NewWebhookSink creates a generic webhook sink
+func NewWebhookSink(cfg config.WebhookConfig, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		client:  &http.Client{Timeout: timeout},
+		headers: cfg.Headers,
+		url:     cfg.URL,
+	}
+}
+
+// Name returns the sink type
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Send posts the alert
+func (w *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, w.client, w.url, alert, w.headers)
+}
+
+// SlackSink posts alerts to a Slack-compatible incoming webhook
+// Fields are ordered for optimal memory alignment
+type SlackSink struct {
+	client   *http.Client
+	url      string
+	channel  string
+	username string
+}
+
+// slackMessage is the incoming webhook payload understood by Slack, Mattermost and Rocket.Chat
+type slackMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// NewSlackSink creates a Slack-compatible webhook sink
+func NewSlackSink(cfg config.SlackConfig, timeout time.Duration) *SlackSink {
+	return &SlackSink{
+		client:   &http.Client{Timeout: timeout},
+		url:      cfg.WebhookURL,
+		channel:  cfg.Channel,
+		username: cfg.Username,
+	}
+}
+
+// Name returns the sink type
+func (s *SlackSink) Name() string {
+	return "slack"
+}
+
+// Send posts the alert as a formatted message
+func (s *SlackSink) Send(ctx context.Context, alert Alert) error {
+	icon := ":warning:"
+	if alert.Severity == SeverityCritical {
+		icon = ":rotating_light:"
+	}
+	text := fmt.Sprintf("%s *%s*", icon, alert.Summary)
+	if alert.Details != "" {
+		text += "\n" + alert.Details
+	}
+	text += fmt.Sprintf("\n_Agent: %s_", alert.Agent)
+
+	return postJSON(ctx, s.client, s.url, slackMessage{
+		Text:     text,
+		Channel:  s.channel,
+		Username: s.username,
+	}, nil)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("cw-agent/%s", version.GetVersion()))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Package outbox provides a disk-backed queue for sync payloads that could not be
+// delivered to the CertWatch API. Entries are replayed in order once the API is
+// reachable again and are capped by total size and age.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// outboxFileName is the name of the outbox file stored alongside the state file
+const outboxFileName = ".certwatch-outbox.json"
+
+// Replay backoff bounds
+const (
+	minBackoff = 5 * time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// ErrBackoff is returned by Replay when the previous replay failed and the backoff has not elapsed
+var ErrBackoff = errors.New("outbox replay backing off")
+
+// Entry is a single queued sync payload
+type Entry struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	ID        uint64          `json:"id"`
+}
+
+// file is the on-disk representation of the queue
+type file struct {
+	Entries []Entry `json:"entries"`
+	NextID  uint64  `json:"next_id"`
+}
+
+// Queue is a persistent FIFO of undelivered sync payloads
+// Fields are ordered for optimal memory alignment
+type Queue struct {
+	nextAttempt time.Time
+	now         func() time.Time
+	filePath    string
+	entries     []Entry
+	maxBytes    int64
+	maxAge      time.Duration
+	nextID      uint64
+	failures    int
+	mu          sync.Mutex
+}
+
+// New creates a queue stored in dir, capped at maxBytes of payload data and maxAge per entry.
+// A zero maxBytes or maxAge disables the respective cap.
+func New(dir string, maxBytes int64, maxAge time.Duration) *Queue {
+	return &Queue{
+		filePath: filepath.Join(dir, outboxFileName),
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		nextID:   1,
+		now:      time.Now,
+	}
+}
+
+// Load reads queued entries from disk
+// Returns nil if the file doesn't exist (nothing queued)
+func (q *Queue) Load() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := os.ReadFile(q.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read outbox file: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to parse outbox file (discarding queued payloads): %w", err)
+	}
+
+	q.entries = f.Entries
+	if f.NextID > q.nextID {
+		q.nextID = f.NextID
+	}
+	q.prune()
+	return nil
+}
+
+// Enqueue appends a payload to the queue and persists it
+func (q *Queue) Enqueue(kind string, payload []byte) error {
+	if !json.Valid(payload) {
+		return errors.New("payload is not valid JSON")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = append(q.entries, Entry{
+		ID:        q.nextID,
+		Kind:      kind,
+		Payload:   payload,
+		CreatedAt: q.now().UTC(),
+	})
+	q.nextID++
+	q.prune()
+
+	return q.save()
+}
+
+// Replay sends queued entries in order using send. It stops at the first failure,
+// keeping that entry and everything after it, and backs off exponentially before
+// the next attempt. Returns the number of entries delivered.
+func (q *Queue) Replay(ctx context.Context, send func(ctx context.Context, e Entry) error) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 {
+		return 0, nil
+	}
+	if q.now().Before(q.nextAttempt) {
+		return 0, ErrBackoff
+	}
+
+	q.prune()
+
+	sent := 0
+	var sendErr error
+	for _, e := range q.entries {
+		if err := ctx.Err(); err != nil {
+			sendErr = err
+			break
+		}
+		if err := send(ctx, e); err != nil {
+			sendErr = err
+			break
+		}
+		sent++
+	}
+	q.entries = q.entries[sent:]
+
+	if sendErr != nil {
+		q.failures++
+		q.nextAttempt = q.now().Add(backoff(q.failures))
+	} else {
+		q.failures = 0
+		q.nextAttempt = time.Time{}
+	}
+
+	if err := q.save(); err != nil {
+		return sent, err
+	}
+	return sent, sendErr
+}
+
+// Len returns the number of queued entries
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Size returns the total payload size of queued entries in bytes
+func (q *Queue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size()
+}
+
+// FilePath returns the path to the outbox file
+func (q *Queue) FilePath() string {
+	return q.filePath
+}
+
+// prune drops entries older than maxAge and the oldest entries beyond maxBytes.
+// Caller must hold q.mu.
+func (q *Queue) prune() {
+	if q.maxAge > 0 {
+		cutoff := q.now().Add(-q.maxAge)
+		drop := 0
+		for drop < len(q.entries) && q.entries[drop].CreatedAt.Before(cutoff) {
+			drop++
+		}
+		q.entries = q.entries[drop:]
+	}
+
+	if q.maxBytes > 0 {
+		total := q.size()
+		drop := 0
+		for drop < len(q.entries) && total > q.maxBytes {
+			total -= int64(len(q.entries[drop].Payload))
+			drop++
+		}
+		q.entries = q.entries[drop:]
+	}
+}
+
+// size returns the total payload size. Caller must hold q.mu.
+func (q *Queue) size() int64 {
+	var total int64
+	for i := range q.entries {
+		total += int64(len(q.entries[i].Payload))
+	}
+	return total
+}
+
+// save writes the queue to disk atomically with secure permissions (0600).
+// Caller must hold q.mu.
+func (q *Queue) save() error {
+	if len(q.entries) == 0 {
+		if err := os.Remove(q.filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove outbox file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(file{Entries: q.entries, NextID: q.nextID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox: %w", err)
+	}
+
+	tmp := q.filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write outbox file: %w", err)
+	}
+	if err := os.Rename(tmp, q.filePath); err != nil {
+		return fmt.Errorf("failed to write outbox file: %w", err)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next replay after the given number of consecutive failures
+func backoff(failures int) time.Duration {
+	d := minBackoff
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEnqueueAndReplayInOrder(t *testing.T) {
+	q := New(t.TempDir(), 0, 0)
+
+	for _, p := range []string{`{"n":1}`, `{"n":2}`, `{"n":3}`} {
+		if err := q.Enqueue("certificates", []byte(p)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	var got []string
+	sent, err := q.Replay(context.Background(), func(_ context.Context, e Entry) error {
+		got = append(got, string(e.Payload))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if sent != 3 {
+		t.Errorf("sent = %d, want 3", sent)
+	}
+	want := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", q.Len())
+	}
+	if _, err := os.Stat(q.FilePath()); !os.IsNotExist(err) {
+		t.Error("expected outbox file to be removed once empty")
+	}
+}
+
+func TestReplayStopsAtFailureAndBacksOff(t *testing.T) {
+	q := New(t.TempDir(), 0, 0)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	for _, p := range []string{`1`, `2`, `3`} {
+		if err := q.Enqueue("certificates", []byte(p)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	errDown := errors.New("down")
+	sent, err := q.Replay(context.Background(), func(_ context.Context, e Entry) error {
+		if string(e.Payload) == "2" {
+			return errDown
+		}
+		return nil
+	})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("Replay() error = %v, want %v", err, errDown)
+	}
+	if sent != 1 {
+		t.Errorf("sent = %d, want 1", sent)
+	}
+	if q.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", q.Len())
+	}
+
+	// Immediate retry is rejected while backing off
+	_, err = q.Replay(context.Background(), func(context.Context, Entry) error { return nil })
+	if !errors.Is(err, ErrBackoff) {
+		t.Fatalf("Replay() error = %v, want ErrBackoff", err)
+	}
+
+	// After the backoff elapses the remaining entries are delivered
+	now = now.Add(minBackoff)
+	sent, err = q.Replay(context.Background(), func(context.Context, Entry) error { return nil })
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if sent != 2 {
+		t.Errorf("sent = %d, want 2", sent)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	q1 := New(dir, 0, 0)
+	if err := q1.Enqueue("certmanager_events", []byte(`{"events":[]}`)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	info, err := os.Stat(q1.FilePath())
+	if err != nil {
+		t.Fatalf("failed to stat outbox file: %v", err)
+	}
+	if info.Mode().Perm()&0o077 != 0 && os.Getenv("OS") != "Windows_NT" {
+		t.Errorf("expected restricted permissions, got %o", info.Mode().Perm())
+	}
+
+	q2 := New(dir, 0, 0)
+	if err := q2.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if q2.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", q2.Len())
+	}
+
+	// IDs keep increasing across restarts
+	if err := q2.Enqueue("certmanager_events", []byte(`{}`)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	var ids []uint64
+	_, _ = q2.Replay(context.Background(), func(_ context.Context, e Entry) error {
+		ids = append(ids, e.ID)
+		return nil
+	})
+	if len(ids) != 2 || ids[1] <= ids[0] {
+		t.Errorf("ids = %v, want increasing", ids)
+	}
+}
+
+func TestSizeCapDropsOldest(t *testing.T) {
+	q := New(t.TempDir(), 10, 0)
+
+	for _, p := range []string{`"aa"`, `"bb"`, `"cc"`} {
+		if err := q.Enqueue("certificates", []byte(p)); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", q.Len())
+	}
+	if q.Size() != 8 {
+		t.Errorf("Size() = %d, want 8", q.Size())
+	}
+
+	var first string
+	_, _ = q.Replay(context.Background(), func(_ context.Context, e Entry) error {
+		if first == "" {
+			first = string(e.Payload)
+		}
+		return nil
+	})
+	if first != `"bb"` {
+		t.Errorf("first entry = %s, want \"bb\"", first)
+	}
+}
+
+func TestAgeCapDropsExpired(t *testing.T) {
+	q := New(t.TempDir(), 0, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	if err := q.Enqueue("certificates", []byte(`"old"`)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := q.Enqueue("certificates", []byte(`"new"`)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", q.Len())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{20, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
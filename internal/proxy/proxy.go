@@ -0,0 +1,222 @@
+// Package proxy routes outbound connections through an HTTP CONNECT or SOCKS5
+// proxy. Hosts on the no-proxy list are connected to directly.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// Supported proxy URL schemes
+const (
+	SchemeHTTP    = "http"
+	SchemeHTTPS   = "https"
+	SchemeSOCKS5  = "socks5"
+	SchemeSOCKS5H = "socks5h" // Hostnames are resolved by the proxy; same as socks5 here
+)
+
+// Dialer connects to addresses directly or through the configured proxy
+// Fields are ordered for optimal memory alignment
+type Dialer struct {
+	proxy   *url.URL
+	direct  *net.Dialer
+	socks   xproxy.ContextDialer
+	noProxy []string
+}
+
+// New creates a Dialer for the proxy at rawURL. An empty rawURL connects
+// directly. noProxy lists hosts (matching subdomains too), .domains, IPs and
+// CIDRs to connect to directly; "*" disables the proxy.
+func New(rawURL string, noProxy []string, timeout time.Duration) (*Dialer, error) {
+	d := &Dialer{
+		direct:  &net.Dialer{Timeout: timeout},
+		noProxy: noProxy,
+	}
+	if rawURL == "" {
+		return d, nil
+	}
+
+	u, err := Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	d.proxy = u
+
+	if u.Scheme == SchemeSOCKS5 || u.Scheme == SchemeSOCKS5H {
+		var auth *xproxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &xproxy.Auth{User: u.User.Username(), Password: password}
+		}
+		socks, err := xproxy.SOCKS5("tcp", u.Host, auth, d.direct)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socks5 proxy: %w", err)
+		}
+		d.socks = socks.(xproxy.ContextDialer)
+	}
+
+	return d, nil
+}
+
+// Parse validates a proxy URL
+func Parse(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case SchemeHTTP, SchemeHTTPS, SchemeSOCKS5, SchemeSOCKS5H:
+	default:
+		return nil, fmt.Errorf("proxy URL must use http, https, socks5 or socks5h scheme")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL must include a host")
+	}
+	if u.Port() == "" {
+		port := map[string]string{SchemeHTTP: "80", SchemeHTTPS: "443", SchemeSOCKS5: "1080", SchemeSOCKS5H: "1080"}[u.Scheme]
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// DialContext connects to addr, through the proxy unless its host bypasses it
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if d.proxy == nil || d.Bypass(host) {
+		return d.direct.DialContext(ctx, network, addr)
+	}
+
+	if d.socks != nil {
+		conn, err := d.socks.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, fmt.Errorf("socks5 proxy %s: %w", d.proxy.Host, err)
+		}
+		return conn, nil
+	}
+	return d.connect(ctx, addr)
+}
+
+// connect opens a tunnel to addr with an HTTP CONNECT request
+func (d *Dialer) connect(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := d.direct.DialContext(ctx, "tcp", d.proxy.Host)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", d.proxy.Host, err)
+	}
+
+	// Bound the handshake with the proxy by the context
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if d.proxy.Scheme == SchemeHTTPS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", d.proxy.Host, err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if auth := d.authorization(); auth != "" {
+		req.Header.Set("Proxy-Authorization", auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", d.proxy.Host, err)
+	}
+
+	// The proxy sends nothing after its response until the tunnel is used, so
+	// nothing buffered beyond the response is lost
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", d.proxy.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: CONNECT %s: %s", d.proxy.Host, addr, resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// authorization returns the Proxy-Authorization header for the credentials in the proxy URL
+func (d *Dialer) authorization() string {
+	if d.proxy.User == nil {
+		return ""
+	}
+	password, _ := d.proxy.User.Password()
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(d.proxy.User.Username()+":"+password))
+}
+
+// Proxy returns the proxy URL for an HTTP request, for use as http.Transport.Proxy
+func (d *Dialer) Proxy(req *http.Request) (*url.URL, error) {
+	if d.proxy == nil || d.Bypass(req.URL.Hostname()) {
+		return nil, nil
+	}
+	return d.proxy, nil
+}
+
+// Enabled reports whether a proxy is configured
+func (d *Dialer) Enabled() bool {
+	return d.proxy != nil
+}
+
+// Bypass reports whether host is on the no-proxy list
+func (d *Dialer) Bypass(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+
+	for _, entry := range d.noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) {
+				return true
+			}
+		case host == entry || strings.HasSuffix(host, "."+entry):
+			return true
+		}
+	}
+	return false
+}
+
+// Transport returns an HTTP transport that connects through the proxy
+func (d *Dialer) Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = d.Proxy
+	t.DialContext = d.direct.DialContext
+	return t
+}
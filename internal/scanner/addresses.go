@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// Issues of targets scanned on all their addresses
+const (
+	// IssueFingerprintMismatch is reported when the addresses of a hostname
+	// serve different certificates
+	IssueFingerprintMismatch = "fingerprint_mismatch"
+	// IssueAddressUnreachable is reported when some addresses fail while
+	// others serve a certificate
+	IssueAddressUnreachable = "address_unreachable"
+)
+
+// scanAllAddresses resolves the target and scans every address. The result is
+// the scan of the certificate that expires first, so a stale backend drives
+// expiry alerts, with every address listed in Addresses.
+func (s *Scanner) scanAllAddresses(ctx context.Context, cert config.CertificateConfig) ScanResult {
+	host := cert.Address
+	if host == "" {
+		host = cert.Hostname
+	}
+
+	ips, err := s.lookupIP(ctx, host)
+	if err == nil && len(ips) == 0 {
+		err = fmt.Errorf("no addresses found")
+	}
+	if err != nil {
+		return ScanResult{
+			Hostname:   cert.Hostname,
+			Address:    cert.Address,
+			ServerName: cert.ServerName,
+			Port:       cert.Port,
+			StartTLS:   cert.StartTLS,
+			Error:      fmt.Sprintf("resolve failed: %v", err),
+			ScannedAt:  time.Now().UTC(),
+		}
+	}
+
+	// Each address takes a slot of the scanner's concurrency limit
+	results := make([]ScanResult, len(ips))
+	var wg sync.WaitGroup
+	for i, ip := range ips {
+		c := cert
+		c.Address = ip.String()
+		c.AllAddresses = false
+
+		wg.Add(1)
+		go func(idx int, c config.CertificateConfig) {
+			defer wg.Done()
+			if !s.acquire(ctx) {
+				results[idx] = canceledResult(c)
+				return
+			}
+			defer s.release()
+			results[idx] = s.Scan(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	merged := mergeAddressResults(results)
+	merged.Address = cert.Address
+	return merged
+}
+
+// mergeAddressResults picks the successful scan whose certificate expires first
+// (or the first failure if none succeeded) and lists every address in it. It
+// adds a fingerprint_mismatch issue when the addresses serve different
+// certificates and an address_unreachable issue when only some of them failed.
+func mergeAddressResults(results []ScanResult) ScanResult {
+	var primary *ScanResult
+	addresses := make([]AddressResult, 0, len(results))
+	byFingerprint := make(map[string][]string)
+	var fingerprints []string // In order of first appearance
+	var failed []string
+
+	for i := range results {
+		r := &results[i]
+		a := AddressResult{Address: r.Address, Error: r.Error, Success: r.Success}
+		if !r.Success {
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.Address, r.Error))
+		}
+		if r.Success && r.Certificate != nil {
+			fp := r.Certificate.FingerprintSHA256
+			a.FingerprintSHA256 = fp
+			a.NotAfter = r.Certificate.NotAfter
+
+			if _, ok := byFingerprint[fp]; !ok {
+				fingerprints = append(fingerprints, fp)
+			}
+			byFingerprint[fp] = append(byFingerprint[fp], r.Address)
+
+			if primary == nil || r.Certificate.NotAfter.Before(primary.Certificate.NotAfter) {
+				primary = r
+			}
+		}
+		addresses = append(addresses, a)
+	}
+
+	if primary == nil {
+		primary = &results[0]
+	}
+	merged := *primary
+	merged.Addresses = addresses
+	if merged.Chain == nil {
+		return merged
+	}
+
+	var issues []ChainIssue
+	if len(fingerprints) > 1 {
+		parts := make([]string, 0, len(fingerprints))
+		for _, fp := range fingerprints {
+			parts = append(parts, fmt.Sprintf("%s on %s", shortFingerprint(fp), strings.Join(byFingerprint[fp], ", ")))
+		}
+		issues = append(issues, ChainIssue{
+			Type:    IssueFingerprintMismatch,
+			Message: fmt.Sprintf("Addresses serve %d different certificates: %s", len(fingerprints), strings.Join(parts, "; ")),
+		})
+	}
+	if len(failed) > 0 && len(failed) < len(results) {
+		issues = append(issues, ChainIssue{
+			Type:    IssueAddressUnreachable,
+			Message: fmt.Sprintf("%d of %d addresses failed: %s", len(failed), len(results), strings.Join(failed, "; ")),
+		})
+	}
+	if len(issues) > 0 {
+		// Copy so the issues aren't added to the chain of the per-address result
+		chain := *merged.Chain
+		chain.Issues = append(append([]ChainIssue(nil), chain.Issues...), issues...)
+		merged.Chain = &chain
+	}
+
+	return merged
+}
+
+func shortFingerprint(fp string) string {
+	if len(fp) > 16 {
+		return fp[:16]
+	}
+	return fp
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+func TestScan_AllAddresses(t *testing.T) {
+	current := newTestCertificate(t, "rr.example.test")
+	stale := newTestCertificate(t, "rr.example.test")
+
+	port := startTLSServer(t, &tls.Config{Certificates: []tls.Certificate{current}})
+
+	// A second backend on another loopback address with the same port
+	ln, err := tls.Listen("tcp", net.JoinHostPort("127.0.0.2", strconv.Itoa(port)), &tls.Config{Certificates: []tls.Certificate{stale}})
+	if err != nil {
+		t.Skipf("127.0.0.2 not available: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+
+	s := New(2*time.Second, 1, zap.NewNop())
+	s.lookupIP = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		if host != "rr.example.test" {
+			t.Errorf("lookupIP(%q), want rr.example.test", host)
+		}
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("127.0.0.2")}, {IP: net.ParseIP("127.0.0.3")}}, nil
+	}
+
+	result := s.Scan(context.Background(), config.CertificateConfig{Hostname: "rr.example.test", Port: port, AllAddresses: true})
+
+	if !result.Success {
+		t.Fatalf("Scan() failed: %s", result.Error)
+	}
+	if len(result.Addresses) != 3 {
+		t.Fatalf("Addresses = %+v, want 3", result.Addresses)
+	}
+	if !result.Addresses[0].Success || !result.Addresses[1].Success || result.Addresses[2].Success {
+		t.Errorf("Addresses = %+v, want the first two to succeed", result.Addresses)
+	}
+	if result.Addresses[0].FingerprintSHA256 == result.Addresses[1].FingerprintSHA256 {
+		t.Error("fingerprints are equal, want the backends to differ")
+	}
+	if result.Address != "" {
+		t.Errorf("Address = %q, want the configured (empty) address", result.Address)
+	}
+
+	got := issueTypes(result.Chain.Issues)
+	if !slices.Contains(got, IssueFingerprintMismatch) || !slices.Contains(got, IssueAddressUnreachable) {
+		t.Errorf("issues = %v, want fingerprint_mismatch and address_unreachable", got)
+	}
+}
+
+func TestMergeAddressResults(t *testing.T) {
+	now := time.Now()
+	scan := func(addr, fp string, notAfter time.Time) ScanResult {
+		return ScanResult{
+			Address:     addr,
+			Success:     true,
+			Certificate: &CertificateInfo{FingerprintSHA256: fp, NotAfter: notAfter},
+			Chain:       &ChainInfo{Valid: true},
+		}
+	}
+
+	t.Run("consistent", func(t *testing.T) {
+		merged := mergeAddressResults([]ScanResult{
+			scan("10.0.0.1", "aa", now.Add(48*time.Hour)),
+			scan("10.0.0.2", "aa", now.Add(48*time.Hour)),
+		})
+		if len(merged.Chain.Issues) != 0 {
+			t.Errorf("issues = %v, want none", issueTypes(merged.Chain.Issues))
+		}
+	})
+
+	t.Run("earliest expiry wins", func(t *testing.T) {
+		results := []ScanResult{
+			scan("10.0.0.1", "aa", now.Add(48*time.Hour)),
+			scan("10.0.0.2", "bb", now.Add(24*time.Hour)),
+		}
+		merged := mergeAddressResults(results)
+		if merged.Address != "10.0.0.2" {
+			t.Errorf("primary = %s, want the address expiring first", merged.Address)
+		}
+		if got := issueTypes(merged.Chain.Issues); len(got) != 1 || got[0] != IssueFingerprintMismatch {
+			t.Errorf("issues = %v, want fingerprint_mismatch", got)
+		}
+		if len(results[1].Chain.Issues) != 0 {
+			t.Error("per-address chain was modified")
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		merged := mergeAddressResults([]ScanResult{
+			scan("10.0.0.1", "aa", now.Add(48*time.Hour)),
+			{Address: "10.0.0.2", Error: "connection refused"},
+		})
+		if !merged.Success || merged.Address != "10.0.0.1" {
+			t.Errorf("merged = %+v, want the successful address", merged)
+		}
+		if got := issueTypes(merged.Chain.Issues); len(got) != 1 || got[0] != IssueAddressUnreachable {
+			t.Errorf("issues = %v, want address_unreachable", got)
+		}
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		merged := mergeAddressResults([]ScanResult{
+			{Address: "10.0.0.1", Error: "connection failed"},
+			{Address: "10.0.0.2", Error: "connection failed"},
+		})
+		if merged.Success || merged.Error == "" || len(merged.Addresses) != 2 {
+			t.Errorf("merged = %+v, want a failure listing both addresses", merged)
+		}
+	})
+}
@@ -0,0 +1,352 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// Revocation issue types
+const (
+	IssueRevoked               = "revoked"
+	IssueOCSPUnavailable       = "ocsp_unavailable"
+	IssueOCSPMustStapleMissing = "ocsp_must_staple_missing"
+)
+
+const (
+	// revocationCacheTTL bounds how long a response without nextUpdate is reused
+	revocationCacheTTL  = time.Hour
+	maxOCSPResponseSize = 1 << 20
+	maxCRLSize          = 32 << 20
+)
+
+var (
+	// oidTLSFeature is the TLS Feature extension (RFC 7633) used for OCSP Must-Staple
+	oidTLSFeature = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+	errNoOCSPResponder = errors.New("no OCSP responder")
+	errNoCRL           = errors.New("no CRL distribution point")
+)
+
+// tlsFeatureStatusRequest is the status_request TLS extension number
+const tlsFeatureStatusRequest = 5
+
+// RevocationChecker checks certificates against OCSP responders, falling back to
+// CRLs. Responses are cached until their nextUpdate so repeated scans don't hit
+// the responders every interval.
+// Fields are ordered for optimal memory alignment
+type RevocationChecker struct {
+	httpClient    *http.Client
+	logger        *zap.Logger
+	ocspCache     map[string]cachedOCSP
+	crlCache      map[string]cachedCRL
+	ocspResponder string
+	crlURL        string
+	mu            sync.Mutex
+}
+
+type cachedOCSP struct {
+	resp    *ocsp.Response
+	expires time.Time
+}
+
+type cachedCRL struct {
+	crl     *x509.RevocationList
+	expires time.Time
+}
+
+// NewRevocationChecker creates a checker. Responder and CRL URLs in cfg, when set,
+// replace the URLs embedded in the certificates.
+func NewRevocationChecker(cfg config.RevocationConfig, timeout time.Duration, logger *zap.Logger) *RevocationChecker {
+	return &RevocationChecker{
+		httpClient:    &http.Client{Timeout: timeout},
+		logger:        logger,
+		ocspCache:     make(map[string]cachedOCSP),
+		crlCache:      make(map[string]cachedCRL),
+		ocspResponder: cfg.OCSPResponder,
+		crlURL:        cfg.CRLURL,
+	}
+}
+
+// Check returns revocation issues for certs (leaf first). Each certificate is checked
+// against the certificate after it when that one signed it; self-signed roots are
+// skipped. stapled is the OCSP response from the TLS handshake, if any, and is used
+// for the leaf instead of querying the responder.
+func (r *RevocationChecker) Check(ctx context.Context, certs []*x509.Certificate, stapled []byte) []ChainIssue {
+	var issues []ChainIssue
+
+	if len(certs) > 0 && len(stapled) == 0 && hasMustStaple(certs[0]) {
+		issues = append(issues, ChainIssue{
+			Type:             IssueOCSPMustStapleMissing,
+			Message:          "Certificate requires OCSP stapling but the server did not staple a response",
+			CertificateIndex: 0,
+		})
+	}
+
+	for i := 0; i+1 < len(certs); i++ {
+		cert, issuer := certs[i], certs[i+1]
+		if cert.CheckSignatureFrom(issuer) != nil {
+			continue
+		}
+		var staple []byte
+		if i == 0 {
+			staple = stapled
+		}
+		if issue := r.checkCertificate(ctx, cert, issuer, staple, i); issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+
+	return issues
+}
+
+// checkCertificate tries the stapled response, then OCSP, then the CRL
+func (r *RevocationChecker) checkCertificate(ctx context.Context, cert, issuer *x509.Certificate, stapled []byte, idx int) *ChainIssue {
+	if len(stapled) > 0 {
+		resp, err := ocsp.ParseResponseForCert(stapled, cert, issuer)
+		if err == nil && resp.Status != ocsp.Unknown {
+			return ocspIssue(resp, idx)
+		}
+		r.logger.Debug("ignoring stapled OCSP response", zap.Int("certificate_index", idx), zap.Error(err))
+	}
+
+	resp, ocspErr := r.queryOCSP(ctx, cert, issuer)
+	if ocspErr == nil {
+		return ocspIssue(resp, idx)
+	}
+
+	revokedAt, crlErr := r.checkCRL(ctx, cert, issuer)
+	if crlErr == nil {
+		if revokedAt.IsZero() {
+			return nil
+		}
+		return &ChainIssue{
+			Type:             IssueRevoked,
+			Message:          fmt.Sprintf("Certificate revoked on %s (CRL)", revokedAt.Format(time.RFC3339)),
+			CertificateIndex: idx,
+		}
+	}
+
+	// Nothing to check against is not an error; many private CAs publish neither
+	if errors.Is(ocspErr, errNoOCSPResponder) && errors.Is(crlErr, errNoCRL) {
+		return nil
+	}
+
+	r.logger.Debug("revocation status unavailable",
+		zap.Int("certificate_index", idx),
+		zap.NamedError("ocsp_error", ocspErr),
+		zap.NamedError("crl_error", crlErr),
+	)
+	return &ChainIssue{
+		Type:             IssueOCSPUnavailable,
+		Message:          fmt.Sprintf("Revocation status unavailable: OCSP: %v; CRL: %v", ocspErr, crlErr),
+		CertificateIndex: idx,
+	}
+}
+
+func ocspIssue(resp *ocsp.Response, idx int) *ChainIssue {
+	if resp.Status != ocsp.Revoked {
+		return nil
+	}
+	return &ChainIssue{
+		Type: IssueRevoked,
+		Message: fmt.Sprintf("Certificate revoked on %s (OCSP, reason: %s)",
+			resp.RevokedAt.Format(time.RFC3339), revocationReason(resp.RevocationReason)),
+		CertificateIndex: idx,
+	}
+}
+
+// queryOCSP returns a good or revoked response; an unknown status is an error so the CRL is tried
+func (r *RevocationChecker) queryOCSP(ctx context.Context, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	responder := r.ocspResponder
+	if responder == "" {
+		if len(cert.OCSPServer) == 0 {
+			return nil, errNoOCSPResponder
+		}
+		responder = cert.OCSPServer[0]
+	}
+
+	key := issuerKey(issuer) + "/" + cert.SerialNumber.String()
+	r.mu.Lock()
+	cached, ok := r.ocspCache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.resp, nil
+	}
+
+	reqBody, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := r.fetch(ctx, http.MethodPost, responder, reqBody, maxOCSPResponseSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Status == ocsp.Unknown {
+		return nil, fmt.Errorf("responder returned unknown status")
+	}
+
+	r.mu.Lock()
+	r.ocspCache[key] = cachedOCSP{resp: resp, expires: cacheExpiry(resp.NextUpdate)}
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// checkCRL returns the revocation time of cert, or the zero time if it is not listed
+func (r *RevocationChecker) checkCRL(ctx context.Context, cert, issuer *x509.Certificate) (time.Time, error) {
+	crlURL := r.crlURL
+	if crlURL == "" {
+		for _, dp := range cert.CRLDistributionPoints {
+			if strings.HasPrefix(dp, "http://") || strings.HasPrefix(dp, "https://") {
+				crlURL = dp
+				break
+			}
+		}
+		if crlURL == "" {
+			return time.Time{}, errNoCRL
+		}
+	}
+
+	crl, err := r.loadCRL(ctx, crlURL, issuer)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return entry.RevocationTime, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (r *RevocationChecker) loadCRL(ctx context.Context, crlURL string, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	key := issuerKey(issuer) + "/" + crlURL
+	r.mu.Lock()
+	cached, ok := r.crlCache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.crl, nil
+	}
+
+	body, err := r.fetch(ctx, http.MethodGet, crlURL, nil, maxCRLSize)
+	if err != nil {
+		return nil, err
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL not signed by issuer: %w", err)
+	}
+
+	r.mu.Lock()
+	r.crlCache[key] = cachedCRL{crl: crl, expires: cacheExpiry(crl.NextUpdate)}
+	r.mu.Unlock()
+
+	return crl, nil
+}
+
+func (r *RevocationChecker) fetch(ctx context.Context, method, url string, body []byte, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return data, nil
+}
+
+// issuerKey identifies an issuer by its public key for cache keys
+func issuerKey(issuer *x509.Certificate) string {
+	sum := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheExpiry caches until nextUpdate, capped at revocationCacheTTL
+func cacheExpiry(nextUpdate time.Time) time.Time {
+	limit := time.Now().Add(revocationCacheTTL)
+	if nextUpdate.IsZero() || nextUpdate.After(limit) {
+		return limit
+	}
+	return nextUpdate
+}
+
+// hasMustStaple reports whether cert carries the TLS Feature extension with status_request
+func hasMustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidTLSFeature) {
+			continue
+		}
+		var features []int
+		if _, err := asn1.Unmarshal(ext.Value, &features); err != nil {
+			return false
+		}
+		for _, f := range features {
+			if f == tlsFeatureStatusRequest {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// revocationReason returns the RFC 5280 name of a CRL reason code
+func revocationReason(code int) string {
+	reasons := map[int]string{
+		ocsp.Unspecified:          "unspecified",
+		ocsp.KeyCompromise:        "keyCompromise",
+		ocsp.CACompromise:         "cACompromise",
+		ocsp.AffiliationChanged:   "affiliationChanged",
+		ocsp.Superseded:           "superseded",
+		ocsp.CessationOfOperation: "cessationOfOperation",
+		ocsp.CertificateHold:      "certificateHold",
+		ocsp.RemoveFromCRL:        "removeFromCRL",
+		ocsp.PrivilegeWithdrawn:   "privilegeWithdrawn",
+		ocsp.AACompromise:         "aACompromise",
+	}
+	if reason, ok := reasons[code]; ok {
+		return reason
+	}
+	return fmt.Sprintf("code %d", code)
+}
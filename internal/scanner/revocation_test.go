@@ -0,0 +1,297 @@
+package scanner
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// testCA issues leaf certificates and signs OCSP responses and CRLs
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue creates a leaf with the given serial, OCSP responder and CRL distribution point
+func (ca *testCA) issue(t *testing.T, serial int64, ocspURL, crlURL string, mustStaple bool) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "leaf.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"leaf.test"},
+	}
+	if ocspURL != "" {
+		tmpl.OCSPServer = []string{ocspURL}
+	}
+	if crlURL != "" {
+		tmpl.CRLDistributionPoints = []string{crlURL}
+	}
+	if mustStaple {
+		value, _ := asn1.Marshal([]int{tlsFeatureStatusRequest})
+		tmpl.ExtraExtensions = []pkix.Extension{{Id: oidTLSFeature, Value: value}}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create leaf: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse leaf: %v", err)
+	}
+	return cert
+}
+
+func (ca *testCA) ocspResponse(t *testing.T, serial *big.Int, status int) []byte {
+	t.Helper()
+
+	tmpl := ocsp.Response{
+		Status:       status,
+		SerialNumber: serial,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}
+	if status == ocsp.Revoked {
+		tmpl.RevokedAt = time.Now().Add(-time.Minute).Truncate(time.Second)
+		tmpl.RevocationReason = ocsp.KeyCompromise
+	}
+	resp, err := ocsp.CreateResponse(ca.cert, ca.cert, tmpl, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create OCSP response: %v", err)
+	}
+	return resp
+}
+
+// ocspServer answers OCSP requests with the status registered for the serial; any
+// serial not listed gets a 500
+func (ca *testCA) ocspServer(t *testing.T, statuses map[int64]int) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, ok := statuses[req.SerialNumber.Int64()]
+		if !ok {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(ca.ocspResponse(t, req.SerialNumber, status))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func (ca *testCA) crlServer(t *testing.T, revoked ...int64) *httptest.Server {
+	t.Helper()
+
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Hour),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create CRL: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(der)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func issueTypes(issues []ChainIssue) []string {
+	types := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		types = append(types, issue.Type)
+	}
+	return types
+}
+
+func TestRevocationChecker_OCSP(t *testing.T) {
+	ca := newTestCA(t)
+	srv, _ := ca.ocspServer(t, map[int64]int{10: ocsp.Good, 11: ocsp.Revoked})
+
+	checker := NewRevocationChecker(config.RevocationConfig{}, 5*time.Second, zap.NewNop())
+
+	good := ca.issue(t, 10, srv.URL, "", false)
+	if issues := checker.Check(context.Background(), []*x509.Certificate{good, ca.cert}, nil); len(issues) != 0 {
+		t.Errorf("Check(good) = %v, want no issues", issueTypes(issues))
+	}
+
+	revoked := ca.issue(t, 11, srv.URL, "", false)
+	issues := checker.Check(context.Background(), []*x509.Certificate{revoked, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueRevoked {
+		t.Fatalf("Check(revoked) = %v, want [revoked]", issueTypes(issues))
+	}
+	if issues[0].CertificateIndex != 0 {
+		t.Errorf("CertificateIndex = %d, want 0", issues[0].CertificateIndex)
+	}
+}
+
+func TestRevocationChecker_CachesOCSPResponses(t *testing.T) {
+	ca := newTestCA(t)
+	srv, requests := ca.ocspServer(t, map[int64]int{10: ocsp.Good})
+
+	checker := NewRevocationChecker(config.RevocationConfig{}, 5*time.Second, zap.NewNop())
+	leaf := ca.issue(t, 10, srv.URL, "", false)
+
+	for range 3 {
+		checker.Check(context.Background(), []*x509.Certificate{leaf, ca.cert}, nil)
+	}
+	if *requests != 1 {
+		t.Errorf("responder requests = %d, want 1", *requests)
+	}
+}
+
+func TestRevocationChecker_CRLFallback(t *testing.T) {
+	ca := newTestCA(t)
+	ocspSrv, _ := ca.ocspServer(t, nil) // Always fails
+	crlSrv := ca.crlServer(t, 20)
+
+	checker := NewRevocationChecker(config.RevocationConfig{}, 5*time.Second, zap.NewNop())
+
+	revoked := ca.issue(t, 20, ocspSrv.URL, crlSrv.URL, false)
+	issues := checker.Check(context.Background(), []*x509.Certificate{revoked, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueRevoked {
+		t.Errorf("Check(revoked) = %v, want [revoked]", issueTypes(issues))
+	}
+
+	good := ca.issue(t, 21, ocspSrv.URL, crlSrv.URL, false)
+	if issues := checker.Check(context.Background(), []*x509.Certificate{good, ca.cert}, nil); len(issues) != 0 {
+		t.Errorf("Check(good) = %v, want no issues", issueTypes(issues))
+	}
+}
+
+func TestRevocationChecker_Unavailable(t *testing.T) {
+	ca := newTestCA(t)
+	srv, _ := ca.ocspServer(t, nil)
+
+	checker := NewRevocationChecker(config.RevocationConfig{}, 5*time.Second, zap.NewNop())
+
+	leaf := ca.issue(t, 30, srv.URL, "", false)
+	issues := checker.Check(context.Background(), []*x509.Certificate{leaf, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueOCSPUnavailable {
+		t.Errorf("Check() = %v, want [ocsp_unavailable]", issueTypes(issues))
+	}
+
+	// No responder and no CRL means there is nothing to check
+	bare := ca.issue(t, 31, "", "", false)
+	if issues := checker.Check(context.Background(), []*x509.Certificate{bare, ca.cert}, nil); len(issues) != 0 {
+		t.Errorf("Check(no endpoints) = %v, want no issues", issueTypes(issues))
+	}
+}
+
+func TestRevocationChecker_Stapled(t *testing.T) {
+	ca := newTestCA(t)
+	srv, requests := ca.ocspServer(t, map[int64]int{40: ocsp.Good})
+
+	checker := NewRevocationChecker(config.RevocationConfig{}, 5*time.Second, zap.NewNop())
+
+	// The stapled response wins over the responder's answer
+	leaf := ca.issue(t, 40, srv.URL, "", true)
+	stapled := ca.ocspResponse(t, leaf.SerialNumber, ocsp.Revoked)
+	issues := checker.Check(context.Background(), []*x509.Certificate{leaf, ca.cert}, stapled)
+	if len(issues) != 1 || issues[0].Type != IssueRevoked {
+		t.Errorf("Check(stapled revoked) = %v, want [revoked]", issueTypes(issues))
+	}
+	if *requests != 0 {
+		t.Errorf("responder requests = %d, want 0 with a stapled response", *requests)
+	}
+
+	// Must-Staple without a staple is reported, and the responder is still queried
+	issues = checker.Check(context.Background(), []*x509.Certificate{leaf, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueOCSPMustStapleMissing {
+		t.Errorf("Check(no staple) = %v, want [ocsp_must_staple_missing]", issueTypes(issues))
+	}
+	if *requests != 1 {
+		t.Errorf("responder requests = %d, want 1", *requests)
+	}
+}
+
+func TestRevocationChecker_OverrideURLs(t *testing.T) {
+	ca := newTestCA(t)
+	srv, _ := ca.ocspServer(t, map[int64]int{50: ocsp.Revoked})
+	crlSrv := ca.crlServer(t, 51)
+
+	// Certificates point at unreachable endpoints; the overrides are used instead
+	checker := NewRevocationChecker(config.RevocationConfig{
+		OCSPResponder: srv.URL,
+		CRLURL:        crlSrv.URL,
+	}, 5*time.Second, zap.NewNop())
+
+	viaOCSP := ca.issue(t, 50, "http://ocsp.invalid", "", false)
+	issues := checker.Check(context.Background(), []*x509.Certificate{viaOCSP, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueRevoked {
+		t.Errorf("Check(ocsp override) = %v, want [revoked]", issueTypes(issues))
+	}
+
+	viaCRL := ca.issue(t, 51, "", "http://crl.invalid/ca.crl", false)
+	issues = checker.Check(context.Background(), []*x509.Certificate{viaCRL, ca.cert}, nil)
+	if len(issues) != 1 || issues[0].Type != IssueRevoked {
+		t.Errorf("Check(crl override) = %v, want [revoked]", issueTypes(issues))
+	}
+}
@@ -2,12 +2,17 @@ package scanner
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -20,49 +25,97 @@ import (
 // Scanner handles TLS certificate scanning
 // Fields are ordered for optimal memory alignment
 type Scanner struct {
-	logger      *zap.Logger
-	timeout     time.Duration
-	concurrency int
+	logger     *zap.Logger
+	dialer     Dialer
+	transport  http.RoundTripper // For OCSP, CRL and AIA fetches; nil uses the default
+	revocation *RevocationChecker
+	audit      *TLSAuditor
+	trust      *trustStore
+	lookupIP   func(ctx context.Context, host string) ([]net.IPAddr, error)
+	sem        chan struct{} // Limits concurrent connections; nil means no limit
+	timeout    time.Duration
+}
+
+// Dialer opens the TCP connections of scans, e.g. a *proxy.Dialer
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// httpTransporter is implemented by dialers that also route HTTP requests
+type httpTransporter interface {
+	Transport() *http.Transport
 }
 
 // New creates a new Scanner
 func New(timeout time.Duration, concurrency int, logger *zap.Logger) *Scanner {
+	trust, err := newTrustStore(timeout)
+	if err != nil {
+		logger.Warn("chains will only be trusted via ca_bundle", zap.Error(err))
+	}
+	var sem chan struct{}
+	if concurrency > 0 {
+		sem = make(chan struct{}, concurrency)
+	}
 	return &Scanner{
-		timeout:     timeout,
-		concurrency: concurrency,
-		logger:      logger,
+		sem:      sem,
+		timeout:  timeout,
+		logger:   logger,
+		dialer:   &net.Dialer{Timeout: timeout},
+		trust:    trust,
+		lookupIP: net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// SetDialer routes scan connections through d. If d also provides an HTTP
+// transport, OCSP, CRL and AIA fetches use it as well.
+func (s *Scanner) SetDialer(d Dialer) {
+	s.dialer = d
+	if t, ok := d.(httpTransporter); ok {
+		s.transport = t.Transport()
+		s.trust.httpClient.Transport = s.transport
+		if s.revocation != nil {
+			s.revocation.httpClient.Transport = s.transport
+		}
 	}
 }
 
+// SetRevocationChecker enables OCSP/CRL revocation checks on scanned chains
+func (s *Scanner) SetRevocationChecker(r *RevocationChecker) {
+	if s.transport != nil {
+		r.httpClient.Transport = s.transport
+	}
+	s.revocation = r
+}
+
+// SetTLSAuditor enables probing of the TLS versions and cipher suites each endpoint accepts
+func (s *Scanner) SetTLSAuditor(a *TLSAuditor) {
+	s.audit = a
+}
+
 // ScanAll scans all configured certificates concurrently
 func (s *Scanner) ScanAll(ctx context.Context, certs []config.CertificateConfig) []ScanResult {
 	results := make([]ScanResult, len(certs))
 	var wg sync.WaitGroup
 
-	// Use a semaphore channel for concurrency control
-	sem := make(chan struct{}, s.concurrency)
-
 	for i, cert := range certs {
 		wg.Add(1)
 		go func(idx int, c config.CertificateConfig) {
 			defer wg.Done()
 
+			// Targets scanned on all addresses take a slot per address instead
+			if c.AllAddresses {
+				results[idx] = s.scanAllAddresses(ctx, c)
+				return
+			}
+
 			// Acquire semaphore
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-ctx.Done():
-				results[idx] = ScanResult{
-					Hostname:  c.Hostname,
-					Port:      c.Port,
-					Success:   false,
-					Error:     "context canceled",
-					ScannedAt: time.Now().UTC(),
-				}
+			if !s.acquire(ctx) {
+				results[idx] = canceledResult(c)
 				return
 			}
+			defer s.release()
 
-			results[idx] = s.Scan(ctx, c.Hostname, c.Port)
+			results[idx] = s.Scan(ctx, c)
 		}(i, cert)
 	}
 
@@ -70,36 +123,94 @@ func (s *Scanner) ScanAll(ctx context.Context, certs []config.CertificateConfig)
 	return results
 }
 
-// Scan performs a TLS connection and extracts certificate information
-func (s *Scanner) Scan(ctx context.Context, hostname string, port int) ScanResult {
+// acquire takes a slot of the concurrency limit. It reports false if ctx is
+// done first.
+func (s *Scanner) acquire(ctx context.Context) bool {
+	if s.sem == nil {
+		return true
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire
+func (s *Scanner) release() {
+	if s.sem != nil {
+		<-s.sem
+	}
+}
+
+func canceledResult(c config.CertificateConfig) ScanResult {
+	return ScanResult{
+		Hostname:   c.Hostname,
+		Address:    c.Address,
+		ServerName: c.ServerName,
+		Port:       c.Port,
+		Success:    false,
+		Error:      "context canceled",
+		ScannedAt:  time.Now().UTC(),
+	}
+}
+
+// Scan performs a TLS connection and extracts certificate information.
+// If the certificate is configured with a STARTTLS protocol, the plaintext
+// session is upgraded before the TLS handshake. With AllAddresses set every
+// resolved address is scanned.
+func (s *Scanner) Scan(ctx context.Context, cert config.CertificateConfig) ScanResult {
+	if cert.AllAddresses {
+		return s.scanAllAddresses(ctx, cert)
+	}
+
+	hostname := cert.Hostname
+	port := cert.Port
 	result := ScanResult{
-		Hostname:  hostname,
-		Port:      port,
-		ScannedAt: time.Now().UTC(),
+		Hostname:   hostname,
+		Address:    cert.Address,
+		ServerName: cert.ServerName,
+		Port:       port,
+		StartTLS:   cert.StartTLS,
+		ScannedAt:  time.Now().UTC(),
+	}
+
+	roots, err := s.trust.roots(cert.CABundle)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result
 	}
 
-	addr := fmt.Sprintf("%s:%d", hostname, port)
+	addr := cert.DialAddress()
+
+	// The certificate is checked against the server name sent, or the hostname
+	// when scanning without SNI
+	sni := cert.SNI()
+	verifyName := sni
+	if verifyName == "" {
+		verifyName = hostname
+	}
 
 	// Create TLS config
 	// We intentionally skip TLS verification and validate manually to inspect the full chain
 	tlsConfig := &tls.Config{
-		ServerName:         hostname,
+		ServerName:         sni,
 		InsecureSkipVerify: true, //nolint:gosec // We validate manually to inspect the full certificate chain
 	}
 
-	// Create dialer with timeout
-	dialer := &net.Dialer{
-		Timeout: s.timeout,
-	}
-
 	// Establish connection with context
-	conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	conn, err := s.dialTLS(ctx, addr, cert.StartTLS, verifyName, tlsConfig)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("connection failed: %v", err)
 		s.logger.Debug("scan failed",
 			zap.String("hostname", hostname),
+			zap.String("address", addr),
+			zap.String("server_name", sni),
 			zap.Int("port", port),
+			zap.String("starttls", cert.StartTLS),
 			zap.Error(err),
 		)
 		return result
@@ -117,10 +228,37 @@ func (s *Scanner) Scan(ctx context.Context, hostname string, port int) ScanResul
 	// Parse leaf certificate
 	leaf := state.PeerCertificates[0]
 	result.Success = true
-	result.Certificate = s.parseCertificate(leaf)
+	result.Certificate = ParseCertificate(leaf)
 
 	// Parse chain
-	result.Chain = s.parseChain(state.PeerCertificates, hostname)
+	result.Chain = ParseChain(state.PeerCertificates, verifyName)
+
+	// Verify the presented chain against the trust store
+	for _, issue := range s.trust.verify(ctx, state.PeerCertificates, roots) {
+		if issue.Type == IssueIncompleteChain || issue.Type == IssueUntrustedRoot {
+			result.Chain.Valid = false
+		}
+		result.Chain.Issues = append(result.Chain.Issues, issue)
+	}
+
+	// Check revocation, preferring the stapled OCSP response for the leaf
+	if s.revocation != nil {
+		for _, issue := range s.revocation.Check(ctx, state.PeerCertificates, state.OCSPResponse) {
+			if issue.Type == IssueRevoked {
+				result.Chain.Valid = false
+			}
+			result.Chain.Issues = append(result.Chain.Issues, issue)
+		}
+	}
+
+	// Record the negotiated connection and audit what else the endpoint accepts
+	result.TLS = &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+	if s.audit != nil {
+		s.auditTLS(ctx, addr, sni, verifyName, cert.StartTLS, port, result.TLS)
+	}
 
 	s.logger.Debug("scan successful",
 		zap.String("hostname", hostname),
@@ -132,7 +270,70 @@ func (s *Scanner) Scan(ctx context.Context, hostname string, port int) ScanResul
 	return result
 }
 
-func (s *Scanner) parseCertificate(cert *x509.Certificate) *CertificateInfo {
+// auditTLS probes the endpoint through the TLS auditor and adds its findings to info
+func (s *Scanner) auditTLS(ctx context.Context, addr, sni, hostname, protocol string, port int, info *TLSInfo) {
+	// Bound the audit; it takes a dozen handshakes against slow endpoints
+	ctx, cancel := context.WithTimeout(ctx, 4*s.timeout)
+	defer cancel()
+
+	probe := func(ctx context.Context, cfg *tls.Config) (tls.ConnectionState, error) {
+		cfg.ServerName = sni
+		cfg.InsecureSkipVerify = true //nolint:gosec // Only the negotiated parameters are inspected
+		conn, err := s.dialTLS(ctx, addr, protocol, hostname, cfg)
+		if err != nil {
+			return tls.ConnectionState{}, err
+		}
+		defer conn.Close()
+		return conn.ConnectionState(), nil
+	}
+
+	audit, ok := s.audit.audit(ctx, addr+"/"+sni+"/"+protocol, protocol == "", isHTTPSPort(port), probe)
+	if !ok {
+		s.logger.Debug("tls audit incomplete", zap.String("address", addr))
+		return
+	}
+
+	info.Audited = true
+	info.ALPN = audit.alpn
+	info.SupportedVersions = audit.versions
+	info.CipherSuites = audit.suites
+	info.Issues = audit.issues
+}
+
+// dialTLS connects to addr, performs the optional STARTTLS upgrade announcing
+// hostname and completes the TLS handshake. The whole exchange is bounded by the
+// scanner timeout.
+func (s *Scanner) dialTLS(ctx context.Context, addr, protocol, hostname string, tlsConfig *tls.Config) (*tls.Conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	rawConn, err := s.dialer.DialContext(dialCtx, "tcp", addr)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rawConn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+
+	if protocol != "" {
+		if err := startTLS(rawConn, protocol, hostname); err != nil {
+			rawConn.Close()
+			return nil, fmt.Errorf("%s starttls: %w", protocol, err)
+		}
+	}
+
+	conn := tls.Client(rawConn, tlsConfig)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// ParseCertificate extracts certificate information from a parsed leaf certificate
+func ParseCertificate(cert *x509.Certificate) *CertificateInfo {
 	// Calculate SHA256 fingerprint
 	fingerprint := sha256.Sum256(cert.Raw)
 	fingerprintHex := hex.EncodeToString(fingerprint[:])
@@ -153,6 +354,8 @@ func (s *Scanner) parseCertificate(cert *x509.Certificate) *CertificateInfo {
 		sanList = append(sanList, ip.String())
 	}
 
+	keyAlgorithm, keySize := publicKeyInfo(cert)
+
 	return &CertificateInfo{
 		Subject:           cert.Subject.CommonName,
 		Issuer:            cert.Issuer.CommonName,
@@ -163,10 +366,14 @@ func (s *Scanner) parseCertificate(cert *x509.Certificate) *CertificateInfo {
 		NotAfter:          cert.NotAfter.UTC(),
 		SANList:           sanList,
 		DaysUntilExpiry:   daysUntilExpiry,
+		KeyAlgorithm:      keyAlgorithm,
+		KeySize:           keySize,
 	}
 }
 
-func (s *Scanner) parseChain(certs []*x509.Certificate, hostname string) *ChainInfo {
+// ParseChain builds chain information and runs the chain checks on certs (leaf first).
+// An empty hostname skips the hostname check.
+func ParseChain(certs []*x509.Certificate, hostname string) *ChainInfo {
 	chain := &ChainInfo{
 		Valid:        true,
 		Issues:       make([]ChainIssue, 0),
@@ -215,7 +422,7 @@ func (s *Scanner) parseChain(certs []*x509.Certificate, hostname string) *ChainI
 	}
 
 	// Verify hostname matches
-	if len(certs) > 0 {
+	if len(certs) > 0 && hostname != "" {
 		leaf := certs[0]
 		if err := leaf.VerifyHostname(hostname); err != nil {
 			chain.Issues = append(chain.Issues, ChainIssue{
@@ -240,6 +447,44 @@ func (s *Scanner) parseChain(certs []*x509.Certificate, hostname string) *ChainI
 	return chain
 }
 
+// ParsePEMCertificates decodes all CERTIFICATE blocks from PEM data, e.g. a Kubernetes
+// TLS Secret's tls.crt, preserving their order
+func ParsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d: %w", len(certs), err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// publicKeyInfo returns the key algorithm (RSA, ECDSA, Ed25519) and size in bits
+func publicKeyInfo(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+	return cert.PublicKeyAlgorithm.String(), 0
+}
+
 func isWeakSignature(algo string) bool {
 	weak := []string{"MD2", "MD5", "SHA1"}
 	algo = strings.ToUpper(algo)
@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+func TestScan_ServerNames(t *testing.T) {
+	certs := map[string]tls.Certificate{
+		"a.example.test": newTestCertificate(t, "a.example.test"),
+		"b.example.test": newTestCertificate(t, "b.example.test"),
+	}
+	fallback := newTestCertificate(t, "default.example.test")
+
+	var mu sync.Mutex
+	var sent []string
+	port := startTLSServer(t, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			mu.Lock()
+			sent = append(sent, hello.ServerName)
+			mu.Unlock()
+			if cert, ok := certs[hello.ServerName]; ok {
+				return &cert, nil
+			}
+			return &fallback, nil
+		},
+	})
+
+	s := New(5*time.Second, 1, zap.NewNop())
+
+	tests := []struct {
+		serverName  string
+		wantSent    string
+		wantSubject string
+		wantName    string
+	}{
+		{serverName: "a.example.test", wantSent: "a.example.test", wantSubject: "a.example.test", wantName: "a.example.test@lb.example.test"},
+		{serverName: "b.example.test", wantSent: "b.example.test", wantSubject: "b.example.test", wantName: "b.example.test@lb.example.test"},
+		{serverName: config.NoSNI, wantSent: "", wantSubject: "default.example.test", wantName: "-@lb.example.test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			mu.Lock()
+			sent = nil
+			mu.Unlock()
+
+			result := s.Scan(context.Background(), config.CertificateConfig{
+				Hostname:   "lb.example.test",
+				Address:    "127.0.0.1",
+				ServerName: tt.serverName,
+				Port:       port,
+			})
+
+			if !result.Success {
+				t.Fatalf("Scan() failed: %s", result.Error)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(sent) != 1 || sent[0] != tt.wantSent {
+				t.Errorf("SNI sent = %q, want %q", sent, tt.wantSent)
+			}
+			if result.Certificate.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", result.Certificate.Subject, tt.wantSubject)
+			}
+			if result.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", result.Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+// recordingDialer dials directly and records the addresses
+type recordingDialer struct {
+	addrs []string
+	mu    sync.Mutex
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.mu.Lock()
+	d.addrs = append(d.addrs, address)
+	d.mu.Unlock()
+	return (&net.Dialer{}).DialContext(ctx, network, address)
+}
+
+func TestScan_CustomDialer(t *testing.T) {
+	port := startTLSServer(t, &tls.Config{Certificates: []tls.Certificate{newTestCertificate(t, "localhost")}})
+
+	d := &recordingDialer{}
+	s := New(5*time.Second, 1, zap.NewNop())
+	s.SetDialer(d)
+
+	result := s.Scan(context.Background(), config.CertificateConfig{Hostname: "localhost", Address: "127.0.0.1", Port: port})
+	if !result.Success {
+		t.Fatalf("Scan() failed: %s", result.Error)
+	}
+
+	want := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if len(d.addrs) != 1 || d.addrs[0] != want {
+		t.Errorf("dialed %v, want [%s]", d.addrs, want)
+	}
+}
@@ -0,0 +1,376 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// startTLS negotiates a protocol-specific upgrade to TLS on a plaintext connection.
+// On success the connection is ready for the TLS client handshake.
+func startTLS(conn net.Conn, protocol, hostname string) error {
+	switch protocol {
+	case config.StartTLSSMTP:
+		return startTLSSMTP(conn)
+	case config.StartTLSIMAP:
+		return startTLSIMAP(conn)
+	case config.StartTLSPOP3:
+		return startTLSPOP3(conn)
+	case config.StartTLSLDAP:
+		return startTLSLDAP(conn)
+	case config.StartTLSFTP:
+		return startTLSFTP(conn)
+	case config.StartTLSXMPP:
+		return startTLSXMPP(conn, hostname)
+	case config.StartTLSPostgres:
+		return startTLSPostgres(conn)
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// readReply reads a (possibly multi-line) SMTP/FTP style reply and returns its status code.
+// Continuation lines use "NNN-" and the final line uses "NNN ".
+func readReply(r *bufio.Reader) (string, error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 3 {
+			return "", fmt.Errorf("malformed reply: %q", line)
+		}
+		if len(line) == 3 || line[3] == ' ' {
+			return line[:3], nil
+		}
+	}
+}
+
+// expectReply reads a reply and checks that its status code matches want
+func expectReply(r *bufio.Reader, want string) error {
+	code, err := readReply(r)
+	if err != nil {
+		return err
+	}
+	if code != want {
+		return fmt.Errorf("unexpected reply code %s (want %s)", code, want)
+	}
+	return nil
+}
+
+func writeLine(conn net.Conn, line string) error {
+	_, err := io.WriteString(conn, line+"\r\n")
+	return err
+}
+
+// startTLSSMTP implements RFC 3207
+func startTLSSMTP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	if err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if err := writeLine(conn, "EHLO cw-agent"); err != nil {
+		return err
+	}
+	if err := expectReply(r, "250"); err != nil {
+		return fmt.Errorf("EHLO: %w", err)
+	}
+	if err := writeLine(conn, "STARTTLS"); err != nil {
+		return err
+	}
+	if err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// startTLSFTP implements RFC 4217 explicit FTPS
+func startTLSFTP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	if err := expectReply(r, "220"); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if err := writeLine(conn, "AUTH TLS"); err != nil {
+		return err
+	}
+	if err := expectReply(r, "234"); err != nil {
+		return fmt.Errorf("AUTH TLS: %w", err)
+	}
+	return nil
+}
+
+// startTLSIMAP implements RFC 3501 section 6.2.1
+func startTLSIMAP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting: %q", strings.TrimSpace(greeting))
+	}
+	if err := writeLine(conn, "a001 STARTTLS"); err != nil {
+		return err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+		// Skip untagged responses until our tagged completion arrives
+		if !strings.HasPrefix(line, "a001 ") {
+			continue
+		}
+		if !strings.HasPrefix(line, "a001 OK") {
+			return fmt.Errorf("STARTTLS rejected: %q", strings.TrimSpace(line))
+		}
+		return nil
+	}
+}
+
+// startTLSPOP3 implements RFC 2595 section 4
+func startTLSPOP3(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	greeting, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("unexpected greeting: %q", strings.TrimSpace(greeting))
+	}
+	if err := writeLine(conn, "STLS"); err != nil {
+		return err
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("STLS: %w", err)
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("STLS rejected: %q", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+// xmppStreamHeader opens a client-to-server XMPP stream (RFC 6120)
+const xmppStreamHeader = "<?xml version='1.0'?>" +
+	"<stream:stream to='%s' xmlns='jabber:client' " +
+	"xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>"
+
+// maxXMPPPreamble bounds how much of the stream we buffer while waiting for features
+const maxXMPPPreamble = 64 * 1024
+
+// startTLSXMPP implements RFC 6120 section 5
+func startTLSXMPP(conn net.Conn, hostname string) error {
+	if _, err := fmt.Fprintf(conn, xmppStreamHeader, hostname); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(conn)
+	features, err := readUntil(r, "</stream:features>")
+	if err != nil {
+		return fmt.Errorf("stream features: %w", err)
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return errors.New("server does not offer STARTTLS")
+	}
+
+	if _, err := io.WriteString(conn, "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+
+	reply, err := readUntil(r, "/>")
+	if err != nil {
+		return fmt.Errorf("starttls: %w", err)
+	}
+	if !strings.Contains(reply, "<proceed") {
+		return fmt.Errorf("starttls rejected: %q", strings.TrimSpace(reply))
+	}
+	return nil
+}
+
+// readUntil reads from r until marker has been seen and returns everything read
+func readUntil(r *bufio.Reader, marker string) (string, error) {
+	var sb strings.Builder
+	for sb.Len() < maxXMPPPreamble {
+		b, err := r.ReadByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteByte(b)
+		if strings.HasSuffix(sb.String(), marker) {
+			return sb.String(), nil
+		}
+	}
+	return sb.String(), errors.New("response too large")
+}
+
+// postgresSSLRequestCode is the magic request code defined by the PostgreSQL wire protocol
+const postgresSSLRequestCode = 80877103
+
+// startTLSPostgres sends an SSLRequest message and expects 'S' in reply
+func startTLSPostgres(conn net.Conn) error {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint32(msg[0:4], 8)
+	binary.BigEndian.PutUint32(msg[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("SSLRequest: %w", err)
+	}
+	if resp[0] != 'S' {
+		return errors.New("server does not support SSL")
+	}
+	return nil
+}
+
+// ldapStartTLSOID is the StartTLS extended operation OID (RFC 4511 section 4.14)
+const ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// BER tags used in the LDAP StartTLS exchange
+const (
+	berTagSequence         = 0x30
+	berTagInteger          = 0x02
+	berTagEnumerated       = 0x0a
+	ldapTagExtendedRequest = 0x77 // [APPLICATION 23], constructed
+	ldapTagExtendedResp    = 0x78 // [APPLICATION 24], constructed
+	ldapTagRequestName     = 0x80 // [0], primitive
+)
+
+// startTLSLDAP sends an LDAP StartTLS ExtendedRequest and checks for resultCode success
+func startTLSLDAP(conn net.Conn) error {
+	requestName := berEncode(ldapTagRequestName, []byte(ldapStartTLSOID))
+	extendedReq := berEncode(ldapTagExtendedRequest, requestName)
+	messageID := berEncode(berTagInteger, []byte{1})
+	msg := berEncode(berTagSequence, append(messageID, extendedReq...))
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+
+	tag, body, err := berRead(conn)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if tag != berTagSequence {
+		return fmt.Errorf("unexpected response tag 0x%02x", tag)
+	}
+
+	// LDAPMessage: messageID, protocolOp
+	_, _, rest, err := berSplit(body)
+	if err != nil {
+		return err
+	}
+	tag, op, _, err := berSplit(rest)
+	if err != nil {
+		return err
+	}
+	if tag != ldapTagExtendedResp {
+		return fmt.Errorf("unexpected protocol op 0x%02x", tag)
+	}
+
+	// ExtendedResponse starts with the LDAPResult resultCode
+	tag, code, _, err := berSplit(op)
+	if err != nil {
+		return err
+	}
+	if tag != berTagEnumerated || len(code) != 1 {
+		return errors.New("malformed resultCode")
+	}
+	if code[0] != 0 {
+		return fmt.Errorf("StartTLS rejected with resultCode %d", code[0])
+	}
+	return nil
+}
+
+// berEncode encodes a single tag-length-value element
+func berEncode(tag byte, value []byte) []byte {
+	out := []byte{tag}
+	n := len(value)
+	switch {
+	case n < 0x80:
+		out = append(out, byte(n))
+	case n <= 0xff:
+		out = append(out, 0x81, byte(n))
+	default:
+		out = append(out, 0x82, byte(n>>8), byte(n))
+	}
+	return append(out, value...)
+}
+
+// berSplit decodes the first element of buf and returns its tag, value and the remaining bytes
+func berSplit(buf []byte) (tag byte, value, rest []byte, err error) {
+	if len(buf) < 2 {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	tag = buf[0]
+	length, hdr, err := berLength(buf[1:])
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	start := 1 + hdr
+	if len(buf) < start+length {
+		return 0, nil, nil, errors.New("truncated BER element")
+	}
+	return tag, buf[start : start+length], buf[start+length:], nil
+}
+
+// berLength decodes a definite-form BER length and returns it with the number of bytes consumed
+func berLength(buf []byte) (length, consumed int, err error) {
+	if len(buf) == 0 {
+		return 0, 0, errors.New("truncated BER length")
+	}
+	if buf[0] < 0x80 {
+		return int(buf[0]), 1, nil
+	}
+	n := int(buf[0] & 0x7f)
+	if n == 0 || n > 4 || len(buf) < 1+n {
+		return 0, 0, errors.New("unsupported BER length")
+	}
+	for i := 1; i <= n; i++ {
+		length = length<<8 | int(buf[i])
+	}
+	return length, 1 + n, nil
+}
+
+// maxLDAPResponse bounds the size of the StartTLS response we are willing to read
+const maxLDAPResponse = 64 * 1024
+
+// berRead reads one complete BER element from r
+func berRead(r io.Reader) (tag byte, value []byte, err error) {
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return 0, nil, err
+	}
+	tag = hdr[0]
+
+	lenBytes := hdr[1:]
+	if hdr[1] >= 0x80 {
+		extra := make([]byte, int(hdr[1]&0x7f))
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return 0, nil, err
+		}
+		lenBytes = append(lenBytes, extra...)
+	}
+	length, _, err := berLength(lenBytes)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length > maxLDAPResponse {
+		return 0, nil, errors.New("response too large")
+	}
+
+	value = make([]byte, length)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return 0, nil, err
+	}
+	return tag, value, nil
+}
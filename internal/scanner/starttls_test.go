@@ -0,0 +1,241 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// newTestCertificate creates a self-signed certificate for the given common name
+func newTestCertificate(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(30 * 24 * time.Hour),
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startFakeServer accepts a single connection, runs the plaintext exchange and then serves TLS
+func startFakeServer(t *testing.T, cert tls.Certificate, exchange func(conn net.Conn, r *bufio.Reader) bool) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		if !exchange(conn, bufio.NewReader(conn)) {
+			return
+		}
+
+		tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}})
+		_ = tlsConn.Handshake()
+		_, _ = io.Copy(io.Discard, tlsConn)
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func expectLine(r *bufio.Reader, prefix string) bool {
+	line, err := r.ReadString('\n')
+	return err == nil && strings.HasPrefix(line, prefix)
+}
+
+func TestScan_StartTLS(t *testing.T) {
+	cert := newTestCertificate(t, "starttls.test")
+
+	tests := []struct {
+		name     string
+		protocol string
+		exchange func(conn net.Conn, r *bufio.Reader) bool
+	}{
+		{
+			name:     "smtp",
+			protocol: config.StartTLSSMTP,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				io.WriteString(conn, "220-mail.test ESMTP\r\n220 ready\r\n")
+				if !expectLine(r, "EHLO") {
+					return false
+				}
+				io.WriteString(conn, "250-mail.test\r\n250-PIPELINING\r\n250 STARTTLS\r\n")
+				if !expectLine(r, "STARTTLS") {
+					return false
+				}
+				io.WriteString(conn, "220 go ahead\r\n")
+				return true
+			},
+		},
+		{
+			name:     "imap",
+			protocol: config.StartTLSIMAP,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+				if !expectLine(r, "a001 STARTTLS") {
+					return false
+				}
+				io.WriteString(conn, "* CAPABILITY IMAP4rev1\r\na001 OK begin TLS\r\n")
+				return true
+			},
+		},
+		{
+			name:     "pop3",
+			protocol: config.StartTLSPOP3,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				io.WriteString(conn, "+OK POP3 ready\r\n")
+				if !expectLine(r, "STLS") {
+					return false
+				}
+				io.WriteString(conn, "+OK begin TLS\r\n")
+				return true
+			},
+		},
+		{
+			name:     "ftp",
+			protocol: config.StartTLSFTP,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				io.WriteString(conn, "220 FTP ready\r\n")
+				if !expectLine(r, "AUTH TLS") {
+					return false
+				}
+				io.WriteString(conn, "234 proceed\r\n")
+				return true
+			},
+		},
+		{
+			name:     "xmpp",
+			protocol: config.StartTLSXMPP,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				if _, err := readUntil(r, "version='1.0'>"); err != nil {
+					return false
+				}
+				io.WriteString(conn, "<stream:stream from='starttls.test' version='1.0'>"+
+					"<stream:features><starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>")
+				if _, err := readUntil(r, "/>"); err != nil {
+					return false
+				}
+				io.WriteString(conn, "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")
+				return true
+			},
+		},
+		{
+			name:     "postgres",
+			protocol: config.StartTLSPostgres,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				buf := make([]byte, 8)
+				if _, err := io.ReadFull(r, buf); err != nil {
+					return false
+				}
+				conn.Write([]byte{'S'})
+				return true
+			},
+		},
+		{
+			name:     "ldap",
+			protocol: config.StartTLSLDAP,
+			exchange: func(conn net.Conn, r *bufio.Reader) bool {
+				tag, body, err := berRead(r)
+				if err != nil || tag != berTagSequence || !strings.Contains(string(body), ldapStartTLSOID) {
+					return false
+				}
+				result := append(berEncode(berTagEnumerated, []byte{0}),
+					append(berEncode(0x04, nil), berEncode(0x04, nil)...)...)
+				resp := berEncode(berTagSequence, append(
+					berEncode(berTagInteger, []byte{1}),
+					berEncode(ldapTagExtendedResp, result)...))
+				conn.Write(resp)
+				return true
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := startFakeServer(t, cert, tt.exchange)
+			s := New(3*time.Second, 1, zap.NewNop())
+
+			result := s.Scan(context.Background(), config.CertificateConfig{
+				Hostname: "127.0.0.1",
+				Port:     port,
+				StartTLS: tt.protocol,
+			})
+
+			if !result.Success {
+				t.Fatalf("scan failed: %s", result.Error)
+			}
+			if result.Certificate.Subject != "starttls.test" {
+				t.Errorf("Subject = %v, want starttls.test", result.Certificate.Subject)
+			}
+			if result.StartTLS != tt.protocol {
+				t.Errorf("StartTLS = %v, want %v", result.StartTLS, tt.protocol)
+			}
+		})
+	}
+}
+
+func TestScan_StartTLSRejected(t *testing.T) {
+	cert := newTestCertificate(t, "starttls.test")
+
+	port := startFakeServer(t, cert, func(conn net.Conn, r *bufio.Reader) bool {
+		buf := make([]byte, 8)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return false
+		}
+		conn.Write([]byte{'N'})
+		return false
+	})
+
+	s := New(3*time.Second, 1, zap.NewNop())
+	result := s.Scan(context.Background(), config.CertificateConfig{
+		Hostname: "127.0.0.1",
+		Port:     port,
+		StartTLS: config.StartTLSPostgres,
+	})
+
+	if result.Success {
+		t.Fatal("expected scan to fail when server refuses SSL")
+	}
+	if !strings.Contains(result.Error, "does not support SSL") {
+		t.Errorf("Error = %v, want SSL refusal", result.Error)
+	}
+}
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// TLS audit issue types
+const (
+	IssueDeprecatedProtocol = "deprecated_protocol"
+	IssueWeakCipher         = "weak_cipher"
+	IssueNoForwardSecrecy   = "no_forward_secrecy"
+	IssueNoALPN             = "no_alpn"
+)
+
+// auditVersions are the protocol versions probed, oldest first. SSL 3.0 can't be
+// negotiated by crypto/tls.
+var auditVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// AuditedVersions returns the names of the protocol versions an audit probes
+func AuditedVersions() []string {
+	names := make([]string, 0, len(auditVersions))
+	for _, v := range auditVersions {
+		names = append(names, tls.VersionName(v))
+	}
+	return names
+}
+
+// alpnProtocols are offered when checking ALPN support
+var alpnProtocols = []string{"h2", "http/1.1"}
+
+// probeFunc performs a handshake with the given client config and returns the
+// negotiated connection state
+type probeFunc func(ctx context.Context, cfg *tls.Config) (tls.ConnectionState, error)
+
+// TLSAuditor probes which TLS versions and cipher suites endpoints accept. Results
+// are reused for the configured interval since server configurations rarely
+// change and probing takes a dozen handshakes.
+// Fields are ordered for optimal memory alignment
+type TLSAuditor struct {
+	cache    map[string]cachedAudit
+	interval time.Duration
+	mu       sync.Mutex
+}
+
+type cachedAudit struct {
+	expires time.Time
+	result  tlsAudit
+}
+
+// tlsAudit is the outcome of probing one endpoint
+type tlsAudit struct {
+	alpn     string
+	versions []string
+	suites   []string
+	issues   []ChainIssue
+}
+
+// NewTLSAuditor creates an auditor
+func NewTLSAuditor(cfg config.TLSAuditConfig) *TLSAuditor {
+	return &TLSAuditor{
+		cache:    make(map[string]cachedAudit),
+		interval: cfg.Interval,
+	}
+}
+
+// audit returns the cached result for key or probes the endpoint. ALPN is only
+// negotiated when checkALPN is set, i.e. for endpoints that aren't STARTTLS
+// services, and its absence is only an issue when requireALPN is set as well.
+func (a *TLSAuditor) audit(ctx context.Context, key string, checkALPN, requireALPN bool, probe probeFunc) (tlsAudit, bool) {
+	a.mu.Lock()
+	cached, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.result, true
+	}
+
+	result := probeEndpoint(ctx, checkALPN, requireALPN, probe)
+	// An endpoint that accepts no version at all or an interrupted audit is most
+	// likely a network failure; don't remember it
+	if len(result.versions) == 0 || ctx.Err() != nil {
+		return tlsAudit{}, false
+	}
+
+	a.mu.Lock()
+	a.cache[key] = cachedAudit{result: result, expires: time.Now().Add(a.interval)}
+	a.mu.Unlock()
+	return result, true
+}
+
+// isHTTPSPort reports whether port is one HTTPS is usually served on. Other
+// direct-TLS services such as SMTPS or LDAPS don't use ALPN.
+func isHTTPSPort(port int) bool {
+	return port == 443 || port == 8443
+}
+
+// probeEndpoint runs the handshakes of an audit
+func probeEndpoint(ctx context.Context, checkALPN, requireALPN bool, probe probeFunc) tlsAudit {
+	var result tlsAudit
+
+	// Accepted protocol versions, each offered alone with every suite Go supports
+	var legacy uint16 // Highest accepted version up to TLS 1.2
+	for _, v := range auditVersions {
+		cfg := &tls.Config{MinVersion: v, MaxVersion: v}
+		if v != tls.VersionTLS13 {
+			cfg.CipherSuites = suitesFor(v, nil)
+		}
+		state, err := probe(ctx, cfg)
+		if err != nil {
+			continue
+		}
+		result.versions = append(result.versions, tls.VersionName(v))
+		if v == tls.VersionTLS13 {
+			// TLS 1.3 suites are all strong and can't be restricted by the client
+			result.suites = append(result.suites, tls.CipherSuiteName(state.CipherSuite))
+		} else {
+			legacy = v
+		}
+		if v < tls.VersionTLS12 {
+			result.issues = append(result.issues, ChainIssue{
+				Type:    IssueDeprecatedProtocol,
+				Message: fmt.Sprintf("Server accepts deprecated protocol %s", tls.VersionName(v)),
+			})
+		}
+	}
+
+	// Accepted pre-TLS 1.3 suites: offer all, remove the one the server picks and
+	// repeat until the handshake fails
+	if legacy != 0 {
+		var excluded []uint16
+		var noPFS []string
+		for range suitesFor(legacy, nil) {
+			suites := suitesFor(legacy, excluded)
+			if len(suites) == 0 {
+				break
+			}
+			state, err := probe(ctx, &tls.Config{MinVersion: legacy, MaxVersion: legacy, CipherSuites: suites})
+			if err != nil || slices.Contains(excluded, state.CipherSuite) {
+				break
+			}
+			excluded = append(excluded, state.CipherSuite)
+
+			name := tls.CipherSuiteName(state.CipherSuite)
+			result.suites = append(result.suites, name)
+			if isWeakSuite(name) {
+				result.issues = append(result.issues, ChainIssue{
+					Type:    IssueWeakCipher,
+					Message: fmt.Sprintf("Server accepts weak cipher suite %s", name),
+				})
+			}
+			if !strings.Contains(name, "_ECDHE_") {
+				noPFS = append(noPFS, name)
+			}
+		}
+		if len(noPFS) > 0 {
+			result.issues = append(result.issues, ChainIssue{
+				Type:    IssueNoForwardSecrecy,
+				Message: fmt.Sprintf("Server accepts cipher suites without forward secrecy: %s", strings.Join(noPFS, ", ")),
+			})
+		}
+	}
+
+	if checkALPN {
+		state, err := probe(ctx, &tls.Config{MinVersion: tls.VersionTLS10, NextProtos: alpnProtocols})
+		if err == nil {
+			result.alpn = state.NegotiatedProtocol
+		}
+		if result.alpn == "" && requireALPN {
+			result.issues = append(result.issues, ChainIssue{
+				Type:    IssueNoALPN,
+				Message: fmt.Sprintf("Server does not negotiate ALPN (offered %s)", strings.Join(alpnProtocols, ", ")),
+			})
+		}
+	}
+
+	return result
+}
+
+// suitesFor returns the IDs of all suites crypto/tls implements for version v,
+// secure and insecure, minus excluded
+func suitesFor(v uint16, excluded []uint16) []uint16 {
+	var ids []uint16
+	for _, list := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, s := range list {
+			if slices.Contains(s.SupportedVersions, v) && !slices.Contains(excluded, s.ID) {
+				ids = append(ids, s.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// isWeakSuite reports whether the suite uses a broken cipher (RC4, 3DES) or CBC
+// with SHA-256, which has no constant-time implementation. Suites crypto/tls only
+// lists as insecure for lacking forward secrecy are reported separately.
+func isWeakSuite(name string) bool {
+	return strings.Contains(name, "_RC4_") || strings.Contains(name, "_3DES_") || strings.HasSuffix(name, "_CBC_SHA256")
+}
@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// newRSATestCertificate creates a self-signed RSA certificate, needed for the RSA
+// key exchange suites
+func newRSATestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(30 * 24 * time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startTLSServer serves TLS handshakes with cfg until the test ends
+func startTLSServer(t *testing.T, cfg *tls.Config) int {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+				_ = conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func auditScan(t *testing.T, port int) *TLSInfo {
+	t.Helper()
+
+	s := New(5*time.Second, 1, zap.NewNop())
+	s.SetTLSAuditor(NewTLSAuditor(config.TLSAuditConfig{Enabled: true, Interval: time.Hour}))
+	result := s.Scan(context.Background(), config.CertificateConfig{Hostname: "localhost", Port: port})
+	if !result.Success || result.TLS == nil {
+		t.Fatalf("Scan() = %+v, want success with TLS info", result)
+	}
+	return result.TLS
+}
+
+func TestScan_TLSAuditLegacyServer(t *testing.T) {
+	port := startTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{newRSATestCertificate(t)},
+		MinVersion:   tls.VersionTLS10,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+		},
+	})
+
+	info := auditScan(t, port)
+
+	if info.Version != "TLS 1.2" || info.CipherSuite != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+		t.Errorf("negotiated %s %s, want TLS 1.2 with ECDHE", info.Version, info.CipherSuite)
+	}
+	if want := []string{"TLS 1.0", "TLS 1.1", "TLS 1.2"}; !slices.Equal(info.SupportedVersions, want) {
+		t.Errorf("SupportedVersions = %v, want %v", info.SupportedVersions, want)
+	}
+	if len(info.CipherSuites) != 3 {
+		t.Errorf("CipherSuites = %v, want the 3 configured suites", info.CipherSuites)
+	}
+
+	got := issueTypes(info.Issues)
+	want := []string{IssueDeprecatedProtocol, IssueDeprecatedProtocol, IssueWeakCipher, IssueNoForwardSecrecy}
+	if !slices.Equal(got, want) {
+		t.Errorf("issues = %v, want %v", got, want)
+	}
+}
+
+func TestScan_TLSAuditModernServer(t *testing.T) {
+	port := startTLSServer(t, &tls.Config{
+		Certificates: []tls.Certificate{newTestCertificate(t, "localhost")},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	})
+
+	info := auditScan(t, port)
+
+	if info.Version != "TLS 1.3" {
+		t.Errorf("Version = %s, want TLS 1.3", info.Version)
+	}
+	if want := []string{"TLS 1.2", "TLS 1.3"}; !slices.Equal(info.SupportedVersions, want) {
+		t.Errorf("SupportedVersions = %v, want %v", info.SupportedVersions, want)
+	}
+	if info.ALPN != "h2" {
+		t.Errorf("ALPN = %q, want h2", info.ALPN)
+	}
+	if len(info.Issues) != 0 {
+		t.Errorf("issues = %+v, want none", info.Issues)
+	}
+}
+
+func TestTLSAuditor_Cache(t *testing.T) {
+	a := NewTLSAuditor(config.TLSAuditConfig{Enabled: true, Interval: time.Hour})
+	probes := 0
+	up := false
+	probe := func(_ context.Context, cfg *tls.Config) (tls.ConnectionState, error) {
+		probes++
+		if !up || cfg.MinVersion != tls.VersionTLS13 {
+			return tls.ConnectionState{}, errors.New("handshake failure")
+		}
+		return tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}, nil
+	}
+
+	// Nothing accepted looks like an outage and isn't cached
+	if _, ok := a.audit(context.Background(), "example:443/", false, false, probe); ok {
+		t.Error("audit() ok = true for an unreachable endpoint, want false")
+	}
+
+	up = true
+	first, ok := a.audit(context.Background(), "example:443/", false, false, probe)
+	if !ok || !slices.Equal(first.versions, []string{"TLS 1.3"}) {
+		t.Fatalf("audit() = %+v, %v, want TLS 1.3 only", first, ok)
+	}
+
+	before := probes
+	if _, ok := a.audit(context.Background(), "example:443/", false, false, probe); !ok || probes != before {
+		t.Errorf("probes = %d, want %d (cached)", probes, before)
+	}
+}
+
+func TestTLSAuditor_ALPN(t *testing.T) {
+	probe := func(_ context.Context, cfg *tls.Config) (tls.ConnectionState, error) {
+		if cfg.MaxVersion != 0 && cfg.MaxVersion != tls.VersionTLS13 {
+			return tls.ConnectionState{}, errors.New("handshake failure")
+		}
+		return tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}, nil
+	}
+
+	// No ALPN is only an issue where HTTPS is expected
+	a := NewTLSAuditor(config.TLSAuditConfig{Enabled: true, Interval: time.Hour})
+	result, ok := a.audit(context.Background(), "example:443/", true, isHTTPSPort(443), probe)
+	if got := issueTypes(result.issues); !ok || !slices.Equal(got, []string{IssueNoALPN}) {
+		t.Errorf("HTTPS issues = %v, want [%s]", got, IssueNoALPN)
+	}
+	result, ok = a.audit(context.Background(), "example:636/", true, isHTTPSPort(636), probe)
+	if !ok || len(result.issues) != 0 {
+		t.Errorf("LDAPS issues = %+v, want none", result.issues)
+	}
+}
@@ -4,6 +4,8 @@ package scanner
 import (
 	"fmt"
 	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
 )
 
 // ScanResult represents the result of scanning a single certificate
@@ -11,13 +13,28 @@ import (
 type ScanResult struct {
 	Certificate *CertificateInfo
 	Chain       *ChainInfo
+	TLS         *TLSInfo
 	Hostname    string
+	Address     string // Host or IP connected to when not the hostname
+	ServerName  string // Configured server name; empty sends the hostname, config.NoSNI none
+	StartTLS    string
 	Error       string
+	Addresses   []AddressResult // Every resolved address when scanned with all_addresses
 	ScannedAt   time.Time
 	Port        int
 	Success     bool
 }
 
+// AddressResult is the outcome of scanning one resolved address of a hostname
+// Fields are ordered for optimal memory alignment
+type AddressResult struct {
+	NotAfter          time.Time
+	Address           string
+	FingerprintSHA256 string
+	Error             string
+	Success           bool
+}
+
 // CertificateInfo contains parsed certificate information
 type CertificateInfo struct {
 	Subject           string
@@ -25,10 +42,12 @@ type CertificateInfo struct {
 	IssuerOrg         string
 	SerialNumber      string
 	FingerprintSHA256 string
+	KeyAlgorithm      string
 	SANList           []string
 	NotBefore         time.Time
 	NotAfter          time.Time
 	DaysUntilExpiry   int
+	KeySize           int
 }
 
 // ChainInfo contains certificate chain information
@@ -39,6 +58,19 @@ type ChainInfo struct {
 	Valid        bool
 }
 
+// TLSInfo describes the negotiated TLS connection and, when audited, the
+// protocol versions and cipher suites the endpoint accepts
+// Fields are ordered for optimal memory alignment
+type TLSInfo struct {
+	Version           string       // Negotiated by the scan, e.g. "TLS 1.3"
+	CipherSuite       string       // Negotiated by the scan
+	ALPN              string       // Protocol selected when offered h2 and http/1.1
+	SupportedVersions []string     // Accepted protocol versions
+	CipherSuites      []string     // Accepted cipher suites
+	Issues            []ChainIssue // deprecated_protocol, weak_cipher, no_forward_secrecy, no_alpn
+	Audited           bool         // Whether versions and cipher suites were probed
+}
+
 // ChainIssue represents an issue with the certificate chain
 type ChainIssue struct {
 	Type             string `json:"type"`
@@ -55,9 +87,15 @@ type ChainCertificate struct {
 	NotAfter  time.Time `json:"not_after"`
 }
 
-// GetHostPort returns the hostname:port string
+// GetHostPort returns the name:port string
 func (r *ScanResult) GetHostPort() string {
-	return formatHostPort(r.Hostname, r.Port)
+	return formatHostPort(r.Name(), r.Port)
+}
+
+// Name identifies the scanned target: its hostname, or sni@hostname when a
+// different server name was sent
+func (r *ScanResult) Name() string {
+	return config.TargetName(r.Hostname, r.ServerName)
 }
 
 func formatHostPort(hostname string, port int) string {
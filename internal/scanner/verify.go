@@ -0,0 +1,283 @@
+package scanner
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Trust issue types
+const (
+	IssueIncompleteChain   = "incomplete_chain"
+	IssueUntrustedRoot     = "untrusted_root"
+	IssueWrongOrder        = "wrong_order"
+	IssueExtraCertificates = "extra_certificates"
+)
+
+const (
+	// maxAIAFetches bounds how many missing intermediates are fetched for one chain
+	maxAIAFetches = 3
+	maxAIASize    = 1 << 20
+)
+
+// trustStore holds the roots chains are verified against and caches intermediates
+// fetched via AIA so an incomplete chain isn't re-downloaded on every scan
+// Fields are ordered for optimal memory alignment
+type trustStore struct {
+	system     *x509.CertPool
+	httpClient *http.Client
+	aia        map[string]*x509.Certificate // key: caIssuers URL
+	mu         sync.Mutex
+}
+
+func newTrustStore(timeout time.Duration) (*trustStore, error) {
+	system, err := x509.SystemCertPool()
+	if err != nil {
+		return &trustStore{
+			system:     x509.NewCertPool(),
+			httpClient: &http.Client{Timeout: timeout},
+			aia:        make(map[string]*x509.Certificate),
+		}, fmt.Errorf("failed to load system roots: %w", err)
+	}
+	return &trustStore{
+		system:     system,
+		httpClient: &http.Client{Timeout: timeout},
+		aia:        make(map[string]*x509.Certificate),
+	}, nil
+}
+
+// roots returns the system roots plus the certificates in caBundle, if set
+func (t *trustStore) roots(caBundle string) (*x509.CertPool, error) {
+	if caBundle == "" {
+		return t.system, nil
+	}
+	data, err := os.ReadFile(caBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_bundle: %w", err)
+	}
+	certs, err := ParsePEMCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ca_bundle: %w", err)
+	}
+	pool := t.system.Clone()
+	for _, c := range certs {
+		pool.AddCert(c)
+	}
+	return pool, nil
+}
+
+// verify validates certs (leaf first, as presented by the server) against roots and
+// reports how the presented chain differs from a valid path. Hostname and validity
+// period are checked by ParseChain, so verification runs at a time inside the leaf's
+// validity window and ignores the hostname.
+func (t *trustStore) verify(ctx context.Context, certs []*x509.Certificate, roots *x509.CertPool) []ChainIssue {
+	if len(certs) == 0 {
+		return nil
+	}
+	leaf := certs[0]
+	var issues []ChainIssue
+
+	path := presentedPath(certs)
+	for i := 1; i < len(path); i++ {
+		if path[i] < path[i-1] {
+			issues = append(issues, ChainIssue{
+				Type: IssueWrongOrder,
+				Message: fmt.Sprintf("Issuer %q is sent before the certificate it signed, %q",
+					certs[path[i]].Subject.CommonName, certs[path[i-1]].Subject.CommonName),
+				CertificateIndex: path[i],
+			})
+			break
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime(leaf),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		issues = append(issues, t.trustIssue(ctx, certs[path[len(path)-1]], opts, err))
+		return append(issues, extraCertificates(certs, inPath(path))...)
+	}
+
+	used := make(map[int]bool)
+	for _, chain := range chains {
+		for _, c := range chain {
+			for i := range certs {
+				if certs[i].Equal(c) {
+					used[i] = true
+				}
+			}
+		}
+	}
+	return append(issues, extraCertificates(certs, used)...)
+}
+
+// trustIssue explains a verification failure. last is the final certificate of the
+// presented path; if it isn't a root, its issuer is fetched via AIA to tell a missing
+// intermediate apart from an untrusted root.
+func (t *trustStore) trustIssue(ctx context.Context, last *x509.Certificate, opts x509.VerifyOptions, verifyErr error) ChainIssue {
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(verifyErr, &unknown) {
+		return ChainIssue{
+			Type:    IssueUntrustedRoot,
+			Message: fmt.Sprintf("Chain verification failed: %v", verifyErr),
+		}
+	}
+
+	if isSelfSigned(last) {
+		return ChainIssue{
+			Type:    IssueUntrustedRoot,
+			Message: fmt.Sprintf("Root %q is not in the trust store", last.Subject.CommonName),
+		}
+	}
+
+	if len(last.IssuingCertificateURL) == 0 {
+		return ChainIssue{
+			Type:    IssueUntrustedRoot,
+			Message: fmt.Sprintf("Issuer %q is not in the trust store", last.Issuer.CommonName),
+		}
+	}
+
+	// Follow AIA caIssuers until the chain verifies or no more issuers can be fetched
+	current := last
+	for range maxAIAFetches {
+		if len(current.IssuingCertificateURL) == 0 {
+			break
+		}
+		issuer, err := t.fetchIssuer(ctx, current.IssuingCertificateURL[0])
+		if err != nil || current.CheckSignatureFrom(issuer) != nil {
+			break
+		}
+		opts.Intermediates.AddCert(issuer)
+		if _, err := current.Verify(opts); err == nil {
+			return ChainIssue{
+				Type:    IssueIncompleteChain,
+				Message: fmt.Sprintf("Server did not send intermediate %q", issuer.Subject.CommonName),
+			}
+		}
+		current = issuer
+	}
+
+	return ChainIssue{
+		Type:    IssueIncompleteChain,
+		Message: fmt.Sprintf("Issuer %q of %q was not sent and could not be verified", last.Issuer.CommonName, last.Subject.CommonName),
+	}
+}
+
+func (t *trustStore) fetchIssuer(ctx context.Context, url string) (*x509.Certificate, error) {
+	t.mu.Lock()
+	cached, ok := t.aia[url]
+	t.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAIASize))
+	if err != nil {
+		return nil, err
+	}
+
+	// caIssuers is usually DER, but some CAs serve PEM
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		certs, pemErr := ParsePEMCertificates(data)
+		if pemErr != nil {
+			return nil, fmt.Errorf("invalid issuer certificate: %w", err)
+		}
+		cert = certs[0]
+	}
+
+	t.mu.Lock()
+	t.aia[url] = cert
+	t.mu.Unlock()
+	return cert, nil
+}
+
+// presentedPath follows issuers through the presented certificates starting at the
+// leaf and returns their indices in path order
+func presentedPath(certs []*x509.Certificate) []int {
+	path := []int{0}
+	used := map[int]bool{0: true}
+	current := certs[0]
+	for !isSelfSigned(current) {
+		next := -1
+		for j, c := range certs {
+			if !used[j] && current.CheckSignatureFrom(c) == nil {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		path = append(path, next)
+		used[next] = true
+		current = certs[next]
+	}
+	return path
+}
+
+func inPath(path []int) map[int]bool {
+	used := make(map[int]bool, len(path))
+	for _, idx := range path {
+		used[idx] = true
+	}
+	return used
+}
+
+// extraCertificates reports presented certificates that are not part of the chain
+func extraCertificates(certs []*x509.Certificate, used map[int]bool) []ChainIssue {
+	var issues []ChainIssue
+	for i := 1; i < len(certs); i++ {
+		if !used[i] {
+			issues = append(issues, ChainIssue{
+				Type:             IssueExtraCertificates,
+				Message:          fmt.Sprintf("Certificate %q is not part of the chain", certs[i].Subject.CommonName),
+				CertificateIndex: i,
+			})
+		}
+	}
+	return issues
+}
+
+// verifyTime returns now clamped into the leaf's validity period
+func verifyTime(leaf *x509.Certificate) time.Time {
+	now := time.Now()
+	switch {
+	case now.After(leaf.NotAfter):
+		return leaf.NotAfter.Add(-time.Second)
+	case now.Before(leaf.NotBefore):
+		return leaf.NotBefore.Add(time.Second)
+	}
+	return now
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.Subject.String() == cert.Issuer.String() && cert.CheckSignatureFrom(cert) == nil
+}
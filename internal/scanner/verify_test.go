@@ -0,0 +1,256 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// testPKI is a root -> intermediate -> leaf hierarchy
+type testPKI struct {
+	root         *testCA
+	intermediate *testCA
+	leaf         tls.Certificate
+	leafCert     *x509.Certificate
+}
+
+// newTestPKI builds a hierarchy; aiaURL, if set, is the leaf's caIssuers URL
+func newTestPKI(t *testing.T, aiaURL string) *testPKI {
+	t.Helper()
+
+	root := newTestCA(t)
+
+	interKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	interTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, interTmpl, root.cert, &interKey.PublicKey, root.key)
+	if err != nil {
+		t.Fatalf("failed to create intermediate: %v", err)
+	}
+	interCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse intermediate: %v", err)
+	}
+	inter := &testCA{cert: interCert, key: interKey}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "leaf.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"leaf.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if aiaURL != "" {
+		leafTmpl.IssuingCertificateURL = []string{aiaURL}
+	}
+	der, err = x509.CreateCertificate(rand.Reader, leafTmpl, interCert, &leafKey.PublicKey, interKey)
+	if err != nil {
+		t.Fatalf("failed to create leaf: %v", err)
+	}
+	leafCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse leaf: %v", err)
+	}
+
+	return &testPKI{
+		root:         root,
+		intermediate: inter,
+		leaf:         tls.Certificate{Certificate: [][]byte{der, interCert.Raw}, PrivateKey: leafKey},
+		leafCert:     leafCert,
+	}
+}
+
+func (p *testPKI) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(p.root.cert)
+	return pool
+}
+
+func newTestTrustStore() *trustStore {
+	return &trustStore{
+		system:     x509.NewCertPool(),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		aia:        make(map[string]*x509.Certificate),
+	}
+}
+
+func TestTrustStore_Verify(t *testing.T) {
+	pki := newTestPKI(t, "")
+	other := newTestCA(t)
+
+	tests := []struct {
+		name      string
+		certs     []*x509.Certificate
+		roots     *x509.CertPool
+		wantTypes []string
+		wantIndex int
+	}{
+		{
+			name:  "complete chain",
+			certs: []*x509.Certificate{pki.leafCert, pki.intermediate.cert},
+			roots: pki.roots(),
+		},
+		{
+			name:  "root included",
+			certs: []*x509.Certificate{pki.leafCert, pki.intermediate.cert, pki.root.cert},
+			roots: pki.roots(),
+		},
+		{
+			name:      "untrusted root sent",
+			certs:     []*x509.Certificate{pki.leafCert, pki.intermediate.cert, pki.root.cert},
+			roots:     x509.NewCertPool(),
+			wantTypes: []string{IssueUntrustedRoot},
+		},
+		{
+			name:      "untrusted private issuer",
+			certs:     []*x509.Certificate{pki.leafCert},
+			roots:     x509.NewCertPool(),
+			wantTypes: []string{IssueUntrustedRoot},
+		},
+		{
+			name:      "wrong order",
+			certs:     []*x509.Certificate{pki.leafCert, pki.root.cert, pki.intermediate.cert},
+			roots:     pki.roots(),
+			wantTypes: []string{IssueWrongOrder},
+			wantIndex: 1,
+		},
+		{
+			name:      "extra certificate",
+			certs:     []*x509.Certificate{pki.leafCert, pki.intermediate.cert, other.cert},
+			roots:     pki.roots(),
+			wantTypes: []string{IssueExtraCertificates},
+			wantIndex: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := newTestTrustStore().verify(context.Background(), tt.certs, tt.roots)
+			got := issueTypes(issues)
+			if len(got) != len(tt.wantTypes) {
+				t.Fatalf("verify() = %v, want %v", got, tt.wantTypes)
+			}
+			for i := range got {
+				if got[i] != tt.wantTypes[i] {
+					t.Errorf("verify() = %v, want %v", got, tt.wantTypes)
+				}
+			}
+			if len(issues) > 0 && issues[0].CertificateIndex != tt.wantIndex {
+				t.Errorf("CertificateIndex = %d, want %d", issues[0].CertificateIndex, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestTrustStore_IncompleteChain(t *testing.T) {
+	var intermediate []byte
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write(intermediate)
+	}))
+	t.Cleanup(srv.Close)
+
+	pki := newTestPKI(t, srv.URL+"/intermediate.der")
+	intermediate = pki.intermediate.cert.Raw
+
+	store := newTestTrustStore()
+	for range 2 {
+		issues := store.verify(context.Background(), []*x509.Certificate{pki.leafCert}, pki.roots())
+		if len(issues) != 1 || issues[0].Type != IssueIncompleteChain {
+			t.Fatalf("verify() = %v, want [incomplete_chain]", issueTypes(issues))
+		}
+	}
+	if requests != 1 {
+		t.Errorf("AIA requests = %d, want 1 (cached)", requests)
+	}
+
+	// The issuer can't be fetched, but the AIA URL shows an intermediate is missing
+	srv.Close()
+	issues := newTestTrustStore().verify(context.Background(), []*x509.Certificate{pki.leafCert}, pki.roots())
+	if len(issues) != 1 || issues[0].Type != IssueIncompleteChain {
+		t.Errorf("verify(AIA down) = %v, want [incomplete_chain]", issueTypes(issues))
+	}
+}
+
+func TestScan_CABundle(t *testing.T) {
+	pki := newTestPKI(t, "")
+	noExchange := func(net.Conn, *bufio.Reader) bool { return true }
+
+	s := New(5*time.Second, 1, zap.NewNop())
+
+	// Without the bundle the private root is untrusted
+	port := startFakeServer(t, pki.leaf, noExchange)
+	result := s.Scan(context.Background(), config.CertificateConfig{Hostname: "127.0.0.1", Port: port})
+	if !result.Success {
+		t.Fatalf("Scan() error = %s", result.Error)
+	}
+	if result.Chain.Valid {
+		t.Error("Chain.Valid = true, want false for an untrusted root")
+	}
+	if !hasIssue(result.Chain.Issues, IssueUntrustedRoot) {
+		t.Errorf("Chain.Issues = %v, want untrusted_root", issueTypes(result.Chain.Issues))
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pki.root.cert.Raw})
+	if err := os.WriteFile(bundle, pemData, 0o600); err != nil {
+		t.Fatalf("failed to write bundle: %v", err)
+	}
+
+	port = startFakeServer(t, pki.leaf, noExchange)
+	result = s.Scan(context.Background(), config.CertificateConfig{Hostname: "127.0.0.1", Port: port, CABundle: bundle})
+	if !result.Success {
+		t.Fatalf("Scan() error = %s", result.Error)
+	}
+	if !result.Chain.Valid {
+		t.Errorf("Chain.Valid = false, want true with ca_bundle; issues = %v", result.Chain.Issues)
+	}
+
+	result = s.Scan(context.Background(), config.CertificateConfig{Hostname: "127.0.0.1", Port: port, CABundle: bundle + ".missing"})
+	if result.Success {
+		t.Error("Scan() succeeded with a missing ca_bundle")
+	}
+}
+
+func hasIssue(issues []ChainIssue, issueType string) bool {
+	for _, issue := range issues {
+		if issue.Type == issueType {
+			return true
+		}
+	}
+	return false
+}
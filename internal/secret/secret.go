@@ -0,0 +1,168 @@
+// Package secret reads credentials such as the CertWatch API key from files,
+// helper commands or secret stores, and caches them so rotated values are
+// picked up without a restart.
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	gosync "sync"
+	"time"
+)
+
+// Source fetches the current value of a secret
+type Source interface {
+	Fetch(ctx context.Context) (string, error)
+	// String describes the source for logs; it never includes the value
+	String() string
+}
+
+// Static is a fixed value, e.g. from the config file or an environment variable
+type Static string
+
+// Fetch returns the value
+func (s Static) Fetch(context.Context) (string, error) {
+	if s == "" {
+		return "", errors.New("value is empty")
+	}
+	return string(s), nil
+}
+
+func (s Static) String() string { return "config" }
+
+// File reads the value from a file, e.g. a mounted Kubernetes or Docker secret.
+// Surrounding whitespace is removed.
+type File string
+
+// Fetch reads the file
+func (f File) Fetch(context.Context) (string, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", string(f))
+	}
+	return value, nil
+}
+
+func (f File) String() string { return "file " + string(f) }
+
+// Command runs a helper program and reads the value from its standard output
+type Command []string
+
+// Fetch runs the command
+func (c Command) Fetch(ctx context.Context) (string, error) {
+	if len(c) == 0 || c[0] == "" {
+		return "", errors.New("no command configured")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c[0], c[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", c[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", c[0], err)
+	}
+
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return "", fmt.Errorf("%s printed nothing", c[0])
+	}
+	return value, nil
+}
+
+func (c Command) String() string {
+	if len(c) == 0 {
+		return "command"
+	}
+	return "command " + c[0]
+}
+
+// Cache holds the last value fetched from a Source and fetches it again once
+// it is older than the refresh interval. If a refresh fails the previous value
+// is kept, so a briefly unavailable secret store does not stop the agent.
+// Fields are ordered for optimal memory alignment
+type Cache struct {
+	fetchedAt time.Time
+	source    Source
+	onError   func(error)
+	value     string
+	refresh   time.Duration
+	timeout   time.Duration
+	mu        gosync.Mutex
+}
+
+// NewCache caches values from source for refresh (never refreshed if 0).
+// Each fetch is bounded by timeout.
+func NewCache(source Source, refresh, timeout time.Duration) *Cache {
+	return &Cache{source: source, refresh: refresh, timeout: timeout}
+}
+
+// SetErrorHandler sets a function called when a refresh fails and the
+// previous value is kept
+func (c *Cache) SetErrorHandler(fn func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onError = fn
+}
+
+// Get returns the cached value, fetching it first if none is cached or the
+// cached value is due for a refresh
+func (c *Cache) Get(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.value != "" && (c.refresh == 0 || time.Since(c.fetchedAt) < c.refresh) {
+		return c.value, nil
+	}
+	return c.fetch(ctx)
+}
+
+// Refresh fetches the value now, e.g. after the API rejected the cached one
+func (c *Cache) Refresh(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fetch(ctx)
+}
+
+// String describes the source
+func (c *Cache) String() string {
+	return c.source.String()
+}
+
+// fetch reads the value from the source; c.mu must be held
+func (c *Cache) fetch(ctx context.Context) (string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	value, err := c.source.Fetch(ctx)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", c.source, err)
+		if c.value == "" {
+			return "", err
+		}
+		if c.onError != nil {
+			c.onError(err)
+		}
+		// Try again at the next refresh rather than on every call
+		c.fetchedAt = time.Now()
+		return c.value, nil
+	}
+
+	c.value = value
+	c.fetchedAt = time.Now()
+	return value, nil
+}
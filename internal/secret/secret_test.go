@@ -0,0 +1,108 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(path, []byte("cw_from_file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := File(path).Fetch(context.Background())
+	if err != nil || got != "cw_from_file" {
+		t.Errorf("Fetch() = %q, %v, want cw_from_file", got, err)
+	}
+
+	if err := os.WriteFile(path, []byte("  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := File(path).Fetch(context.Background()); err == nil {
+		t.Error("Fetch() of an empty file succeeded, want error")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	got, err := Command{"sh", "-c", "echo cw_from_command"}.Fetch(context.Background())
+	if err != nil || got != "cw_from_command" {
+		t.Errorf("Fetch() = %q, %v, want cw_from_command", got, err)
+	}
+
+	_, err = Command{"sh", "-c", "echo vault sealed >&2; exit 2"}.Fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "vault sealed") {
+		t.Errorf("Fetch() error = %v, want the command's stderr", err)
+	}
+}
+
+// sequence returns its values in turn; an empty value fails
+type sequence struct {
+	values []string
+	calls  int
+}
+
+func (s *sequence) Fetch(context.Context) (string, error) {
+	v := s.values[min(s.calls, len(s.values)-1)]
+	s.calls++
+	if v == "" {
+		return "", errors.New("unavailable")
+	}
+	return v, nil
+}
+
+func (s *sequence) String() string { return "sequence" }
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("refreshes when due", func(t *testing.T) {
+		src := &sequence{values: []string{"cw_one", "cw_two"}}
+		c := NewCache(src, time.Hour, time.Second)
+
+		if got, _ := c.Get(ctx); got != "cw_one" {
+			t.Errorf("Get() = %q, want cw_one", got)
+		}
+		if got, _ := c.Get(ctx); got != "cw_one" || src.calls != 1 {
+			t.Errorf("Get() = %q after %d fetches, want the cached cw_one", got, src.calls)
+		}
+
+		c.fetchedAt = time.Now().Add(-2 * time.Hour)
+		if got, _ := c.Get(ctx); got != "cw_two" {
+			t.Errorf("Get() = %q, want the rotated cw_two", got)
+		}
+	})
+
+	t.Run("keeps the previous value", func(t *testing.T) {
+		src := &sequence{values: []string{"cw_one", ""}}
+		c := NewCache(src, time.Hour, time.Second)
+		var refreshErr error
+		c.SetErrorHandler(func(err error) { refreshErr = err })
+
+		_, _ = c.Get(ctx)
+		got, err := c.Refresh(ctx)
+		if err != nil || got != "cw_one" {
+			t.Errorf("Refresh() = %q, %v, want the previous cw_one", got, err)
+		}
+		if refreshErr == nil {
+			t.Error("error handler not called")
+		}
+	})
+
+	t.Run("fails without a value", func(t *testing.T) {
+		c := NewCache(&sequence{values: []string{""}}, time.Hour, time.Second)
+		if _, err := c.Get(ctx); err == nil || !strings.Contains(err.Error(), "sequence") {
+			t.Errorf("Get() error = %v, want an error naming the source", err)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package secret
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Vault defaults, matching the Vault CLI where it has an equivalent
+const (
+	DefaultVaultMount = "secret"
+	DefaultVaultField = "api_key"
+)
+
+// Vault reads a field of a secret in a HashiCorp Vault KV engine (version 1 or 2).
+// The token is read from TokenFile on every fetch so a Vault Agent sink can
+// renew it; Address and Token fall back to VAULT_ADDR and VAULT_TOKEN.
+// Fields are ordered for optimal memory alignment
+type Vault struct {
+	Client    *http.Client // Optional; a client trusting CAFile is created if nil
+	Address   string
+	Namespace string // Vault Enterprise namespace
+	Mount     string // KV mount path; default "secret"
+	Path      string // Secret path within the mount
+	Field     string // Field holding the value; default "api_key"
+	Token     string
+	TokenFile string
+	CAFile    string // PEM roots trusted for Address in addition to the system roots
+	KVVersion int    // 1 or 2; default 2
+}
+
+// Fetch reads the secret from Vault
+func (v *Vault) Fetch(ctx context.Context) (string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", fmt.Errorf("no address configured and VAULT_ADDR is not set")
+	}
+
+	token, err := v.token()
+	if err != nil {
+		return "", err
+	}
+
+	client, err := v.client()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url(address), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("X-Vault-Request", "true")
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", v.secretPath(), vaultError(resp.Status, body))
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("%s: invalid response: %w", v.secretPath(), err)
+	}
+	data := secret.Data
+	if v.kvVersion() == 2 {
+		// KV version 2 nests the secret under data.data, next to its metadata
+		data, _ = data["data"].(map[string]any)
+	}
+
+	value, _ := data[v.field()].(string)
+	if value == "" {
+		return "", fmt.Errorf("%s has no field %q", v.secretPath(), v.field())
+	}
+	return value, nil
+}
+
+func (v *Vault) String() string {
+	return "vault " + v.secretPath()
+}
+
+// url returns the API URL of the secret
+func (v *Vault) url(address string) string {
+	return strings.TrimSuffix(address, "/") + "/v1/" + v.secretPath()
+}
+
+// secretPath returns the API path of the secret below /v1/
+func (v *Vault) secretPath() string {
+	mount := strings.Trim(v.Mount, "/")
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	p := strings.Trim(v.Path, "/")
+	escaped := make([]string, 0, strings.Count(p, "/")+1)
+	for _, seg := range strings.Split(p, "/") {
+		escaped = append(escaped, url.PathEscape(seg))
+	}
+	p = strings.Join(escaped, "/")
+
+	if v.kvVersion() == 2 {
+		return mount + "/data/" + p
+	}
+	return mount + "/" + p
+}
+
+func (v *Vault) kvVersion() int {
+	if v.KVVersion == 1 {
+		return 1
+	}
+	return 2
+}
+
+func (v *Vault) field() string {
+	if v.Field == "" {
+		return DefaultVaultField
+	}
+	return v.Field
+}
+
+// token returns the Vault token from TokenFile, Token or VAULT_TOKEN
+func (v *Vault) token() (string, error) {
+	if v.TokenFile != "" {
+		token, err := File(v.TokenFile).Fetch(context.Background())
+		if err != nil {
+			return "", fmt.Errorf("token_file: %w", err)
+		}
+		return token, nil
+	}
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("no token configured and VAULT_TOKEN is not set")
+}
+
+// client returns the HTTP client for Vault requests
+func (v *Vault) client() (*http.Client, error) {
+	if v.Client != nil {
+		return v.Client, nil
+	}
+	if v.CAFile == "" {
+		return http.DefaultClient, nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	data, err := os.ReadFile(v.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("ca_file: %w", err)
+	}
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("ca_file: no PEM certificates found")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
+
+// vaultError extracts the error messages of a Vault error response
+func vaultError(status string, body []byte) string {
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil && len(resp.Errors) > 0 {
+		return status + ": " + strings.Join(resp.Errors, "; ")
+	}
+	return status
+}
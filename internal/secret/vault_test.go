@@ -0,0 +1,121 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+)
+
+// devVault is a stand-in for a Vault dev server with a KV version 1 engine at
+// kv/ and a version 2 engine at secret/
+type devVault struct {
+	secrets map[string]map[string]any // API path below /v1/ -> secret data
+	token   string
+	mu      gosync.Mutex
+}
+
+func startDevVault(t *testing.T, token string) (*devVault, string) {
+	t.Helper()
+
+	v := &devVault{secrets: map[string]map[string]any{}, token: token}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != v.token {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{"permission denied"}})
+			return
+		}
+
+		v.mu.Lock()
+		data, ok := v.secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+		v.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]any{"errors": []string{}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return v, srv.URL
+}
+
+// put stores a secret like `vault kv put`
+func (v *devVault) put(mount, path string, fields map[string]any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if mount == "kv" {
+		v.secrets[mount+"/"+path] = fields
+		return
+	}
+	v.secrets[mount+"/data/"+path] = map[string]any{
+		"data":     fields,
+		"metadata": map[string]any{"version": len(v.secrets) + 1},
+	}
+}
+
+func TestVault(t *testing.T) {
+	dev, addr := startDevVault(t, "root")
+	dev.put("secret", "certwatch/prod", map[string]any{"api_key": "cw_v2"})
+	dev.put("kv", "certwatch", map[string]any{"key": "cw_v1"})
+
+	ctx := context.Background()
+
+	t.Run("kv v2", func(t *testing.T) {
+		v := &Vault{Address: addr, Token: "root", Path: "certwatch/prod"}
+		got, err := v.Fetch(ctx)
+		if err != nil || got != "cw_v2" {
+			t.Errorf("Fetch() = %q, %v, want cw_v2", got, err)
+		}
+
+		// A new version is read on the next fetch
+		dev.put("secret", "certwatch/prod", map[string]any{"api_key": "cw_v2_rotated"})
+		if got, _ := v.Fetch(ctx); got != "cw_v2_rotated" {
+			t.Errorf("Fetch() = %q, want cw_v2_rotated", got)
+		}
+	})
+
+	t.Run("kv v1 with token file", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(tokenFile, []byte("root\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		v := &Vault{Address: addr, TokenFile: tokenFile, Mount: "kv", Path: "certwatch", Field: "key", KVVersion: 1}
+		got, err := v.Fetch(ctx)
+		if err != nil || got != "cw_v1" {
+			t.Errorf("Fetch() = %q, %v, want cw_v1", got, err)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", addr)
+		t.Setenv("VAULT_TOKEN", "root")
+		got, err := (&Vault{Path: "certwatch/prod"}).Fetch(ctx)
+		if err != nil || !strings.HasPrefix(got, "cw_v2") {
+			t.Errorf("Fetch() = %q, %v, want the v2 secret", got, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			vault Vault
+			want  string
+		}{
+			{"bad token", Vault{Address: addr, Token: "wrong", Path: "certwatch/prod"}, "permission denied"},
+			{"missing secret", Vault{Address: addr, Token: "root", Path: "nope"}, "404"},
+			{"missing field", Vault{Address: addr, Token: "root", Path: "certwatch/prod", Field: "password"}, `no field "password"`},
+		}
+		for _, tt := range tests {
+			_, err := tt.vault.Fetch(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s: Fetch() error = %v, want %q", tt.name, err, tt.want)
+			}
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 var (
 	ready    atomic.Bool
+	standby  atomic.Bool
 	lastScan atomic.Value // time.Time
 	lastSync atomic.Value // time.Time
 )
@@ -26,6 +27,12 @@ func SetReady(r bool) {
 	ready.Store(r)
 }
 
+// SetStandby marks the agent as waiting for the leader lease. A standby agent
+// doesn't scan but is reported alive.
+func SetStandby(s bool) {
+	standby.Store(s)
+}
+
 // RecordScan records the time of the last successful scan.
 func RecordScan() {
 	lastScan.Store(time.Now())
@@ -90,10 +97,20 @@ func readyzHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // livezHandler returns whether the agent is alive and functioning.
-// Returns 503 if no scans have occurred in the last 10 minutes.
+// Returns 503 if no scans have occurred in the last 10 minutes, unless the
+// agent is a standby waiting for the leader lease.
 func livezHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if standby.Load() {
+		w.WriteHeader(http.StatusOK)
+		writeJSON(w, map[string]any{
+			"status":    "standby",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
 	ls, ok := lastScan.Load().(time.Time)
 	if !ok || time.Since(ls) > 10*time.Minute {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -18,6 +18,9 @@ type State struct {
 	PreviousAgentID string    `json:"previous_agent_id,omitempty"` // For migration
 	LastSyncAt      time.Time `json:"last_sync_at,omitempty"`
 	LastUpdated     time.Time `json:"last_updated"`
+
+	// Notifications maps alert keys to when they were sent, so alerts fire once across restarts
+	Notifications map[string]time.Time `json:"notifications,omitempty"`
 }
 
 // Manager handles state persistence
@@ -174,6 +177,35 @@ func (m *Manager) SetLastSyncAt(t time.Time) {
 	m.state.LastSyncAt = t
 }
 
+// NotifiedAt returns when the alert with key was last sent
+func (m *Manager) NotifiedAt(key string) (time.Time, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.state.Notifications[key]
+	return t, ok
+}
+
+// SetNotified records that the alert with key was sent (call Save() to persist)
+func (m *Manager) SetNotified(key string, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.Notifications == nil {
+		m.state.Notifications = make(map[string]time.Time)
+	}
+	m.state.Notifications[key] = t
+}
+
+// PruneNotifications forgets alerts sent before cutoff (call Save() to persist)
+func (m *Manager) PruneNotifications(cutoff time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, t := range m.state.Notifications {
+		if t.Before(cutoff) {
+			delete(m.state.Notifications, key)
+		}
+	}
+}
+
 // HasNameChanged checks if the config name differs from the persisted name
 // Returns false if no previous name is stored (first run)
 func (m *Manager) HasNameChanged(configName string) bool {
@@ -214,3 +246,9 @@ func (m *Manager) Reset() error {
 func (m *Manager) FilePath() string {
 	return m.filePath
 }
+
+// Dir returns the directory containing the state file
+// Other persistent agent data (e.g., the sync outbox) is stored alongside it
+func (m *Manager) Dir() string {
+	return filepath.Dir(m.filePath)
+}
@@ -372,3 +372,31 @@ func TestConcurrentAccess(t *testing.T) {
 
 	// If we get here without deadlock or race, the test passes
 }
+
+func TestNotifications(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "certwatch.yaml")
+
+	m1 := NewManager(configPath)
+	sent := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	m1.SetNotified("expiry/example.com:443/abc/30", sent)
+	m1.SetNotified("old", sent.Add(-100*24*time.Hour))
+	m1.PruneNotifications(sent.Add(-90 * 24 * time.Hour))
+
+	if err := m1.Save(); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	m2 := NewManager(configPath)
+	if err := m2.Load(); err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+
+	got, ok := m2.NotifiedAt("expiry/example.com:443/abc/30")
+	if !ok || !got.Equal(sent) {
+		t.Errorf("NotifiedAt() = %v, %v, want %v, true", got, ok, sent)
+	}
+	if _, ok := m2.NotifiedAt("old"); ok {
+		t.Error("expected pruned notification to be forgotten")
+	}
+}
@@ -2,17 +2,23 @@ package sync
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/outbox"
+	"github.com/certwatch-app/cw-agent/internal/proxy"
 	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/secret"
 	"github.com/certwatch-app/cw-agent/internal/state"
 	"github.com/certwatch-app/cw-agent/internal/version"
 )
@@ -20,27 +26,85 @@ import (
 // Client handles communication with the CertWatch API
 type Client struct {
 	endpoint          string
-	apiKey            string
+	apiKey            *secret.Cache
 	httpClient        *http.Client
 	logger            *zap.Logger
 	agentName         string
 	stateManager      *state.Manager
+	outbox            *outbox.Queue
+	onRetry           func(operation, reason string)
+	onPayload         func(kind string, size, sent int)
+	retry             RetryPolicy
 	heartbeatInterval time.Duration
+	compress          atomic.Bool // gzip request bodies; cleared if the API rejects them
 }
 
-// New creates a new sync Client with state manager for agent ID persistence
-func New(cfg *config.Config, logger *zap.Logger, stateManager *state.Manager) *Client {
-	return &Client{
+// minCompressSize is the smallest payload worth compressing
+const minCompressSize = 1024
+
+// New creates a new sync Client with state manager for agent ID persistence.
+// It fails if the configured API TLS settings can't be loaded.
+func New(cfg *config.Config, logger *zap.Logger, stateManager *state.Manager) (*Client, error) {
+	retry := RetryPolicy{
+		MaxAttempts:    cfg.API.Retry.MaxAttempts,
+		InitialBackoff: cfg.API.Retry.InitialBackoff,
+		MaxBackoff:     cfg.API.Retry.MaxBackoff,
+	}
+	if retry.MaxAttempts == 0 {
+		retry = DefaultRetryPolicy
+	}
+
+	httpClient, err := newHTTPClient(cfg.API.Timeout, cfg.API.Proxy, cfg.API.TLS, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
 		endpoint:          cfg.API.Endpoint,
-		apiKey:            cfg.API.Key,
+		apiKey:            newAPIKey(cfg.API.APIKey(), cfg.API.Timeout, logger),
 		agentName:         cfg.Agent.Name,
 		stateManager:      stateManager,
 		heartbeatInterval: cfg.Agent.HeartbeatInterval,
-		httpClient: &http.Client{
-			Timeout: cfg.API.Timeout,
-		},
-		logger: logger,
+		retry:             retry,
+		httpClient:        httpClient,
+		logger:            logger,
+	}
+	c.compress.Store(cfg.API.Compression == config.CompressionGzip)
+	return c, nil
+}
+
+// newHTTPClient creates the HTTP client for API calls. Without a configured proxy
+// the standard HTTPS_PROXY and NO_PROXY environment variables apply.
+func newHTTPClient(timeout time.Duration, proxyCfg config.ProxyConfig, tlsCfg config.APITLSConfig, logger *zap.Logger) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyCfg.URL != "" {
+		d, err := proxy.New(proxyCfg.URL, proxyCfg.NoProxy, timeout)
+		if err != nil {
+			logger.Warn("connecting to the API without proxy", zap.Error(err))
+		} else {
+			transport = d.Transport()
+		}
+	}
+
+	// Never fall back to default TLS: that would drop the client certificate
+	// and CA pinning the operator configured
+	tlsConfig, err := newTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api.tls settings: %w", err)
 	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// newAPIKey caches the API key from its configured source. Keys from files,
+// commands or Vault are read again periodically so rotation needs no restart.
+func newAPIKey(cfg config.APIKeyConfig, timeout time.Duration, logger *zap.Logger) *secret.Cache {
+	key := secret.NewCache(cfg.Source(), cfg.RefreshInterval(), timeout)
+	key.SetErrorHandler(func(err error) {
+		logger.Warn("failed to read API key, using the previous key", zap.Error(err))
+	})
+	return key
 }
 
 // Sync sends certificate data to the CertWatch API
@@ -56,23 +120,52 @@ func (c *Client) Sync(ctx context.Context, certs []config.CertificateConfig, res
 
 	// Persist agent ID and name for future restarts
 	if resp.Success && resp.AgentID != "" {
-		c.stateManager.SetAgentID(resp.AgentID)
-		c.stateManager.SetAgentName(c.agentName)
-		c.stateManager.SetLastSyncAt(resp.Data.SyncedAt)
-
 		// Clear previous agent ID after successful migration
 		if c.stateManager.GetPreviousAgentID() != "" && resp.Data.Migrated > 0 {
 			c.stateManager.ClearPreviousAgentID()
 		}
-
-		if err := c.stateManager.Save(); err != nil {
-			c.logger.Warn("failed to save state", zap.Error(err))
-		}
+		c.persistAgent(resp.AgentID, resp.Data.SyncedAt)
 	}
 
 	return resp, nil
 }
 
+// SyncCertificateEvents sends certificate change events to the API
+func (c *Client) SyncCertificateEvents(ctx context.Context, events []CertificateEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	req := &CertificateEventSyncRequest{
+		AgentID:   c.stateManager.GetAgentID(),
+		AgentName: c.agentName,
+		Events:    events,
+	}
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	c.logger.Debug("sending certificate event sync request",
+		zap.Int("events", len(events)),
+	)
+
+	_, err = c.send(ctx, KindCertificateEvents, "POST", kindPaths[KindCertificateEvents], jsonData)
+	return err
+}
+
+// persistAgent stores the agent ID returned by a successful sync
+func (c *Client) persistAgent(agentID string, syncedAt time.Time) {
+	c.stateManager.SetAgentID(agentID)
+	c.stateManager.SetAgentName(c.agentName)
+	c.stateManager.SetLastSyncAt(syncedAt)
+
+	if err := c.stateManager.Save(); err != nil {
+		c.logger.Warn("failed to save state", zap.Error(err))
+	}
+}
+
 // Heartbeat sends a heartbeat to the CertWatch API
 func (c *Client) Heartbeat(ctx context.Context, certCount int, lastScan, lastSync time.Time) error {
 	agentID := c.stateManager.GetAgentID()
@@ -106,41 +199,28 @@ func (c *Client) Heartbeat(ctx context.Context, certCount int, lastScan, lastSyn
 // ErrAgentNotFound is returned when the agent ID is no longer valid on the server
 var ErrAgentNotFound = fmt.Errorf("agent not found")
 
-func (c *Client) doHeartbeatRequest(ctx context.Context, body *HeartbeatRequest) (*HeartbeatResponse, error) {
-	url := c.endpoint + "/api/v1/agent/heartbeat"
+// ErrAPIUnavailable is returned when the API could not be reached or returned a server error.
+// Payloads that fail with this error are queued in the outbox when one is configured.
+var ErrAPIUnavailable = fmt.Errorf("API unavailable")
 
+// ErrQueued is wrapped by errors for payloads that were queued in the outbox.
+// They are delivered on a later replay and must not be sent again.
+var ErrQueued = fmt.Errorf("queued for replay")
+
+func (c *Client) doHeartbeatRequest(ctx context.Context, body *HeartbeatRequest) (*HeartbeatResponse, error) {
 	jsonData, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal heartbeat request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create heartbeat request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
-	req.Header.Set("User-Agent", fmt.Sprintf("cw-agent/%s", version.GetVersion()))
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("heartbeat request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := c.post(ctx, "heartbeat", "POST", "/api/v1/agent/heartbeat", jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read heartbeat response: %w", err)
-	}
-
-	// Handle 404 - agent was deleted from server
-	if resp.StatusCode == 404 {
-		return nil, ErrAgentNotFound
-	}
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("heartbeat API returned status %d: %s", resp.StatusCode, string(respBody))
+		// Handle 404 - agent was deleted from server
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, ErrAgentNotFound
+		}
+		return nil, fmt.Errorf("heartbeat failed: %w", err)
 	}
 
 	var heartbeatResp HeartbeatResponse
@@ -152,22 +232,26 @@ func (c *Client) doHeartbeatRequest(ctx context.Context, body *HeartbeatRequest)
 }
 
 func (c *Client) buildSyncRequest(certs []config.CertificateConfig, results []scanner.ScanResult) *SyncRequest {
-	// Build a map of scan results by hostname:port
+	// Build a map of scan results by name:port; targets scanned with several
+	// server names have one result per name
 	resultMap := make(map[string]*scanner.ScanResult)
 	for i := range results {
-		key := fmt.Sprintf("%s:%d", results[i].Hostname, results[i].Port)
+		key := fmt.Sprintf("%s:%d", results[i].Name(), results[i].Port)
 		resultMap[key] = &results[i]
 	}
 
 	// Build certificate sync data
 	certData := make([]CertificateSyncData, 0, len(certs))
 	for _, cert := range certs {
-		key := fmt.Sprintf("%s:%d", cert.Hostname, cert.Port)
+		key := cert.GetHostPort()
 		data := CertificateSyncData{
-			Hostname: cert.Hostname,
-			Port:     cert.Port,
-			Tags:     cert.Tags,
-			Notes:    cert.Notes,
+			Hostname:   cert.Hostname,
+			Address:    cert.Address,
+			ServerName: cert.ServerName,
+			Port:       cert.Port,
+			Tags:       cert.Tags,
+			Notes:      cert.Notes,
+			StartTLS:   cert.StartTLS,
 		}
 
 		// Add scan results if available
@@ -196,9 +280,22 @@ func (c *Client) buildSyncRequest(certs []config.CertificateConfig, results []sc
 						})
 					}
 				}
+
+				if result.TLS != nil {
+					data.TLS = convertTLSInfo(result.TLS)
+				}
 			} else if result.Error != "" {
 				data.LastError = result.Error
 			}
+
+			for _, a := range result.Addresses {
+				addr := AddressData{Address: a.Address, FingerprintSHA256: a.FingerprintSHA256, Error: a.Error}
+				if a.Success {
+					notAfter := a.NotAfter
+					addr.NotAfter = &notAfter
+				}
+				data.Addresses = append(data.Addresses, addr)
+			}
 		}
 
 		certData = append(certData, data)
@@ -224,40 +321,234 @@ func (c *Client) buildSyncRequest(certs []config.CertificateConfig, results []sc
 }
 
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*SyncResponse, error) {
-	url := c.endpoint + path
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	respBody, err := c.send(ctx, KindCertificates, method, path, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var syncResp SyncResponse
+	if err := json.Unmarshal(respBody, &syncResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &syncResp, nil
+}
+
+// send delivers a payload, replaying any queued payloads first so the API receives them in order.
+// If the API is unavailable the payload is queued in the outbox (when configured).
+func (c *Client) send(ctx context.Context, kind, method, path string, payload []byte) ([]byte, error) {
+	if c.outbox != nil && c.outbox.Len() > 0 {
+		if _, err := c.FlushOutbox(ctx); err != nil {
+			return nil, c.enqueue(kind, payload, err)
+		}
+	}
+
+	respBody, err := c.post(ctx, kind, method, path, payload)
+	if err != nil && c.outbox != nil && errors.Is(err, ErrAPIUnavailable) {
+		return nil, c.enqueue(kind, payload, err)
+	}
+	return respBody, err
+}
+
+// enqueue stores a payload in the outbox and returns an error describing the deferred delivery
+func (c *Client) enqueue(kind string, payload []byte, cause error) error {
+	if err := c.outbox.Enqueue(kind, payload); err != nil {
+		c.logger.Error("failed to queue payload in outbox", zap.String("kind", kind), zap.Error(err))
+		return cause
+	}
+
+	c.logger.Info("API unavailable, payload queued for replay",
+		zap.String("kind", kind),
+		zap.Int("pending", c.outbox.Len()),
+		zap.Error(cause),
+	)
+	return fmt.Errorf("%w (%w)", cause, ErrQueued)
+}
+
+// FlushOutbox replays queued payloads in order. It returns the number of payloads delivered.
+// Replay stops at the first failure and backs off before the next attempt.
+func (c *Client) FlushOutbox(ctx context.Context) (int, error) {
+	if c.outbox == nil {
+		return 0, nil
+	}
+
+	sent, err := c.outbox.Replay(ctx, func(ctx context.Context, e outbox.Entry) error {
+		path, ok := kindPaths[e.Kind]
+		if !ok {
+			// Unknown payload kind from a newer or older agent, drop it
+			c.logger.Warn("dropping queued payload of unknown kind", zap.String("kind", e.Kind))
+			return nil
+		}
 
-	var bodyReader io.Reader
-	if body != nil {
-		jsonData, err := json.Marshal(body)
+		// Single attempt per entry, the outbox applies its own backoff between replays
+		respBody, err := c.postOnce(ctx, e.Kind, "POST", path, e.Payload)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+			if errors.Is(err, ErrAPIUnavailable) {
+				return err
+			}
+			// Permanent failure, replaying again won't help
+			c.logger.Warn("dropping queued payload rejected by API",
+				zap.String("kind", e.Kind),
+				zap.Uint64("id", e.ID),
+				zap.Error(err),
+			)
+			return nil
 		}
-		bodyReader = bytes.NewReader(jsonData)
+
+		c.handleReplayResponse(e.Kind, respBody)
+		return nil
+	})
+
+	if sent > 0 {
+		c.logger.Info("replayed queued payloads",
+			zap.Int("sent", sent),
+			zap.Int("pending", c.outbox.Len()),
+		)
+	}
+	return sent, err
+}
+
+// handleReplayResponse persists the agent ID returned for replayed certificate syncs
+func (c *Client) handleReplayResponse(kind string, body []byte) {
+	if kind != KindCertificates && kind != KindCertManagerCertificates {
+		return
+	}
+
+	var resp struct {
+		AgentID string `json:"agent_id"`
+		Data    struct {
+			SyncedAt time.Time `json:"synced_at"`
+		} `json:"data"`
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+	if resp.Success && resp.AgentID != "" {
+		c.persistAgent(resp.AgentID, resp.Data.SyncedAt)
+	}
+}
+
+// convertTLSInfo converts scanner TLS details to the sync payload format
+func convertTLSInfo(info *scanner.TLSInfo) *TLSSyncData {
+	data := &TLSSyncData{
+		Version:           info.Version,
+		CipherSuite:       info.CipherSuite,
+		ALPN:              info.ALPN,
+		SupportedVersions: info.SupportedVersions,
+		CipherSuites:      info.CipherSuites,
+		Audited:           info.Audited,
+	}
+	for _, issue := range info.Issues {
+		data.Issues = append(data.Issues, ChainIssueData{Type: issue.Type, Message: issue.Message})
+	}
+	return data
+}
+
+// SetOutbox enables queuing of undeliverable payloads in the given outbox
+func (c *Client) SetOutbox(q *outbox.Queue) {
+	c.outbox = q
+}
+
+// SetPayloadObserver registers a callback invoked for every request with the
+// payload kind, its size and the number of bytes sent after compression
+func (c *Client) SetPayloadObserver(fn func(kind string, size, sent int)) {
+	c.onPayload = fn
+}
+
+// SetHeartbeatInterval updates the heartbeat interval reported to the API, e.g.
+// after a configuration reload
+func (c *Client) SetHeartbeatInterval(d time.Duration) {
+	c.heartbeatInterval = d
+}
+
+// OutboxLen returns the number of payloads waiting in the outbox
+func (c *Client) OutboxLen() int {
+	if c.outbox == nil {
+		return 0
 	}
+	return c.outbox.Len()
+}
+
+// post sends a JSON payload with the client's retry policy and returns the response body
+func (c *Client) post(ctx context.Context, op, method, path string, payload []byte) ([]byte, error) {
+	return c.withRetry(ctx, op, func() ([]byte, error) {
+		return c.postOnce(ctx, op, method, path, payload)
+	})
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+// postOnce makes a single request and returns the response body. A request
+// rejected as unauthorized is repeated once if the API key has changed.
+// Error responses are returned as *StatusError. Transport errors and retryable
+// status codes are additionally wrapped with ErrAPIUnavailable.
+func (c *Client) postOnce(ctx context.Context, kind, method, path string, payload []byte) ([]byte, error) {
+	key, err := c.apiKey.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key: %w", err)
+	}
+
+	body, err := c.postWithKey(ctx, kind, method, path, key, payload)
+
+	// The key may have been rotated since it was last read; read it again and
+	// retry at once rather than waiting for the next refresh
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+		if newKey, refreshErr := c.apiKey.Refresh(ctx); refreshErr == nil && newKey != key {
+			c.logger.Info("API key was rejected, retrying with the updated key", zap.Stringer("source", c.apiKey))
+			return c.postWithKey(ctx, kind, method, path, newKey, payload)
+		}
+	}
+	return body, err
+}
+
+// postWithKey makes a request authenticated with key. Payloads of at least
+// minCompressSize bytes are sent gzip-compressed unless compression is off.
+func (c *Client) postWithKey(ctx context.Context, kind, method, path, key string, payload []byte) ([]byte, error) {
+	url := c.endpoint + path
+
+	body, encoding := payload, ""
+	if c.compress.Load() && len(payload) >= minCompressSize {
+		if compressed, err := gzipBytes(payload); err == nil {
+			body, encoding = compressed, "gzip"
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
-	req.Header.Set("User-Agent", fmt.Sprintf("cw-agent/%s", version.GetVersion()))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	req.Header.Set("X-API-Key", key)
+	req.Header.Set("User-Agent", userAgentFor(kind))
 
 	c.logger.Debug("sending sync request",
 		zap.String("url", url),
 		zap.String("method", method),
+		zap.Int("body_length", len(payload)),
+		zap.Int("sent_length", len(body)),
 	)
+	if c.onPayload != nil {
+		c.onPayload(kind, len(payload), len(body))
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: request failed: %w", ErrAPIUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %w", ErrAPIUnavailable, err)
 	}
 
 	c.logger.Debug("received response",
@@ -265,23 +556,51 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 		zap.Int("body_length", len(respBody)),
 	)
 
+	// An endpoint that doesn't accept compressed bodies gets them uncompressed from now on
+	if resp.StatusCode == http.StatusUnsupportedMediaType && encoding != "" {
+		c.logger.Warn("API rejected a compressed request, disabling compression", zap.String("url", url))
+		c.compress.Store(false)
+		return c.postWithKey(ctx, kind, method, path, key, payload)
+	}
+
 	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error   *APIError `json:"error"`
-			Success bool      `json:"success"`
+		statusErr := &StatusError{
+			err:        parseAPIError(resp.StatusCode, respBody),
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
 		}
-		if unmarshalErr := json.Unmarshal(respBody, &errResp); unmarshalErr == nil && errResp.Error != nil {
-			return nil, fmt.Errorf("API error (%s): %s", errResp.Error.Code, errResp.Error.Message)
+		if isRetryableStatus(resp.StatusCode) {
+			return nil, fmt.Errorf("%w: %w", ErrAPIUnavailable, statusErr)
 		}
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, statusErr
 	}
 
-	var syncResp SyncResponse
-	if err := json.Unmarshal(respBody, &syncResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	return respBody, nil
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	return &syncResp, nil
+// parseAPIError builds an error from an API error response body
+func parseAPIError(status int, body []byte) error {
+	var errResp struct {
+		Error   *APIError `json:"error"`
+		Success bool      `json:"success"`
+	}
+	if unmarshalErr := json.Unmarshal(body, &errResp); unmarshalErr == nil && errResp.Error != nil {
+		return fmt.Errorf("API error (%s): %s", errResp.Error.Code, errResp.Error.Message)
+	}
+	return fmt.Errorf("API error %d: %s", status, string(body))
 }
 
 // GetAgentID returns the persisted agent ID (empty if not yet synced)
@@ -303,89 +622,94 @@ func getHostname() string {
 
 // ClientConfig holds configuration for creating a sync client without the full config package
 type ClientConfig struct {
-	Endpoint string
-	APIKey   string
-	Timeout  time.Duration
+	Endpoint    string
+	APIKey      string
+	KeySource   config.APIKeyConfig // Where to read the key from; APIKey is used if it has no key
+	Timeout     time.Duration
+	Retry       RetryPolicy
+	Proxy       config.ProxyConfig
+	TLS         config.APITLSConfig
+	Compression string // config.CompressionGzip or config.CompressionNone (default)
 }
 
 // NewWithConfig creates a new sync Client with explicit configuration
 // This is used by the cert-manager controller which has its own config package
-func NewWithConfig(cfg *ClientConfig, agentName string, logger *zap.Logger, stateManager *state.Manager) *Client {
+func NewWithConfig(cfg *ClientConfig, agentName string, logger *zap.Logger, stateManager *state.Manager) (*Client, error) {
 	timeout := cfg.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
 
-	return &Client{
+	retry := cfg.Retry
+	if retry.MaxAttempts == 0 {
+		retry = DefaultRetryPolicy
+	}
+
+	keySource := cfg.KeySource
+	if keySource.Key == "" {
+		keySource.Key = cfg.APIKey
+	}
+
+	httpClient, err := newHTTPClient(timeout, cfg.Proxy, cfg.TLS, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
 		endpoint:     cfg.Endpoint,
-		apiKey:       cfg.APIKey,
+		apiKey:       newAPIKey(keySource, timeout, logger),
 		agentName:    agentName,
 		stateManager: stateManager,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		logger: logger,
+		retry:        retry,
+		httpClient:   httpClient,
+		logger:       logger,
 	}
+	c.compress.Store(cfg.Compression == config.CompressionGzip)
+	return c, nil
 }
 
-// SyncCertManagerCertificates syncs cert-manager certificates to the API
+// SyncCertManagerCertificates syncs the full list of cert-manager certificates to the API
 func (c *Client) SyncCertManagerCertificates(ctx context.Context, clusterName string, certs []CertManagerCertificate) (*CertManagerSyncResponse, error) {
-	req := &CertManagerSyncRequest{
-		AgentID:      c.stateManager.GetAgentID(),
-		AgentName:    c.agentName,
-		AgentVersion: version.GetVersion(),
+	return c.syncCertManagerCertificates(ctx, &CertManagerSyncRequest{
 		ClusterName:  clusterName,
+		Mode:         SyncModeFull,
 		Certificates: certs,
+	})
+}
+
+// SyncCertManagerChanges syncs only the certificates changed and deleted since the last sync
+func (c *Client) SyncCertManagerChanges(ctx context.Context, clusterName string, changed []CertManagerCertificate, deleted []CertManagerCertificateRef) (*CertManagerSyncResponse, error) {
+	if changed == nil {
+		changed = []CertManagerCertificate{}
 	}
+	return c.syncCertManagerCertificates(ctx, &CertManagerSyncRequest{
+		ClusterName:  clusterName,
+		Mode:         SyncModeDelta,
+		Certificates: changed,
+		Deleted:      deleted,
+	})
+}
 
-	url := c.endpoint + "/api/v1/agent/certmanager/sync"
+func (c *Client) syncCertManagerCertificates(ctx context.Context, req *CertManagerSyncRequest) (*CertManagerSyncResponse, error) {
+	req.AgentID = c.stateManager.GetAgentID()
+	req.AgentName = c.agentName
+	req.AgentVersion = version.GetVersion()
 
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-Key", c.apiKey)
-	httpReq.Header.Set("User-Agent", fmt.Sprintf("cw-agent-certmanager/%s", version.GetVersion()))
-
 	c.logger.Debug("sending certmanager sync request",
-		zap.String("url", url),
-		zap.Int("certificates", len(certs)),
-		zap.Bool("api_key_present", c.apiKey != ""),
-		zap.Int("api_key_length", len(c.apiKey)),
+		zap.String("mode", req.Mode),
+		zap.Int("certificates", len(req.Certificates)),
+		zap.Int("deleted", len(req.Deleted)),
+		zap.Stringer("api_key_source", c.apiKey),
 	)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.send(ctx, KindCertManagerCertificates, "POST", kindPaths[KindCertManagerCertificates], jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	c.logger.Debug("received response",
-		zap.Int("status", resp.StatusCode),
-		zap.Int("body_length", len(body)),
-	)
-
-	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error   *APIError `json:"error"`
-			Success bool      `json:"success"`
-		}
-		if unmarshalErr := json.Unmarshal(body, &errResp); unmarshalErr == nil && errResp.Error != nil {
-			return nil, fmt.Errorf("API error (%s): %s", errResp.Error.Code, errResp.Error.Message)
-		}
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var syncResp CertManagerSyncResponse
@@ -395,12 +719,7 @@ func (c *Client) SyncCertManagerCertificates(ctx context.Context, clusterName st
 
 	// Persist agent ID for future syncs
 	if syncResp.Success && syncResp.AgentID != "" {
-		c.stateManager.SetAgentID(syncResp.AgentID)
-		c.stateManager.SetAgentName(c.agentName)
-		c.stateManager.SetLastSyncAt(syncResp.Data.SyncedAt)
-		if err := c.stateManager.Save(); err != nil {
-			c.logger.Warn("failed to save state", zap.Error(err))
-		}
+		c.persistAgent(syncResp.AgentID, syncResp.Data.SyncedAt)
 	}
 
 	return &syncResp, nil
@@ -419,55 +738,17 @@ func (c *Client) SyncCertManagerEvents(ctx context.Context, clusterName string,
 		Events:      events,
 	}
 
-	url := c.endpoint + "/api/v1/agent/certmanager/events"
-
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-Key", c.apiKey)
-	httpReq.Header.Set("User-Agent", fmt.Sprintf("cw-agent-certmanager/%s", version.GetVersion()))
-
 	c.logger.Debug("sending certmanager event sync request",
-		zap.String("url", url),
 		zap.Int("events", len(events)),
 	)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	c.logger.Debug("received response",
-		zap.Int("status", resp.StatusCode),
-		zap.Int("body_length", len(body)),
-	)
-
-	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error   *APIError `json:"error"`
-			Success bool      `json:"success"`
-		}
-		if unmarshalErr := json.Unmarshal(body, &errResp); unmarshalErr == nil && errResp.Error != nil {
-			return fmt.Errorf("API error (%s): %s", errResp.Error.Code, errResp.Error.Message)
-		}
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+	_, err = c.send(ctx, KindCertManagerEvents, "POST", kindPaths[KindCertManagerEvents], jsonData)
+	return err
 }
 
 // SyncCertManagerRequests syncs cert-manager CertificateRequests to the API (Phase 2)
@@ -483,53 +764,75 @@ func (c *Client) SyncCertManagerRequests(ctx context.Context, clusterName string
 		Requests:    requests,
 	}
 
-	url := c.endpoint + "/api/v1/agent/certmanager/requests"
-
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-API-Key", c.apiKey)
-	httpReq.Header.Set("User-Agent", fmt.Sprintf("cw-agent-certmanager/%s", version.GetVersion()))
-
 	c.logger.Debug("sending certmanager request sync",
-		zap.String("url", url),
 		zap.Int("requests", len(requests)),
 	)
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+	_, err = c.send(ctx, KindCertManagerRequests, "POST", kindPaths[KindCertManagerRequests], jsonData)
+	return err
+}
+
+// SyncCertManagerIssuers syncs cert-manager Issuers and ClusterIssuers to the API
+func (c *Client) SyncCertManagerIssuers(ctx context.Context, clusterName string, issuers []CertManagerIssuer) error {
+	if len(issuers) == 0 {
+		return nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	req := &CertManagerIssuerSyncRequest{
+		AgentID:     c.stateManager.GetAgentID(),
+		AgentName:   c.agentName,
+		ClusterName: clusterName,
+		Issuers:     issuers,
+	}
+
+	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	c.logger.Debug("received response",
-		zap.Int("status", resp.StatusCode),
-		zap.Int("body_length", len(body)),
+	c.logger.Debug("sending certmanager issuer sync",
+		zap.Int("issuers", len(issuers)),
 	)
 
-	if resp.StatusCode >= 400 {
-		var errResp struct {
-			Error   *APIError `json:"error"`
-			Success bool      `json:"success"`
-		}
-		if unmarshalErr := json.Unmarshal(body, &errResp); unmarshalErr == nil && errResp.Error != nil {
-			return fmt.Errorf("API error (%s): %s", errResp.Error.Code, errResp.Error.Message)
-		}
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	_, err = c.send(ctx, KindCertManagerIssuers, "POST", kindPaths[KindCertManagerIssuers], jsonData)
+	return err
+}
+
+// SyncCertManagerACME syncs ACME Orders and Challenges to the API
+func (c *Client) SyncCertManagerACME(ctx context.Context, clusterName string, orders []CertManagerACMEOrder, challenges []CertManagerACMEChallenge) error {
+	if len(orders) == 0 && len(challenges) == 0 {
+		return nil
+	}
+	if orders == nil {
+		orders = []CertManagerACMEOrder{}
+	}
+	if challenges == nil {
+		challenges = []CertManagerACMEChallenge{}
+	}
+
+	req := &CertManagerACMESyncRequest{
+		AgentID:     c.stateManager.GetAgentID(),
+		AgentName:   c.agentName,
+		ClusterName: clusterName,
+		Orders:      orders,
+		Challenges:  challenges,
 	}
 
-	return nil
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	c.logger.Debug("sending certmanager acme sync",
+		zap.Int("orders", len(orders)),
+		zap.Int("challenges", len(challenges)),
+	)
+
+	_, err = c.send(ctx, KindCertManagerACME, "POST", kindPaths[KindCertManagerACME], jsonData)
+	return err
 }
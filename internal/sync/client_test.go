@@ -0,0 +1,465 @@
+package sync
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/outbox"
+	"github.com/certwatch-app/cw-agent/internal/scanner"
+	"github.com/certwatch-app/cw-agent/internal/state"
+)
+
+// newTestClient creates a client against endpoint with fast retries
+func newTestClient(t *testing.T, endpoint string) *Client {
+	t.Helper()
+
+	cfg := &ClientConfig{
+		Endpoint: endpoint,
+		APIKey:   "cw_test",
+		Timeout:  5 * time.Second,
+		Retry: RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     5 * time.Millisecond,
+		},
+	}
+	return newClient(t, cfg)
+}
+
+func newClient(t *testing.T, cfg *ClientConfig) *Client {
+	t.Helper()
+
+	c, err := NewWithConfig(cfg, "test-agent", zap.NewNop(), state.NewManagerWithStateDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewWithConfig() error = %v", err)
+	}
+	return c
+}
+
+func TestRetry_ServerErrorThenSuccess(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	var retries []string
+	c.SetRetryObserver(func(op, reason string) { retries = append(retries, op+"/"+reason) })
+
+	err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Issued"}})
+	if err != nil {
+		t.Fatalf("SyncCertManagerEvents() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+	if len(retries) != 2 || retries[0] != "certmanager_events/server_error" {
+		t.Errorf("retries = %v, want 2 server_error retries", retries)
+	}
+}
+
+func TestRetry_PermanentErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"success":false,"error":{"code":"INVALID","message":"bad payload"}}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	err := c.SyncCertManagerRequests(context.Background(), "cluster", []CertManagerRequest{{Name: "req"}})
+	if err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+	if errors.Is(err, ErrAPIUnavailable) {
+		t.Error("400 response should not be classified as unavailable")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var first time.Time
+	var second time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		second = time.Now()
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.retry.MaxBackoff = 2 * time.Second
+	var reason string
+	c.SetRetryObserver(func(_, r string) { reason = r })
+
+	err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Issued"}})
+	if err != nil {
+		t.Fatalf("SyncCertManagerEvents() error = %v", err)
+	}
+	if reason != RetryReasonRateLimited {
+		t.Errorf("reason = %v, want %v", reason, RetryReasonRateLimited)
+	}
+	if gap := second.Sub(first); gap < 900*time.Millisecond {
+		t.Errorf("retry after %v, want at least 1s from Retry-After", gap)
+	}
+}
+
+func TestRetry_StopsWhenBackoffExceedsDeadline(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := c.SyncCertManagerEvents(ctx, "cluster", []CertManagerEvent{{Reason: "Issued"}})
+	if !errors.Is(err, ErrAPIUnavailable) {
+		t.Fatalf("error = %v, want ErrAPIUnavailable", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+	if time.Since(start) > time.Second {
+		t.Error("client waited for a backoff beyond the context deadline")
+	}
+}
+
+func TestRetry_GivesUpWhenRetryAfterExceedsMaxBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Issued"}})
+	if !errors.Is(err, ErrAPIUnavailable) {
+		t.Fatalf("error = %v, want ErrAPIUnavailable", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestAPIKey_RotatedFileRereadAfterUnauthorized(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(keyFile, []byte("cw_old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu gosync.Mutex
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-API-Key"))
+		mu.Unlock()
+		if r.Header.Get("X-API-Key") != "cw_new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	cfg := &ClientConfig{
+		Endpoint:  srv.URL,
+		KeySource: config.APIKeyConfig{File: keyFile, Refresh: time.Hour},
+		Retry:     RetryPolicy{MaxAttempts: 1},
+	}
+	c := newClient(t, cfg)
+	events := []CertManagerEvent{{Reason: "Issued"}}
+
+	if err := c.SyncCertManagerEvents(context.Background(), "cluster", events); err == nil {
+		t.Fatal("SyncCertManagerEvents() with the old key succeeded, want 401")
+	}
+
+	// Rotate the key; the next rejected request reads it again without waiting for the refresh
+	if err := os.WriteFile(keyFile, []byte("cw_new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncCertManagerEvents(context.Background(), "cluster", events); err != nil {
+		t.Fatalf("SyncCertManagerEvents() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"cw_old", "cw_old", "cw_new"}
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("keys sent = %v, want %v", keys, want)
+	}
+}
+
+func TestHeartbeat_AgentNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.stateManager.SetAgentID("agent-1")
+
+	err := c.Heartbeat(context.Background(), 1, time.Time{}, time.Time{})
+	if !errors.Is(err, ErrAgentNotFound) {
+		t.Errorf("Heartbeat() error = %v, want ErrAgentNotFound", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{"Wed, 01 Jan 2025 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 Jan 2025 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 4 * time.Second}
+
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{10, 2 * time.Second, 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := p.backoff(tt.retry); got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", tt.retry, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestOutbox_QueuesWhileUnavailableAndReplaysInOrder(t *testing.T) {
+	var up atomic.Bool
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received = append(received, r.URL.Path)
+		w.Write([]byte(`{"success":true,"agent_id":"agent-1"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	q := outbox.New(t.TempDir(), 0, 0)
+	c.SetOutbox(q)
+
+	err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Failed"}})
+	if !errors.Is(err, ErrAPIUnavailable) || !errors.Is(err, ErrQueued) {
+		t.Fatalf("error = %v, want ErrAPIUnavailable and ErrQueued", err)
+	}
+	if c.OutboxLen() != 1 {
+		t.Fatalf("OutboxLen() = %d, want 1", c.OutboxLen())
+	}
+
+	up.Store(true)
+	resp, err := c.SyncCertManagerCertificates(context.Background(), "cluster", nil)
+	if err != nil {
+		t.Fatalf("SyncCertManagerCertificates() error = %v", err)
+	}
+	if resp.AgentID != "agent-1" {
+		t.Errorf("AgentID = %v, want agent-1", resp.AgentID)
+	}
+	if c.OutboxLen() != 0 {
+		t.Errorf("OutboxLen() = %d, want 0", c.OutboxLen())
+	}
+
+	want := []string{"/api/v1/agent/certmanager/events", "/api/v1/agent/certmanager/sync"}
+	if len(received) != len(want) {
+		t.Fatalf("received = %v, want %v", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Errorf("received[%d] = %v, want %v", i, received[i], want[i])
+		}
+	}
+}
+
+// manyCertificates returns enough certificates for the payload to be compressed
+func manyCertificates(n int) []CertManagerCertificate {
+	certs := make([]CertManagerCertificate, n)
+	for i := range certs {
+		certs[i] = CertManagerCertificate{Namespace: "default", Name: fmt.Sprintf("cert-%d", i), IssuerName: "letsencrypt"}
+	}
+	return certs
+}
+
+func TestCompression_GzipsDeltaSync(t *testing.T) {
+	var got CertManagerSyncRequest
+	var encoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		var body io.Reader = r.Body
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		if err := json.NewDecoder(body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.compress.Store(true) // api.compression: gzip
+	var size, sent int
+	c.SetPayloadObserver(func(kind string, s, n int) { size, sent = s, n })
+
+	deleted := []CertManagerCertificateRef{{Namespace: "default", Name: "old"}}
+	if _, err := c.SyncCertManagerChanges(context.Background(), "cluster", manyCertificates(50), deleted); err != nil {
+		t.Fatalf("SyncCertManagerChanges() error = %v", err)
+	}
+	if encoding != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", encoding)
+	}
+	if got.Mode != SyncModeDelta || len(got.Certificates) != 50 || len(got.Deleted) != 1 || got.Deleted[0].Name != "old" {
+		t.Errorf("request = mode %q, %d certificates, deleted %v; want delta with 50 and [old]",
+			got.Mode, len(got.Certificates), got.Deleted)
+	}
+	if sent == 0 || sent >= size {
+		t.Errorf("sent %d bytes for a %d byte payload, want fewer", sent, size)
+	}
+}
+
+func TestCompression_FallsBackWhenUnsupported(t *testing.T) {
+	var encodings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL)
+	c.compress.Store(true) // api.compression: gzip
+	for range 2 {
+		if _, err := c.SyncCertManagerCertificates(context.Background(), "cluster", manyCertificates(50)); err != nil {
+			t.Fatalf("SyncCertManagerCertificates() error = %v", err)
+		}
+	}
+
+	// Compression stays off after the first rejection
+	want := []string{"gzip", "", ""}
+	if fmt.Sprint(encodings) != fmt.Sprint(want) {
+		t.Errorf("Content-Encoding sequence = %q, want %q", encodings, want)
+	}
+}
+
+func TestBuildSyncRequest_TLS(t *testing.T) {
+	c := newTestClient(t, "http://unused")
+	certs := []config.CertificateConfig{{Hostname: "example.com", Port: 443}}
+	results := []scanner.ScanResult{{
+		Hostname:    "example.com",
+		Port:        443,
+		Success:     true,
+		Certificate: &scanner.CertificateInfo{Subject: "example.com"},
+		TLS: &scanner.TLSInfo{
+			Version:           "TLS 1.2",
+			CipherSuite:       "TLS_RSA_WITH_AES_128_CBC_SHA",
+			SupportedVersions: []string{"TLS 1.1", "TLS 1.2"},
+			Issues:            []scanner.ChainIssue{{Type: scanner.IssueDeprecatedProtocol, Message: "Server accepts deprecated protocol TLS 1.1"}},
+			Audited:           true,
+		},
+	}}
+
+	req := c.buildSyncRequest(certs, results)
+
+	got := req.Certificates[0].TLS
+	if got == nil {
+		t.Fatal("TLS = nil, want TLS details")
+	}
+	if got.Version != "TLS 1.2" || !got.Audited || len(got.SupportedVersions) != 2 {
+		t.Errorf("TLS = %+v, want audited TLS 1.2 with 2 versions", got)
+	}
+	if len(got.Issues) != 1 || got.Issues[0].Type != scanner.IssueDeprecatedProtocol {
+		t.Errorf("Issues = %+v, want deprecated_protocol", got.Issues)
+	}
+}
+
+func TestBuildSyncRequest_ServerNames(t *testing.T) {
+	c := newTestClient(t, "http://unused")
+	certs := []config.CertificateConfig{
+		{Hostname: "lb.example.com", Address: "10.0.0.1", ServerName: "a.example.com", Port: 443},
+		{Hostname: "lb.example.com", Address: "10.0.0.1", ServerName: config.NoSNI, Port: 443},
+	}
+	results := []scanner.ScanResult{
+		{Hostname: "lb.example.com", ServerName: config.NoSNI, Port: 443, Success: true, Certificate: &scanner.CertificateInfo{Subject: "default"}},
+		{Hostname: "lb.example.com", ServerName: "a.example.com", Port: 443, Success: true, Certificate: &scanner.CertificateInfo{Subject: "a.example.com"}},
+	}
+
+	req := c.buildSyncRequest(certs, results)
+
+	for i, want := range []string{"a.example.com", "default"} {
+		got := req.Certificates[i]
+		if got.Subject != want || got.ServerName != certs[i].ServerName || got.Address != "10.0.0.1" {
+			t.Errorf("Certificates[%d] = %+v, want subject %s for server name %s", i, got, want, certs[i].ServerName)
+		}
+	}
+}
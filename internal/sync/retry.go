@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetryPolicy controls how failed API calls are retried
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first (1 disables retries)
+	InitialBackoff time.Duration // Backoff before the first retry
+	MaxBackoff     time.Duration // Upper bound for a single backoff
+}
+
+// DefaultRetryPolicy is used when no retry policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// Retry reasons reported to the retry observer
+const (
+	RetryReasonNetwork     = "network"
+	RetryReasonRateLimited = "rate_limited"
+	RetryReasonServerError = "server_error"
+	RetryReasonTimeout     = "timeout"
+)
+
+// StatusError is returned when the API responds with an error status code
+type StatusError struct {
+	err        error
+	StatusCode int
+	RetryAfter time.Duration // Parsed Retry-After header, 0 if absent
+}
+
+func (e *StatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.err
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// classifyRetry reports whether err is retryable, why, and any server-requested delay.
+// Errors not wrapped with ErrAPIUnavailable (4xx responses, marshaling errors,
+// canceled contexts) are permanent.
+func classifyRetry(ctx context.Context, err error) (retryable bool, reason string, retryAfter time.Duration) {
+	if ctx.Err() != nil || !errors.Is(err, ErrAPIUnavailable) {
+		return false, "", 0
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true, RetryReasonNetwork, 0
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusTooManyRequests:
+		reason = RetryReasonRateLimited
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		reason = RetryReasonTimeout
+	default:
+		reason = RetryReasonServerError
+	}
+	return true, reason, statusErr.RetryAfter
+}
+
+// parseRetryAfter parses a Retry-After header given as delay-seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// backoff returns a jittered exponential delay for the given retry (1-based).
+// The delay is drawn uniformly from [d/2, d] where d doubles on each retry up to MaxBackoff.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(d-half)+1))
+}
+
+// withRetry runs fn until it succeeds, fails permanently, exhausts MaxAttempts, or the
+// next backoff would outlive the context. Retry-After from the server takes precedence
+// over the computed backoff; a Retry-After beyond MaxBackoff ends the retries so the
+// caller (or the outbox) can try again later instead of blocking.
+func (c *Client) withRetry(ctx context.Context, op string, fn func() ([]byte, error)) ([]byte, error) {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, err := fn()
+		if err == nil {
+			return body, nil
+		}
+
+		retryable, reason, retryAfter := classifyRetry(ctx, err)
+		if !retryable || attempt >= attempts {
+			return nil, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > c.retry.MaxBackoff {
+				return nil, err
+			}
+			delay = retryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+
+		c.logger.Debug("retrying API call",
+			zap.String("operation", op),
+			zap.String("reason", reason),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		if c.onRetry != nil {
+			c.onRetry(op, reason)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// SetRetryObserver registers a callback invoked before each retry, e.g. to record metrics
+func (c *Client) SetRetryObserver(fn func(operation, reason string)) {
+	c.onRetry = fn
+}
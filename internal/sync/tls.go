@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	gosync "sync"
+	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+)
+
+// newTLSConfig builds the client TLS configuration for the API connection
+func newTLSConfig(cfg config.APITLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: cfg.MinTLSVersion()}
+
+	if cfg.CAFile != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in ca_file")
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if cfg.CertFile != "" {
+		cert := &clientCertificate{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+		tlsConfig.GetClientCertificate = cert.get
+	}
+
+	return tlsConfig, nil
+}
+
+// clientCertificate loads the API client certificate and reloads it when either
+// file changes, so rotated certificates are used on the next connection
+// Fields are ordered for optimal memory alignment
+type clientCertificate struct {
+	certModTime time.Time
+	keyModTime  time.Time
+	cert        *tls.Certificate
+	certFile    string
+	keyFile     string
+	mu          gosync.Mutex
+}
+
+// get returns the current certificate, for use as tls.Config.GetClientCertificate.
+// While the files are being replaced the previous certificate is kept.
+func (c *clientCertificate) get(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certInfo, certErr := os.Stat(c.certFile)
+	keyInfo, keyErr := os.Stat(c.keyFile)
+	if certErr == nil && keyErr == nil && c.cert != nil &&
+		certInfo.ModTime().Equal(c.certModTime) && keyInfo.ModTime().Equal(c.keyModTime) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	c.cert = &cert
+	if certErr == nil && keyErr == nil {
+		c.certModTime = certInfo.ModTime()
+		c.keyModTime = keyInfo.ModTime()
+	}
+	return c.cert, nil
+}
@@ -0,0 +1,201 @@
+package sync
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/certwatch-app/cw-agent/internal/config"
+	"github.com/certwatch-app/cw-agent/internal/state"
+)
+
+// testCA issues server and client certificates for the mTLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate for commonName signed by the CA, as PEM
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("failed to set mtime of %s: %v", path, err)
+	}
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), time.Now())
+
+	serverCert, serverKey := ca.issue(t, "api.test", x509.ExtKeyUsageServerAuth)
+	pair, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatalf("failed to load server certificate: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	var mu gosync.Mutex
+	var clients []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+		mu.Unlock()
+		w.Write([]byte(`{"success":true}`))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS13,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	certPEM, keyPEM := ca.issue(t, "agent-1", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, time.Now().Add(-time.Minute))
+	writeFile(t, keyFile, keyPEM, time.Now().Add(-time.Minute))
+
+	cfg := &ClientConfig{
+		Endpoint: srv.URL,
+		APIKey:   "cw_test",
+		Timeout:  5 * time.Second,
+		Retry:    RetryPolicy{MaxAttempts: 1},
+		TLS:      config.APITLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: caFile, MinVersion: "1.3"},
+	}
+	c := newClient(t, cfg)
+
+	send := func() {
+		t.Helper()
+		if err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Issued"}}); err != nil {
+			t.Fatalf("SyncCertManagerEvents() error = %v", err)
+		}
+	}
+	send()
+
+	// Rotate the client certificate; new connections present the new one
+	certPEM, keyPEM = ca.issue(t, "agent-2", x509.ExtKeyUsageClientAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	c.httpClient.CloseIdleConnections()
+	send()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(clients) != 2 || clients[0] != "agent-1" || clients[1] != "agent-2" {
+		t.Errorf("client certificates = %v, want [agent-1 agent-2]", clients)
+	}
+}
+
+func TestClient_UntrustedAPI(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"success":true}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // The rejected handshake is expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Trusting an unrelated CA must not make the test server trusted
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: newTestCA(t).cert.Raw}), time.Now())
+
+	cfg := &ClientConfig{
+		Endpoint: srv.URL,
+		APIKey:   "cw_test",
+		Retry:    RetryPolicy{MaxAttempts: 1},
+		TLS:      config.APITLSConfig{CAFile: caFile},
+	}
+	c := newClient(t, cfg)
+
+	if err := c.SyncCertManagerEvents(context.Background(), "cluster", []CertManagerEvent{{Reason: "Issued"}}); err == nil {
+		t.Error("SyncCertManagerEvents() error = nil, want certificate verification failure")
+	}
+}
+
+func TestNewWithConfig_InvalidTLS(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeFile(t, caFile, []byte("not a certificate"), time.Now())
+
+	cfg := &ClientConfig{Endpoint: "https://api.example.com", APIKey: "cw_test", TLS: config.APITLSConfig{CAFile: caFile}}
+	if _, err := NewWithConfig(cfg, "test-agent", zap.NewNop(), state.NewManagerWithStateDir(t.TempDir())); err == nil {
+		t.Error("NewWithConfig() error = nil, want error for an invalid CA bundle")
+	}
+}
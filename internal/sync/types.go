@@ -2,9 +2,43 @@
 package sync
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/certwatch-app/cw-agent/internal/version"
+)
+
+// Payload kinds, used to route queued outbox entries back to their endpoint
+const (
+	KindCertificates            = "certificates"
+	KindCertificateEvents       = "certificate_events"
+	KindCertManagerCertificates = "certmanager_certificates"
+	KindCertManagerEvents       = "certmanager_events"
+	KindCertManagerRequests     = "certmanager_requests"
+	KindCertManagerIssuers      = "certmanager_issuers"
+	KindCertManagerACME         = "certmanager_acme"
 )
 
+// kindPaths maps each payload kind to its API path
+var kindPaths = map[string]string{
+	KindCertificates:            "/api/v1/agent/sync",
+	KindCertificateEvents:       "/api/v1/agent/events",
+	KindCertManagerCertificates: "/api/v1/agent/certmanager/sync",
+	KindCertManagerEvents:       "/api/v1/agent/certmanager/events",
+	KindCertManagerRequests:     "/api/v1/agent/certmanager/requests",
+	KindCertManagerIssuers:      "/api/v1/agent/certmanager/issuers",
+	KindCertManagerACME:         "/api/v1/agent/certmanager/acme",
+}
+
+// userAgentFor returns the User-Agent header for the binary that produces the given payload kind
+func userAgentFor(kind string) string {
+	if strings.HasPrefix(kind, "certmanager") {
+		return fmt.Sprintf("cw-agent-certmanager/%s", version.GetVersion())
+	}
+	return fmt.Sprintf("cw-agent/%s", version.GetVersion())
+}
+
 // SyncRequest represents the agent sync request payload
 type SyncRequest struct {
 	AgentID                  string                `json:"agent_id,omitempty"`
@@ -23,8 +57,12 @@ type CertificateSyncData struct {
 	NotAfter          *time.Time       `json:"not_after,omitempty"`
 	LastCheckAt       *time.Time       `json:"last_check_at,omitempty"`
 	ChainValid        *bool            `json:"chain_valid,omitempty"`
+	TLS               *TLSSyncData     `json:"tls,omitempty"`
 	Hostname          string           `json:"hostname"`
+	Address           string           `json:"address,omitempty"`     // Host or IP connected to instead of hostname
+	ServerName        string           `json:"server_name,omitempty"` // SNI sent instead of hostname, "-" for none
 	Notes             string           `json:"notes,omitempty"`
+	StartTLS          string           `json:"starttls,omitempty"`
 	Subject           string           `json:"subject,omitempty"`
 	Issuer            string           `json:"issuer,omitempty"`
 	IssuerOrg         string           `json:"issuer_org,omitempty"`
@@ -34,6 +72,7 @@ type CertificateSyncData struct {
 	Tags              []string         `json:"tags,omitempty"`
 	SANList           []string         `json:"san_list,omitempty"`
 	ChainIssues       []ChainIssueData `json:"chain_issues,omitempty"`
+	Addresses         []AddressData    `json:"addresses,omitempty"` // Every resolved address when scanned with all_addresses
 	Port              int              `json:"port"`
 }
 
@@ -44,6 +83,48 @@ type ChainIssueData struct {
 	CertificateIndex int    `json:"certificate_index,omitempty"`
 }
 
+// AddressData is the certificate served by one resolved address of a hostname
+// Fields are ordered for optimal memory alignment
+type AddressData struct {
+	NotAfter          *time.Time `json:"not_after,omitempty"`
+	Address           string     `json:"address"`
+	FingerprintSHA256 string     `json:"fingerprint_sha256,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// TLSSyncData describes the negotiated TLS connection and the audit of accepted
+// protocol versions and cipher suites
+// Fields are ordered for optimal memory alignment
+type TLSSyncData struct {
+	Version           string           `json:"version"`
+	CipherSuite       string           `json:"cipher_suite"`
+	ALPN              string           `json:"alpn,omitempty"`
+	SupportedVersions []string         `json:"supported_versions,omitempty"`
+	CipherSuites      []string         `json:"cipher_suites,omitempty"`
+	Issues            []ChainIssueData `json:"issues,omitempty"`
+	Audited           bool             `json:"audited"`
+}
+
+// CertificateEventSyncRequest is the request for syncing certificate change events
+type CertificateEventSyncRequest struct {
+	AgentID   string             `json:"agent_id,omitempty"`
+	AgentName string             `json:"agent_name"`
+	Events    []CertificateEvent `json:"events"`
+}
+
+// CertificateEvent is a change to the certificate served by a monitored target
+// Fields are ordered for optimal memory alignment
+type CertificateEvent struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Hostname   string    `json:"hostname"`
+	ServerName string    `json:"server_name,omitempty"`
+	Type       string    `json:"type"` // One of the history.Change kinds
+	Message    string    `json:"message"`
+	Previous   string    `json:"previous,omitempty"` // Fingerprint, expiry, issuer, key type or names before the change
+	Current    string    `json:"current,omitempty"`  // The same after the change
+	Port       int       `json:"port"`
+}
+
 // SyncResponse represents the API response from sync
 // Fields are ordered for optimal memory alignment
 type SyncResponse struct {
@@ -95,13 +176,29 @@ type HeartbeatResponse struct {
 	ServerTime time.Time `json:"server_time"`
 }
 
+// Cert-manager sync modes. A full sync lists every certificate and the API
+// removes the ones not listed; a delta sync lists only changed certificates
+// and the deleted ones.
+const (
+	SyncModeFull  = "full"
+	SyncModeDelta = "delta"
+)
+
 // CertManagerSyncRequest is the request for syncing cert-manager certificates
 type CertManagerSyncRequest struct {
-	AgentID      string                   `json:"agent_id,omitempty"`
-	AgentName    string                   `json:"agent_name"`
-	AgentVersion string                   `json:"agent_version,omitempty"`
-	ClusterName  string                   `json:"cluster_name"`
-	Certificates []CertManagerCertificate `json:"certificates"`
+	AgentID      string                      `json:"agent_id,omitempty"`
+	AgentName    string                      `json:"agent_name"`
+	AgentVersion string                      `json:"agent_version,omitempty"`
+	ClusterName  string                      `json:"cluster_name"`
+	Mode         string                      `json:"mode"`
+	Certificates []CertManagerCertificate    `json:"certificates"`
+	Deleted      []CertManagerCertificateRef `json:"deleted,omitempty"` // Delta syncs only
+}
+
+// CertManagerCertificateRef identifies a cert-manager certificate
+type CertManagerCertificateRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
 }
 
 // CertManagerCertificate is a cert-manager certificate for sync
@@ -120,6 +217,9 @@ type CertManagerCertificate struct {
 	IssuerKind  string `json:"issuer_kind"`
 	IssuerGroup string `json:"issuer_group,omitempty"`
 
+	// Selected labels and annotations, e.g. team or owner
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Status
 	Ready       bool   `json:"ready"`
 	ReadyReason string `json:"ready_reason,omitempty"`
@@ -133,6 +233,27 @@ type CertManagerCertificate struct {
 	// Health
 	Revision       int `json:"revision"`
 	FailedAttempts int `json:"failed_attempts"`
+
+	// Secret contents (omitted when secret scanning is disabled)
+	Secret *CertManagerSecret `json:"secret,omitempty"`
+}
+
+// CertManagerSecret describes the certificate stored in a Certificate's Secret
+type CertManagerSecret struct {
+	NotBefore         *time.Time       `json:"not_before,omitempty"`
+	NotAfter          *time.Time       `json:"not_after,omitempty"`
+	Subject           string           `json:"subject,omitempty"`
+	Issuer            string           `json:"issuer,omitempty"`
+	SerialNumber      string           `json:"serial_number,omitempty"`
+	FingerprintSHA256 string           `json:"fingerprint_sha256,omitempty"`
+	KeyAlgorithm      string           `json:"key_algorithm,omitempty"`
+	Error             string           `json:"error,omitempty"`
+	SANList           []string         `json:"san_list,omitempty"`
+	ChainIssues       []ChainIssueData `json:"chain_issues,omitempty"`
+	Drift             []ChainIssueData `json:"drift,omitempty"`
+	KeySize           int              `json:"key_size,omitempty"`
+	ChainLength       int              `json:"chain_length"`
+	ChainValid        bool             `json:"chain_valid"`
 }
 
 // CertManagerSyncResponse is the response from the cert-manager sync endpoint
@@ -168,6 +289,7 @@ type CertManagerEventSyncRequest struct {
 type CertManagerEvent struct {
 	CertificateNamespace string    `json:"certificate_namespace"`
 	CertificateName      string    `json:"certificate_name"`
+	Key                  string    `json:"key,omitempty"` // Stable across retries, for deduplication
 	Reason               string    `json:"reason"`
 	Message              string    `json:"message"`
 	Type                 string    `json:"event_type"` // Normal, Warning
@@ -231,3 +353,84 @@ type CertManagerRequestSyncResponseData struct {
 	SyncedAt       time.Time `json:"synced_at"`
 	RequestsStored int       `json:"requests_stored"`
 }
+
+// ============================================================================
+// Issuer Sync Types
+// ============================================================================
+
+// CertManagerIssuerSyncRequest is the request for syncing Issuers and ClusterIssuers
+type CertManagerIssuerSyncRequest struct {
+	AgentID     string              `json:"agent_id,omitempty"`
+	AgentName   string              `json:"agent_name"`
+	ClusterName string              `json:"cluster_name"`
+	Issuers     []CertManagerIssuer `json:"issuers"`
+}
+
+// CertManagerIssuer represents a cert-manager Issuer or ClusterIssuer for sync
+type CertManagerIssuer struct {
+	Namespace      string     `json:"namespace,omitempty"` // Empty for ClusterIssuer
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"` // Issuer or ClusterIssuer
+	Type           string     `json:"type"` // ACME, CA, Vault, Venafi, SelfSigned
+	Ready          bool       `json:"ready"`
+	ReadyReason    string     `json:"ready_reason,omitempty"`
+	ReadyMessage   string     `json:"ready_message,omitempty"`
+	LastTransition *time.Time `json:"last_transition,omitempty"`
+	ACMEServer     string     `json:"acme_server,omitempty"`
+	ACMEEmail      string     `json:"acme_email,omitempty"`
+	ACMEAccountURI string     `json:"acme_account_uri,omitempty"`
+	ACMERegistered bool       `json:"acme_registered,omitempty"`
+}
+
+// ============================================================================
+// ACME Order and Challenge Sync Types
+// ============================================================================
+
+// CertManagerACMESyncRequest is the request for syncing ACME Orders and Challenges
+type CertManagerACMESyncRequest struct {
+	AgentID     string                     `json:"agent_id,omitempty"`
+	AgentName   string                     `json:"agent_name"`
+	ClusterName string                     `json:"cluster_name"`
+	Orders      []CertManagerACMEOrder     `json:"orders"`
+	Challenges  []CertManagerACMEChallenge `json:"challenges"`
+}
+
+// CertManagerACMEOrder represents an ACME Order for sync
+type CertManagerACMEOrder struct {
+	Namespace          string     `json:"namespace"`
+	Name               string     `json:"name"`
+	CertificateRequest string     `json:"certificate_request,omitempty"`
+	CertificateName    string     `json:"certificate_name,omitempty"`
+	IssuerName         string     `json:"issuer_name"`
+	IssuerKind         string     `json:"issuer_kind"`
+	DNSNames           []string   `json:"dns_names,omitempty"`
+	URL                string     `json:"url,omitempty"`
+	State              string     `json:"state,omitempty"`   // pending, ready, valid, invalid, errored, expired
+	Reason             string     `json:"reason,omitempty"`  // ACME error
+	Problem            string     `json:"problem,omitempty"` // rate_limited, dns, connection, unauthorized, caa, rejected, other
+	Failed             bool       `json:"failed"`
+	Deleted            bool       `json:"deleted,omitempty"` // The Order was removed; the rest is its last state
+	CreatedAt          time.Time  `json:"created_at"`
+	FailureTime        *time.Time `json:"failure_time,omitempty"`
+}
+
+// CertManagerACMEChallenge represents an ACME Challenge for sync
+type CertManagerACMEChallenge struct {
+	Namespace          string    `json:"namespace"`
+	Name               string    `json:"name"`
+	OrderName          string    `json:"order_name,omitempty"`
+	CertificateRequest string    `json:"certificate_request,omitempty"`
+	CertificateName    string    `json:"certificate_name,omitempty"`
+	DNSName            string    `json:"dns_name"`
+	Wildcard           bool      `json:"wildcard"`
+	Type               string    `json:"type"`   // HTTP-01 or DNS-01
+	Solver             string    `json:"solver"` // e.g. http01-ingress, dns01-route53
+	State              string    `json:"state,omitempty"`
+	Reason             string    `json:"reason,omitempty"`
+	Problem            string    `json:"problem,omitempty"` // Adds dns_propagation to the Order problems
+	Processing         bool      `json:"processing"`
+	Presented          bool      `json:"presented"`
+	Failed             bool      `json:"failed"`
+	Deleted            bool      `json:"deleted,omitempty"` // The Challenge was removed; the rest is its last state
+	CreatedAt          time.Time `json:"created_at"`
+}